@@ -0,0 +1,154 @@
+// Comando jwtkeys: genera y rota las claves de firma de los JWT en JWT_KEYS_DIR.
+//
+// Una rotación sin cortes:
+//
+//	jwtkeys generate          # la clave nueva se publica en el JWKS pero aún no firma
+//	(reiniciar los servidores y esperar a que los verificadores refresquen el JWKS)
+//	jwtkeys activate <kid>    # la clave nueva firma; la anterior sigue verificando
+//	(reiniciar los servidores y esperar JWT_REFRESH_EXPIRATION)
+//	jwtkeys remove <kid-anterior>
+//
+// rotate hace generate y activate de una vez, cuando nadie más verifica nuestros tokens.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/juank/attendance-backend/pkg/jwtkeys"
+)
+
+const usage = `Usage: jwtkeys [-dir DIR] [-alg RS256|EdDSA] <command> [kid]
+
+Commands:
+  list            List the keys and which one signs
+  generate        Add a key that verifies but does not sign yet (signs if it is the first)
+  activate KID    Sign new tokens with the key
+  rotate          Generate a key and activate it
+  retire KID      Drop the private part of an inactive key; it keeps verifying
+  remove KID      Delete an inactive key; tokens it signed stop being valid
+
+Servers load the keys at startup: restart them after every change.
+`
+
+func main() {
+	// Cargar variables de entorno desde .env (si existe) para tomar JWT_KEYS_DIR
+	_ = godotenv.Load()
+
+	defaultDir := os.Getenv("JWT_KEYS_DIR")
+	if defaultDir == "" {
+		defaultDir = "keys"
+	}
+	dir := flag.String("dir", defaultDir, "key directory (JWT_KEYS_DIR)")
+	alg := flag.String("alg", jwtkeys.AlgRS256, "algorithm of generated keys: RS256 or EdDSA")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	if err := run(*dir, *alg, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "jwtkeys:", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir, alg string, args []string) error {
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	command, args := args[0], args[1:]
+
+	// Los comandos que reciben un kid exigen exactamente uno
+	kid := ""
+	switch command {
+	case "activate", "retire", "remove":
+		if len(args) != 1 {
+			return fmt.Errorf("%s needs a key id", command)
+		}
+		kid = args[0]
+	default:
+		if len(args) != 0 {
+			return fmt.Errorf("%s takes no arguments", command)
+		}
+	}
+
+	switch command {
+	case "list":
+		return list(dir)
+	case "generate":
+		key, err := generate(dir, alg)
+		if err != nil {
+			return err
+		}
+		// La primera clave firma directamente: no hay tokens que verificar con otra
+		if _, active, err := jwtkeys.LoadDir(dir); err != nil || active != "" {
+			return err
+		}
+		return activate(dir, key.ID)
+	case "activate":
+		return activate(dir, kid)
+	case "rotate":
+		key, err := generate(dir, alg)
+		if err != nil {
+			return err
+		}
+		return activate(dir, key.ID)
+	case "retire":
+		if err := jwtkeys.Retire(dir, kid); err != nil {
+			return err
+		}
+		fmt.Printf("Retired %s; it only verifies now\n", kid)
+		return nil
+	case "remove":
+		if err := jwtkeys.Remove(dir, kid); err != nil {
+			return err
+		}
+		fmt.Printf("Removed %s\n", kid)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+func list(dir string) error {
+	keys, active, err := jwtkeys.LoadDir(dir)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		fmt.Println("No keys. Create one with: jwtkeys rotate")
+		return nil
+	}
+	for _, key := range keys {
+		status := "verifies"
+		switch {
+		case key.ID == active:
+			status = "signs"
+		case key.Private == nil:
+			status = "verifies (retired)"
+		}
+		fmt.Printf("%-20s %-6s %s\n", key.ID, key.Algorithm, status)
+	}
+	return nil
+}
+
+func generate(dir, alg string) (*jwtkeys.Key, error) {
+	key, err := jwtkeys.Generate(alg)
+	if err != nil {
+		return nil, err
+	}
+	if err := jwtkeys.Save(dir, key); err != nil {
+		return nil, err
+	}
+	fmt.Printf("Generated %s key %s in %s\n", key.Algorithm, key.ID, dir)
+	return key, nil
+}
+
+func activate(dir, kid string) error {
+	if err := jwtkeys.SetActive(dir, kid); err != nil {
+		return err
+	}
+	fmt.Printf("Key %s signs new tokens\n", kid)
+	return nil
+}
@@ -1,21 +1,28 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/juank/attendance-backend/config"
+	"github.com/juank/attendance-backend/internal/application/jobs"
 	"github.com/juank/attendance-backend/internal/application/services"
 	"github.com/juank/attendance-backend/internal/infrastructure/database"
 	"github.com/juank/attendance-backend/internal/infrastructure/persistence"
 	"github.com/juank/attendance-backend/internal/interfaces/api/handlers"
 	"github.com/juank/attendance-backend/internal/interfaces/api/routes"
+	"github.com/juank/attendance-backend/pkg/jwtkeys"
 	"github.com/juank/attendance-backend/pkg/logger"
+	"github.com/juank/attendance-backend/pkg/mail"
+	"github.com/juank/attendance-backend/pkg/oidc"
+	"github.com/juank/attendance-backend/pkg/pubsub"
 	"go.uber.org/zap"
 )
 
@@ -56,33 +63,120 @@ func main() {
 	refreshTokenRepo := persistence.NewRefreshTokenRepository(db)
 	qrRepo := persistence.NewQRCodeRepository(db)
 	eventRepo := persistence.NewEventRepository(db)
+	participantRepo := persistence.NewEventParticipantRepository(db)
+	leaveRepo := persistence.NewLeaveRequestRepository(db)
+	correctionRepo := persistence.NewAttendanceCorrectionRepository(db)
+	seriesRepo := persistence.NewEventSeriesRepository(db)
+	calendarTokenRepo := persistence.NewCalendarTokenRepository(db)
+	webhookRepo := persistence.NewWebhookRepository(db)
+	webhookDeliveryRepo := persistence.NewWebhookDeliveryRepository(db)
+	roleRepo := persistence.NewRoleRepository(db)
+	auditRepo := persistence.NewAuditLogRepository(db)
+	passwordResetRepo := persistence.NewPasswordResetTokenRepository(db)
+	recoveryCodeRepo := persistence.NewMFARecoveryCodeRepository(db)
+	revokedSessionRepo := persistence.NewRevokedSessionRepository(db)
+	oidcRequestRepo := persistence.NewOIDCAuthRequestRepository(db)
+
+	// Inicializar envío de correo
+	mailer, err := newMailSender(cfg)
+	if err != nil {
+		logger.Fatal("Failed to initialize mail sender", zap.Error(err))
+	}
+
+	// Cargar las claves de firma de los JWT
+	keys, err := jwtkeys.Load(cfg.JWT.KeysDir, cfg.JWT.Secret)
+	if err != nil {
+		logger.Fatal("Failed to load JWT signing keys", zap.Error(err))
+	}
+
+	// Inicio de sesión con el proveedor de identidad, si está configurado
+	var sso *oidc.Provider
+	if cfg.OIDC.Issuer != "" {
+		sso = oidc.NewProvider(oidc.Config{
+			Issuer:       cfg.OIDC.Issuer,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+			Scopes:       cfg.OIDC.Scopes,
+			GroupsClaim:  cfg.OIDC.GroupsClaim,
+		})
+	}
 
 	// Inicializar Servicios
-	authService := services.NewAuthService(userRepo, refreshTokenRepo, cfg)
-	userService := services.NewUserService(userRepo)
-	deptService := services.NewDepartmentService(deptRepo)
-	qrService := services.NewQRService(qrRepo)
-	attendanceService := services.NewAttendanceService(attendanceRepo, qrService)
-	eventService := services.NewEventService(eventRepo)
+	auditService := services.NewAuditService(auditRepo)
+	roleService := services.NewRoleService(roleRepo, auditService)
+	webhookService := services.NewWebhookService(webhookRepo, webhookDeliveryRepo, auditService, cfg)
+	liveHub := pubsub.NewHub(pubsub.DefaultBuffer)
+	livePublisher := services.NewLivePublisher(liveHub, attendanceRepo, userRepo)
+	tokenRevocation := services.NewTokenRevocation(userRepo, revokedSessionRepo, cfg.JWT.Expiration)
+	authService := services.NewAuthService(userRepo, refreshTokenRepo, passwordResetRepo, recoveryCodeRepo, oidcRequestRepo, deptRepo, roleService, tokenRevocation, keys, sso, mailer, auditService, cfg)
+	userService := services.NewUserService(userRepo, deptRepo, refreshTokenRepo, roleService, tokenRevocation, webhookService, auditService)
+	deptService := services.NewDepartmentService(deptRepo, auditService)
+	qrService := services.NewQRService(qrRepo, eventRepo, livePublisher, auditService)
+	qrImageService := services.NewQRImageService(qrService, eventRepo)
+	participantService := services.NewEventParticipantService(participantRepo, eventRepo, userRepo, deptRepo, roleService, auditService)
+	attendanceService := services.NewAttendanceService(attendanceRepo, eventRepo, userRepo, leaveRepo, qrService, participantService, webhookService, livePublisher, auditService)
+	leaveService := services.NewLeaveService(leaveRepo, userRepo, deptRepo, attendanceRepo, roleService, auditService)
+	correctionService := services.NewCorrectionService(correctionRepo, attendanceRepo, userRepo, deptRepo, roleService, webhookService, auditService)
+	reportService := services.NewReportService(attendanceRepo, deptRepo, roleService)
+	exportService := services.NewExportService(attendanceRepo, eventRepo, userRepo)
+	eventService := services.NewEventService(eventRepo, seriesRepo, webhookService, auditService)
+	recurrenceHorizon := time.Duration(cfg.Jobs.RecurrenceHorizonDays) * 24 * time.Hour
+	seriesService := services.NewEventSeriesService(seriesRepo, eventRepo, attendanceRepo, participantRepo, webhookService, auditService, recurrenceHorizon)
+	calendarService := services.NewCalendarService(calendarTokenRepo, eventRepo, seriesRepo, participantRepo, roleService, auditService)
+	liveService := services.NewLiveService(liveHub, eventRepo, attendanceRepo, qrService)
 
 	// Inicializar Handlers
 	authHandler := handlers.NewAuthHandler(authService)
 	userHandler := handlers.NewUserHandler(userService)
 	deptHandler := handlers.NewDepartmentHandler(deptService)
-	qrHandler := handlers.NewQRHandler(qrService)
+	qrHandler := handlers.NewQRHandler(qrService, qrImageService)
 	attendanceHandler := handlers.NewAttendanceHandler(attendanceService, qrService)
-	eventHandler := handlers.NewEventHandler(eventService, attendanceService)
+	eventHandler := handlers.NewEventHandler(eventService, seriesService, attendanceService)
+	participantHandler := handlers.NewEventParticipantHandler(participantService)
+	leaveHandler := handlers.NewLeaveHandler(leaveService)
+	correctionHandler := handlers.NewCorrectionHandler(correctionService)
+	reportHandler := handlers.NewReportHandler(reportService)
+	exportHandler := handlers.NewExportHandler(exportService)
+	seriesHandler := handlers.NewEventSeriesHandler(seriesService)
+	calendarHandler := handlers.NewCalendarHandler(calendarService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	liveHandler := handlers.NewLiveHandler(liveService)
+	roleHandler := handlers.NewRoleHandler(roleService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	jwksHandler := handlers.NewJWKSHandler(keys)
+
+	// Iniciar jobs en segundo plano
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	absenceJob := jobs.NewAbsenceJob(eventRepo, attendanceService, cfg.Jobs.AbsenceInterval)
+	absenceJob.Start(jobsCtx)
+
+	recurrenceJob := jobs.NewRecurrenceJob(seriesService, cfg.Jobs.RecurrenceInterval)
+	recurrenceJob.Start(jobsCtx)
+
+	webhookJob := jobs.NewWebhookJob(webhookService, cfg.Webhooks.DispatchInterval)
+	webhookJob.Start(jobsCtx)
 
 	// Configurar Gin según el entorno
 	if cfg.Server.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	// Crear router
-	engine := gin.Default()
+	// Crear router. Sin gin.Default: su logger escribe la URL completa, con los tokens de
+	// los feeds de calendario y del stream en vivo; el router instala el logger propio, que
+	// los oculta, y el middleware de recuperación
+	engine := gin.New()
+
+	// Solo se cree X-Forwarded-For si viene de un proxy configurado; si no, cualquiera
+	// podría falsear su IP para saltarse los límites por IP o bloquear la de otro
+	if err := engine.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		logger.Fatal("Invalid TRUSTED_PROXIES", zap.Error(err))
+	}
 
 	// Configurar rutas
-	router := routes.NewRouter(cfg, authHandler, userHandler, deptHandler, attendanceHandler, qrHandler, eventHandler)
+	router := routes.NewRouter(cfg, roleService, tokenRevocation, keys, authHandler, userHandler, deptHandler, attendanceHandler, qrHandler, eventHandler, participantHandler, leaveHandler, correctionHandler, reportHandler, exportHandler, seriesHandler, calendarHandler, webhookHandler, liveHandler, roleHandler, auditHandler, jwksHandler)
 	router.Setup(engine)
 
 	// Configurar servidor
@@ -105,5 +199,24 @@ func main() {
 	<-quit
 
 	logger.Info("Shutting down server...")
+	stopJobs()
 	logger.Info("Server stopped")
 }
+
+// newMailSender crea el Sender indicado por MAIL_DRIVER
+func newMailSender(cfg *config.Config) (mail.Sender, error) {
+	switch cfg.Mail.Driver {
+	case "smtp":
+		return mail.NewSMTPSender(mail.SMTPConfig{
+			Host:     cfg.Mail.SMTPHost,
+			Port:     cfg.Mail.SMTPPort,
+			Username: cfg.Mail.SMTPUsername,
+			Password: cfg.Mail.SMTPPassword,
+			From:     cfg.Mail.From,
+		}), nil
+	case "file":
+		return mail.NewFileSender(cfg.Mail.FileDir, cfg.Mail.From)
+	default:
+		return mail.NewLogSender(), nil
+	}
+}
@@ -10,19 +10,29 @@ import (
 )
 
 type Config struct {
-	Server     ServerConfig
-	Database   DatabaseConfig
-	JWT        JWTConfig
-	CORS       CORSConfig
-	Logging    LoggingConfig
-	RateLimit  RateLimitConfig
-	Pagination PaginationConfig
+	Server        ServerConfig
+	Database      DatabaseConfig
+	JWT           JWTConfig
+	CORS          CORSConfig
+	Logging       LoggingConfig
+	RateLimit     RateLimitConfig
+	Login         LoginConfig
+	Pagination    PaginationConfig
+	Jobs          JobsConfig
+	Webhooks      WebhooksConfig
+	Mail          MailConfig
+	PasswordReset PasswordResetConfig
+	MFA           MFAConfig
+	OIDC          OIDCConfig
 }
 
 type ServerConfig struct {
 	Port string
 	Env  string
 	Host string
+	// TrustedProxies son las IPs o CIDR de los proxies cuyo X-Forwarded-For se acepta para
+	// obtener la IP del cliente. Vacío: se usa siempre la IP de la conexión.
+	TrustedProxies []string
 }
 
 type DatabaseConfig struct {
@@ -38,6 +48,9 @@ type JWTConfig struct {
 	Secret            string
 	Expiration        time.Duration
 	RefreshExpiration time.Duration
+	// KeysDir es el directorio de claves RS256/EdDSA (ver cmd/jwtkeys). Si está vacío, los
+	// tokens se firman con Secret (HS256); si no, Secret solo verifica los tokens antiguos.
+	KeysDir string
 }
 
 type CORSConfig struct {
@@ -48,9 +61,19 @@ type LoggingConfig struct {
 	Level string
 }
 
+// RateLimitConfig limita las peticiones por IP a Requests cada Duration (token bucket)
 type RateLimitConfig struct {
 	Requests int
-	Duration string
+	Duration time.Duration
+}
+
+// LoginConfig controla la protección contra ataques de fuerza bruta en el login
+type LoginConfig struct {
+	MaxAttempts     int           // fallos seguidos que bloquean una cuenta
+	IPMaxAttempts   int           // fallos seguidos que bloquean una IP
+	LockoutDuration time.Duration // duración del bloqueo; también olvida los fallos antiguos
+	Delay           time.Duration // espera tras el primer fallo; se duplica con cada fallo
+	MaxDelay        time.Duration // espera máxima entre intentos antes del bloqueo
 }
 
 type PaginationConfig struct {
@@ -58,6 +81,63 @@ type PaginationConfig struct {
 	MaxPageSize     int
 }
 
+type JobsConfig struct {
+	AbsenceInterval       time.Duration
+	RecurrenceInterval    time.Duration
+	RecurrenceHorizonDays int // días por adelantado en que se generan las ocurrencias de eventos recurrentes
+}
+
+type WebhooksConfig struct {
+	DispatchInterval time.Duration // cada cuánto se revisa la cola de entregas
+	Timeout          time.Duration // tiempo máximo de cada petición HTTP
+	MaxAttempts      int           // intentos antes de marcar una entrega como fallida
+}
+
+// MailConfig define cómo se envían los correos. Driver puede ser "smtp", "file" (guarda
+// cada correo como .eml en FileDir) o "log" (solo los escribe en el log).
+type MailConfig struct {
+	Driver       string
+	From         string
+	FileDir      string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+}
+
+type PasswordResetConfig struct {
+	URL        string        // página del frontend que recibe el token como ?token=
+	Expiration time.Duration // validez de cada enlace de restablecimiento
+}
+
+type MFAConfig struct {
+	Issuer              string        // nombre con el que la cuenta aparece en la app de autenticación
+	ChallengeExpiration time.Duration // tiempo para introducir el código tras la contraseña
+}
+
+// OIDCConfig configura el inicio de sesión con un proveedor OpenID Connect. Está
+// desactivado mientras Issuer esté vacío.
+type OIDCConfig struct {
+	Issuer        string
+	ClientID      string
+	ClientSecret  string // vacío si el cliente es público
+	RedirectURL   string // página del frontend que recibe el código y lo envía al backend
+	Scopes        []string
+	GroupsClaim   string
+	AutoProvision bool // crear en el primer inicio de sesión los usuarios que no existan
+	// RoleMapping y DepartmentMapping asignan rol y departamento (por nombre) según los
+	// grupos del usuario; gana la primera regla cuyo grupo tenga
+	RoleMapping       []GroupMapping
+	DepartmentMapping []GroupMapping
+	StateExpiration   time.Duration
+}
+
+// GroupMapping asocia un grupo del proveedor de identidad a un valor
+type GroupMapping struct {
+	Group string
+	Value string
+}
+
 // LoadConfig carga la configuración desde variables de entorno y archivos
 func LoadConfig() (*Config, error) {
 	// Configurar Viper para leer variables de entorno
@@ -84,9 +164,10 @@ func LoadConfig() (*Config, error) {
 	// Mapear configuración a struct
 	config := &Config{
 		Server: ServerConfig{
-			Port: viper.GetString("PORT"),
-			Env:  viper.GetString("ENV"),
-			Host: viper.GetString("HOST"),
+			Port:           viper.GetString("PORT"),
+			Env:            viper.GetString("ENV"),
+			Host:           viper.GetString("HOST"),
+			TrustedProxies: parseList("TRUSTED_PROXIES"),
 		},
 		Database: DatabaseConfig{
 			Host:     viper.GetString("DB_HOST"),
@@ -100,21 +181,74 @@ func LoadConfig() (*Config, error) {
 			Secret:            viper.GetString("JWT_SECRET"),
 			Expiration:        viper.GetDuration("JWT_EXPIRATION"),
 			RefreshExpiration: viper.GetDuration("JWT_REFRESH_EXPIRATION"),
+			KeysDir:           viper.GetString("JWT_KEYS_DIR"),
 		},
 		CORS: CORSConfig{
-			AllowedOrigins: parseAllowedOrigins(),
+			AllowedOrigins: parseList("ALLOWED_ORIGINS"),
 		},
 		Logging: LoggingConfig{
 			Level: viper.GetString("LOG_LEVEL"),
 		},
 		RateLimit: RateLimitConfig{
 			Requests: viper.GetInt("RATE_LIMIT_REQUESTS"),
-			Duration: viper.GetString("RATE_LIMIT_DURATION"),
+			Duration: viper.GetDuration("RATE_LIMIT_DURATION"),
+		},
+		Login: LoginConfig{
+			MaxAttempts:     viper.GetInt("LOGIN_MAX_ATTEMPTS"),
+			IPMaxAttempts:   viper.GetInt("LOGIN_IP_MAX_ATTEMPTS"),
+			LockoutDuration: viper.GetDuration("LOGIN_LOCKOUT_DURATION"),
+			Delay:           viper.GetDuration("LOGIN_DELAY"),
+			MaxDelay:        viper.GetDuration("LOGIN_MAX_DELAY"),
 		},
 		Pagination: PaginationConfig{
 			DefaultPageSize: viper.GetInt("DEFAULT_PAGE_SIZE"),
 			MaxPageSize:     viper.GetInt("MAX_PAGE_SIZE"),
 		},
+		Jobs: JobsConfig{
+			AbsenceInterval:       viper.GetDuration("ABSENCE_JOB_INTERVAL"),
+			RecurrenceInterval:    viper.GetDuration("RECURRENCE_JOB_INTERVAL"),
+			RecurrenceHorizonDays: viper.GetInt("RECURRENCE_HORIZON_DAYS"),
+		},
+		Webhooks: WebhooksConfig{
+			DispatchInterval: viper.GetDuration("WEBHOOK_DISPATCH_INTERVAL"),
+			Timeout:          viper.GetDuration("WEBHOOK_TIMEOUT"),
+			MaxAttempts:      viper.GetInt("WEBHOOK_MAX_ATTEMPTS"),
+		},
+		Mail: MailConfig{
+			Driver:       viper.GetString("MAIL_DRIVER"),
+			From:         viper.GetString("MAIL_FROM"),
+			FileDir:      viper.GetString("MAIL_FILE_DIR"),
+			SMTPHost:     viper.GetString("SMTP_HOST"),
+			SMTPPort:     viper.GetString("SMTP_PORT"),
+			SMTPUsername: viper.GetString("SMTP_USERNAME"),
+			SMTPPassword: viper.GetString("SMTP_PASSWORD"),
+		},
+		PasswordReset: PasswordResetConfig{
+			URL:        viper.GetString("PASSWORD_RESET_URL"),
+			Expiration: viper.GetDuration("PASSWORD_RESET_EXPIRATION"),
+		},
+		MFA: MFAConfig{
+			Issuer:              viper.GetString("MFA_ISSUER"),
+			ChallengeExpiration: viper.GetDuration("MFA_CHALLENGE_EXPIRATION"),
+		},
+		OIDC: OIDCConfig{
+			Issuer:          viper.GetString("OIDC_ISSUER"),
+			ClientID:        viper.GetString("OIDC_CLIENT_ID"),
+			ClientSecret:    viper.GetString("OIDC_CLIENT_SECRET"),
+			RedirectURL:     viper.GetString("OIDC_REDIRECT_URL"),
+			Scopes:          strings.Fields(viper.GetString("OIDC_SCOPES")),
+			GroupsClaim:     viper.GetString("OIDC_GROUPS_CLAIM"),
+			AutoProvision:   viper.GetBool("OIDC_AUTO_PROVISION"),
+			StateExpiration: viper.GetDuration("OIDC_STATE_EXPIRATION"),
+		},
+	}
+
+	var err error
+	if config.OIDC.RoleMapping, err = parseGroupMapping(viper.GetString("OIDC_ROLE_MAPPING")); err != nil {
+		return nil, fmt.Errorf("invalid configuration: OIDC_ROLE_MAPPING: %w", err)
+	}
+	if config.OIDC.DepartmentMapping, err = parseGroupMapping(viper.GetString("OIDC_DEPARTMENT_MAPPING")); err != nil {
+		return nil, fmt.Errorf("invalid configuration: OIDC_DEPARTMENT_MAPPING: %w", err)
 	}
 
 	// Validar configuración crítica
@@ -143,28 +277,78 @@ func setDefaults() {
 	viper.SetDefault("RATE_LIMIT_REQUESTS", 100)
 	viper.SetDefault("RATE_LIMIT_DURATION", "1m")
 
+	viper.SetDefault("LOGIN_MAX_ATTEMPTS", 5)
+	viper.SetDefault("LOGIN_IP_MAX_ATTEMPTS", 20)
+	viper.SetDefault("LOGIN_LOCKOUT_DURATION", "15m")
+	viper.SetDefault("LOGIN_DELAY", "1s")
+	viper.SetDefault("LOGIN_MAX_DELAY", "30s")
+
 	viper.SetDefault("DEFAULT_PAGE_SIZE", 20)
 	viper.SetDefault("MAX_PAGE_SIZE", 100)
+
+	viper.SetDefault("ABSENCE_JOB_INTERVAL", "5m")
+	viper.SetDefault("RECURRENCE_JOB_INTERVAL", "1h")
+	viper.SetDefault("RECURRENCE_HORIZON_DAYS", 90)
+
+	viper.SetDefault("WEBHOOK_DISPATCH_INTERVAL", "10s")
+	viper.SetDefault("WEBHOOK_TIMEOUT", "10s")
+	viper.SetDefault("WEBHOOK_MAX_ATTEMPTS", 8)
+
+	viper.SetDefault("MAIL_FROM", "Attendance <no-reply@localhost>")
+	viper.SetDefault("MAIL_FILE_DIR", "./tmp/mail")
+	viper.SetDefault("SMTP_PORT", "587")
+
+	viper.SetDefault("PASSWORD_RESET_URL", "http://localhost:5173/reset-password")
+	viper.SetDefault("PASSWORD_RESET_EXPIRATION", "1h")
+
+	viper.SetDefault("MFA_ISSUER", "Attendance")
+	viper.SetDefault("MFA_CHALLENGE_EXPIRATION", "5m")
+
+	viper.SetDefault("OIDC_SCOPES", "openid email profile")
+	viper.SetDefault("OIDC_GROUPS_CLAIM", "groups")
+	viper.SetDefault("OIDC_AUTO_PROVISION", true)
+	viper.SetDefault("OIDC_STATE_EXPIRATION", "10m")
 }
 
-// parseAllowedOrigins parsea ALLOWED_ORIGINS desde variable de entorno
-// Maneja formato: "http://localhost:3000,http://localhost:5173"
-func parseAllowedOrigins() []string {
-	originsStr := viper.GetString("ALLOWED_ORIGINS")
-	if originsStr == "" {
+// parseList parsea una lista separada por comas desde variable de entorno, como
+// ALLOWED_ORIGINS: "http://localhost:3000,http://localhost:5173"
+func parseList(key string) []string {
+	value := viper.GetString(key)
+	if value == "" {
 		return []string{}
 	}
 
 	// Split por coma y limpiar espacios
-	origins := []string{}
-	for _, origin := range strings.Split(originsStr, ",") {
-		trimmed := strings.TrimSpace(origin)
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		trimmed := strings.TrimSpace(item)
 		if trimmed != "" {
-			origins = append(origins, trimmed)
+			items = append(items, trimmed)
 		}
 	}
 
-	return origins
+	return items
+}
+
+// parseGroupMapping lee reglas "grupo=valor" separadas por ";". El grupo termina en el
+// último "=", así que puede ser un DN como cn=admins,ou=groups,dc=example,dc=com.
+func parseGroupMapping(value string) ([]GroupMapping, error) {
+	var mapping []GroupMapping
+	for _, rule := range strings.Split(value, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		i := strings.LastIndex(rule, "=")
+		if i <= 0 || strings.TrimSpace(rule[i+1:]) == "" {
+			return nil, fmt.Errorf("rule %q must be group=value", rule)
+		}
+		mapping = append(mapping, GroupMapping{
+			Group: strings.TrimSpace(rule[:i]),
+			Value: strings.TrimSpace(rule[i+1:]),
+		})
+	}
+	return mapping, nil
 }
 
 // validateConfig valida que la configuración tenga los valores críticos
@@ -178,8 +362,28 @@ func validateConfig(config *Config) error {
 	if config.Database.DBName == "" {
 		return fmt.Errorf("DB_NAME is required")
 	}
-	if config.JWT.Secret == "" {
-		return fmt.Errorf("JWT_SECRET is required")
+	if config.JWT.Secret == "" && config.JWT.KeysDir == "" {
+		return fmt.Errorf("JWT_SECRET or JWT_KEYS_DIR is required")
+	}
+	// MAIL_DRIVER no tiene valor por defecto: el driver log escribe los enlaces de
+	// restablecimiento de contraseña en el log y solo se admite en desarrollo
+	switch config.Mail.Driver {
+	case "smtp":
+		if config.Mail.SMTPHost == "" {
+			return fmt.Errorf("SMTP_HOST is required when MAIL_DRIVER is smtp")
+		}
+	case "file":
+	case "log":
+		if config.Server.Env != "development" {
+			return fmt.Errorf("MAIL_DRIVER log is only allowed when ENV is development")
+		}
+	case "":
+		return fmt.Errorf("MAIL_DRIVER is required (smtp, file or log)")
+	default:
+		return fmt.Errorf("MAIL_DRIVER must be smtp, file or log")
+	}
+	if config.OIDC.Issuer != "" && (config.OIDC.ClientID == "" || config.OIDC.RedirectURL == "") {
+		return fmt.Errorf("OIDC_CLIENT_ID and OIDC_REDIRECT_URL are required when OIDC_ISSUER is set")
 	}
 	return nil
 }
@@ -0,0 +1,79 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/repositories"
+	"github.com/juank/attendance-backend/internal/domain/services"
+	"github.com/juank/attendance-backend/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// AbsenceJob periodically closes events that ended or were deactivated,
+// recording an absence for every expected participant who never checked in
+type AbsenceJob struct {
+	eventRepo         repositories.EventRepository
+	attendanceService services.AttendanceService
+	interval          time.Duration
+}
+
+func NewAbsenceJob(
+	eventRepo repositories.EventRepository,
+	attendanceService services.AttendanceService,
+	interval time.Duration,
+) *AbsenceJob {
+	return &AbsenceJob{
+		eventRepo:         eventRepo,
+		attendanceService: attendanceService,
+		interval:          interval,
+	}
+}
+
+// Start runs the job in the background until the context is cancelled
+func (j *AbsenceJob) Start(ctx context.Context) {
+	if j.interval <= 0 {
+		logger.Warn("Absence job disabled", zap.Duration("interval", j.interval))
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		j.RunOnce()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				j.RunOnce()
+			}
+		}
+	}()
+}
+
+// RunOnce closes every event that is pending closure
+func (j *AbsenceJob) RunOnce() {
+	events, err := j.eventRepo.GetPendingClosure(time.Now())
+	if err != nil {
+		logger.Error("Failed to load events pending closure", zap.Error(err))
+		return
+	}
+
+	for _, event := range events {
+		created, err := j.attendanceService.MaterializeAbsences(event.ID)
+		if err != nil {
+			logger.Error("Failed to materialize absences",
+				zap.Uint("event_id", event.ID),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		logger.Info("Event closed",
+			zap.Uint("event_id", event.ID),
+			zap.Int64("absences", created),
+		)
+	}
+}
@@ -0,0 +1,61 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/juank/attendance-backend/internal/application/services"
+	"github.com/juank/attendance-backend/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// RecurrenceJob periodically generates the upcoming occurrences of recurring events
+// so that every series always has occurrences up to its horizon
+type RecurrenceJob struct {
+	seriesService *services.EventSeriesService
+	interval      time.Duration
+}
+
+func NewRecurrenceJob(seriesService *services.EventSeriesService, interval time.Duration) *RecurrenceJob {
+	return &RecurrenceJob{
+		seriesService: seriesService,
+		interval:      interval,
+	}
+}
+
+// Start runs the job in the background until the context is cancelled
+func (j *RecurrenceJob) Start(ctx context.Context) {
+	if j.interval <= 0 {
+		logger.Warn("Recurrence job disabled", zap.Duration("interval", j.interval))
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		j.RunOnce()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				j.RunOnce()
+			}
+		}
+	}()
+}
+
+// RunOnce extends every series up to the horizon
+func (j *RecurrenceJob) RunOnce() {
+	extended, err := j.seriesService.ExtendAll(time.Now())
+	if err != nil {
+		logger.Error("Failed to extend event series",
+			zap.Int("extended", extended),
+			zap.Error(err),
+		)
+		return
+	}
+
+	logger.Debug("Event series extended", zap.Int("series", extended))
+}
@@ -0,0 +1,62 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/services"
+	"github.com/juank/attendance-backend/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// WebhookJob drains the webhook outbox, sending the deliveries that are due
+// and rescheduling the failed ones with backoff
+type WebhookJob struct {
+	webhookService services.WebhookService
+	interval       time.Duration
+}
+
+func NewWebhookJob(webhookService services.WebhookService, interval time.Duration) *WebhookJob {
+	return &WebhookJob{
+		webhookService: webhookService,
+		interval:       interval,
+	}
+}
+
+// Start runs the job in the background until the context is cancelled
+func (j *WebhookJob) Start(ctx context.Context) {
+	if j.interval <= 0 {
+		logger.Warn("Webhook job disabled", zap.Duration("interval", j.interval))
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		j.RunOnce()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				j.RunOnce()
+			}
+		}
+	}()
+}
+
+// RunOnce sends due deliveries until the outbox has none left
+func (j *WebhookJob) RunOnce() {
+	for {
+		sent, err := j.webhookService.DispatchDue(time.Now())
+		if err != nil {
+			logger.Error("Failed to dispatch webhook deliveries", zap.Error(err))
+			return
+		}
+		if sent == 0 {
+			return
+		}
+		logger.Debug("Webhook deliveries dispatched", zap.Int("deliveries", sent))
+	}
+}
@@ -2,22 +2,48 @@ package services
 
 import (
 	"errors"
+	"math"
 	"time"
 
 	"github.com/juank/attendance-backend/internal/domain/models"
 	"github.com/juank/attendance-backend/internal/domain/repositories"
 	"github.com/juank/attendance-backend/internal/domain/services"
+	"github.com/juank/attendance-backend/pkg/geo"
 )
 
 type AttendanceServiceImpl struct {
 	attendanceRepo repositories.AttendanceRepository
+	eventRepo      repositories.EventRepository
+	userRepo       repositories.UserRepository
+	leaveRepo      repositories.LeaveRequestRepository
 	qrService      services.QRService
+	participants   services.EventParticipantService
+	webhooks       services.WebhookPublisher
+	live           services.LivePublisher
+	audit          services.AuditRecorder
 }
 
-func NewAttendanceService(attendanceRepo repositories.AttendanceRepository, qrService services.QRService) services.AttendanceService {
+func NewAttendanceService(
+	attendanceRepo repositories.AttendanceRepository,
+	eventRepo repositories.EventRepository,
+	userRepo repositories.UserRepository,
+	leaveRepo repositories.LeaveRequestRepository,
+	qrService services.QRService,
+	participants services.EventParticipantService,
+	webhooks services.WebhookPublisher,
+	live services.LivePublisher,
+	audit services.AuditRecorder,
+) services.AttendanceService {
 	return &AttendanceServiceImpl{
 		attendanceRepo: attendanceRepo,
+		eventRepo:      eventRepo,
+		userRepo:       userRepo,
+		leaveRepo:      leaveRepo,
 		qrService:      qrService,
+		participants:   participants,
+		webhooks:       webhooks,
+		live:           live,
+		audit:          audit,
 	}
 }
 
@@ -28,25 +54,42 @@ func (s *AttendanceServiceImpl) MarkAttendance(req *services.MarkAttendanceReque
 		return nil, err
 	}
 
+	// Restricted events only accept scans from users on the roster
+	if qr.Event.IsRestricted {
+		expected, err := s.participants.IsExpected(qr.EventID, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if !expected {
+			return nil, errors.New("user is not a participant of this event")
+		}
+	}
+
 	// Check if user already marked attendance for this event
 	existingAttendance, err := s.attendanceRepo.GetByEventAndUser(qr.EventID, req.UserID)
 	if err == nil && existingAttendance != nil {
 		return nil, errors.New("user already marked attendance for this event")
 	}
 
-	// Determine status based on check-in time
+	// Determine status based on the event schedule
 	now := time.Now()
-	status := s.calculateStatus(now)
+	status := s.calculateStatus(&qr.Event, now)
 
 	// Create attendance record
 	attendance := &models.Attendance{
-		UserID:   req.UserID,
-		EventID:  qr.EventID,
-		CheckIn:  now,
-		Status:   string(status),
-		Location: req.Location,
-		Notes:    req.Notes,
-		QRToken:  req.QRToken,
+		UserID:    req.UserID,
+		EventID:   qr.EventID,
+		CheckIn:   now,
+		Status:    string(status),
+		Location:  req.Location,
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+		Notes:     req.Notes,
+		QRToken:   req.QRToken,
+	}
+
+	if err := s.applyGeofence(&qr.Event, attendance); err != nil {
+		return nil, err
 	}
 
 	err = s.attendanceRepo.Create(attendance)
@@ -54,6 +97,9 @@ func (s *AttendanceServiceImpl) MarkAttendance(req *services.MarkAttendanceReque
 		return nil, err
 	}
 
+	s.webhooks.Publish(models.WebhookAttendanceMarked, attendance)
+	s.live.PublishAttendance(attendance)
+
 	return attendance, nil
 }
 
@@ -91,39 +137,64 @@ func (s *AttendanceServiceImpl) GetByDateRange(userID uint, startDate, endDate t
 	return s.attendanceRepo.GetByDateRange(userID, startDate, endDate)
 }
 
-// calculateStatus determines the attendance status based on check-in time
-func (s *AttendanceServiceImpl) calculateStatus(checkInTime time.Time) models.AttendanceStatus {
-	// Business rule: Late if after 9:15 AM
-	limitTime := time.Date(
-		checkInTime.Year(),
-		checkInTime.Month(),
-		checkInTime.Day(),
-		9, 15, 0, 0,
-		checkInTime.Location(),
-	)
+// applyGeofence computes the distance of a check-in to the event geofence and
+// rejects or flags it when it falls outside, depending on the event mode
+func (s *AttendanceServiceImpl) applyGeofence(event *models.Event, attendance *models.Attendance) error {
+	if !event.HasGeofence() {
+		return nil
+	}
+
+	if attendance.Latitude == nil || attendance.Longitude == nil {
+		if event.GeofenceMode == models.GeofenceReject {
+			return errors.New("location coordinates are required for this event")
+		}
+		attendance.OutsideGeofence = true
+		return nil
+	}
 
-	if checkInTime.After(limitTime) {
-		return models.StatusLate
+	point := geo.Point{Lat: *attendance.Latitude, Lng: *attendance.Longitude}
+	if !point.Valid() {
+		return errors.New("invalid location coordinates")
 	}
 
-	return models.StatusPresent
+	distance := math.Round(event.GeofenceDistance(point)*10) / 10
+	attendance.DistanceMeters = &distance
+
+	if !event.IsInsideGeofence(distance) {
+		if event.GeofenceMode == models.GeofenceReject {
+			return errors.New("check-in location is outside the event area")
+		}
+		attendance.OutsideGeofence = true
+	}
+
+	return nil
+}
+
+// calculateStatus determines the attendance status from the event's own start time,
+// grace period and absent cutoff
+func (s *AttendanceServiceImpl) calculateStatus(event *models.Event, checkInTime time.Time) models.AttendanceStatus {
+	return event.AttendanceStatusAt(checkInTime)
 }
 
 func (s *AttendanceServiceImpl) GetEventAttendance(eventID uint) ([]models.Attendance, error) {
 	return s.attendanceRepo.GetByEventID(eventID)
 }
 
-func (s *AttendanceServiceImpl) MarkManualAttendance(eventID, userID uint, notes string) (*models.Attendance, error) {
+func (s *AttendanceServiceImpl) MarkManualAttendance(actor *services.Actor, eventID, userID uint, notes string) (*models.Attendance, error) {
 	// Check if user already marked attendance for this event
 	existingAttendance, err := s.attendanceRepo.GetByEventAndUser(eventID, userID)
 	if err == nil && existingAttendance != nil {
 		return nil, errors.New("user already marked attendance for this event")
 	}
 
+	event, err := s.eventRepo.GetByID(eventID)
+	if err != nil {
+		return nil, errors.New("event not found")
+	}
+
 	now := time.Now()
-	// Manual entry is typically considered "present" or we could calculate logic.
-	// For simplicity, let's calculate status normally.
-	status := s.calculateStatus(now)
+	// Manual entries follow the same lateness rules as scanned ones
+	status := s.calculateStatus(event, now)
 
 	attendance := &models.Attendance{
 		UserID:   userID,
@@ -138,5 +209,182 @@ func (s *AttendanceServiceImpl) MarkManualAttendance(eventID, userID uint, notes
 		return nil, err
 	}
 
+	s.audit.Record(actor, models.AuditAttendanceManual, models.AuditTargetAttendance, attendance.ID, nil, attendance)
+
+	s.webhooks.Publish(models.WebhookAttendanceMarked, attendance)
+	s.live.PublishAttendance(attendance)
+
 	return attendance, nil
 }
+
+func (s *AttendanceServiceImpl) CheckOut(req *services.CheckOutRequest) (*models.Attendance, error) {
+	// The event's current QR code is used for check-out as well
+	qr, err := s.qrService.ValidateToken(req.QRToken)
+	if err != nil {
+		return nil, err
+	}
+
+	attendance, _, err := s.checkOut(&qr.Event, req.UserID, time.Now())
+	return attendance, err
+}
+
+func (s *AttendanceServiceImpl) MarkManualCheckOut(actor *services.Actor, eventID, userID uint) (*models.Attendance, error) {
+	event, err := s.eventRepo.GetByID(eventID)
+	if err != nil {
+		return nil, errors.New("event not found")
+	}
+
+	attendance, before, err := s.checkOut(event, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(actor, models.AuditAttendanceCheckOut, models.AuditTargetAttendance, attendance.ID, before, attendance)
+	return attendance, nil
+}
+
+// checkOut stamps the check-out on the user's attendance record for the event. It also
+// returns the record as it was before.
+func (s *AttendanceServiceImpl) checkOut(event *models.Event, userID uint, now time.Time) (*models.Attendance, *models.Attendance, error) {
+	attendance, err := s.attendanceRepo.GetByEventAndUser(event.ID, userID)
+	if err != nil {
+		return nil, nil, errors.New("no check-in found for this event")
+	}
+
+	if attendance.Status == string(models.StatusAbsent) || attendance.Status == string(models.StatusOnLeave) {
+		return nil, nil, errors.New("no check-in found for this event")
+	}
+
+	if attendance.IsCheckedOut() {
+		return nil, nil, errors.New("user already checked out of this event")
+	}
+
+	before := *attendance
+	attendance.RecordCheckOut(now, event)
+
+	if err := s.attendanceRepo.Update(attendance); err != nil {
+		return nil, nil, err
+	}
+
+	return attendance, &before, nil
+}
+
+func (s *AttendanceServiceImpl) MaterializeAbsences(eventID uint) (int64, error) {
+	event, err := s.eventRepo.GetByID(eventID)
+	if err != nil {
+		return 0, errors.New("event not found")
+	}
+
+	// An event deactivated before it started was cancelled: nobody was expected at it
+	closedAt := time.Now()
+	if !event.IsActive && event.StartTime.After(closedAt) {
+		return 0, s.closeEvent(event, closedAt, 0)
+	}
+
+	participants, err := s.expectedParticipants(event)
+	if err != nil {
+		return 0, err
+	}
+
+	checkedIn, err := s.attendanceRepo.GetUserIDsByEventID(eventID)
+	if err != nil {
+		return 0, err
+	}
+	seen := make(map[uint]bool, len(checkedIn))
+	for _, userID := range checkedIn {
+		seen[userID] = true
+	}
+
+	// Users who joined after the event ended were never expected to attend it
+	cutoff := closedAt
+	if event.HasEnded(closedAt) {
+		cutoff = event.EndTime
+	}
+
+	var missing []uint
+	for _, user := range participants {
+		if !seen[user.ID] && !user.CreatedAt.After(cutoff) {
+			missing = append(missing, user.ID)
+		}
+	}
+
+	onLeave, err := s.approvedLeaveFor(event, missing)
+	if err != nil {
+		return 0, err
+	}
+
+	var absences []models.Attendance
+	for _, userID := range missing {
+		absence := models.Attendance{
+			UserID:       userID,
+			EventID:      eventID,
+			CheckIn:      event.StartTime,
+			Status:       string(models.StatusAbsent),
+			Notes:        "No check-in recorded",
+			AutoRecorded: true,
+		}
+		if leave, ok := onLeave[userID]; ok {
+			absence.Status = string(models.StatusOnLeave)
+			absence.Notes = leaveNote(leave)
+		}
+		absences = append(absences, absence)
+	}
+
+	created, err := s.attendanceRepo.CreateBatch(absences)
+	if err != nil {
+		return 0, err
+	}
+
+	return created, s.closeEvent(event, closedAt, created)
+}
+
+// closeEvent stamps the event as closed and announces it with the absences recorded
+func (s *AttendanceServiceImpl) closeEvent(event *models.Event, closedAt time.Time, absences int64) error {
+	if err := s.eventRepo.MarkClosed(event.ID, closedAt); err != nil {
+		return err
+	}
+
+	event.ClosedAt = &closedAt
+	s.webhooks.Publish(models.WebhookEventClosed, &services.EventClosedData{
+		Event:            event,
+		AbsencesRecorded: absences,
+	})
+	return nil
+}
+
+// approvedLeaveFor returns, per user, the approved leave covering the event day
+func (s *AttendanceServiceImpl) approvedLeaveFor(event *models.Event, userIDs []uint) (map[uint]*models.LeaveRequest, error) {
+	result := make(map[uint]*models.LeaveRequest)
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+
+	// Widen the range by a day on each side; the exact day is checked in the event's time zone
+	leaves, err := s.leaveRepo.GetApprovedOverlapping(userIDs, event.StartTime.AddDate(0, 0, -1), event.StartTime.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range leaves {
+		if leaves[i].Covers(event.StartTime, event.Location()) {
+			result[leaves[i].UserID] = &leaves[i]
+		}
+	}
+
+	return result, nil
+}
+
+// expectedParticipants returns the users that are supposed to attend an event.
+// Unrestricted events without a roster expect every active user.
+func (s *AttendanceServiceImpl) expectedParticipants(event *models.Event) ([]models.User, error) {
+	roster, err := s.participants.GetParticipants(event.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(roster) == 0 && !event.IsRestricted {
+		return s.userRepo.GetActive()
+	}
+
+	return s.participants.GetExpectedUsers(event.ID)
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/repositories"
+	"github.com/juank/attendance-backend/internal/domain/services"
+	"github.com/juank/attendance-backend/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const auditUserAgentLimit = 512
+
+// auditIgnoredFields change on every write and only add noise to the diff
+var auditIgnoredFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+}
+
+// auditRedactedFields are recorded as changed without their values
+var auditRedactedFields = map[string]bool{
+	"password":   true,
+	"secret":     true,
+	"token":      true,
+	"token_hash": true,
+	"qr_token":   true,
+}
+
+const auditRedacted = "[redacted]"
+
+type AuditServiceImpl struct {
+	auditRepo repositories.AuditLogRepository
+}
+
+func NewAuditService(auditRepo repositories.AuditLogRepository) services.AuditService {
+	return &AuditServiceImpl{
+		auditRepo: auditRepo,
+	}
+}
+
+func (s *AuditServiceImpl) Record(actor *services.Actor, action models.AuditAction, target models.AuditTarget, targetID uint, before, after interface{}) {
+	entry := &models.AuditLog{
+		Action:     action,
+		TargetType: target,
+	}
+	if targetID != 0 {
+		entry.TargetID = &targetID
+	}
+	if actor != nil {
+		if actor.UserID != 0 {
+			actorID := actor.UserID
+			entry.ActorID = &actorID
+		}
+		entry.ActorRole = actor.Role
+		entry.IP = actor.IP
+		entry.UserAgent = actor.UserAgent
+		if len(entry.UserAgent) > auditUserAgentLimit {
+			entry.UserAgent = entry.UserAgent[:auditUserAgentLimit]
+		}
+	}
+
+	changes, err := auditDiff(before, after)
+	if err == nil {
+		entry.Changes = changes
+		err = s.auditRepo.Create(entry)
+	}
+	if err != nil {
+		logger.Error("Failed to write audit log",
+			zap.String("action", string(action)),
+			zap.String("target_type", string(target)),
+			zap.Uint("target_id", targetID),
+			zap.Error(err),
+		)
+	}
+}
+
+func (s *AuditServiceImpl) GetByID(id uint) (*models.AuditLog, error) {
+	entry, err := s.auditRepo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("audit log entry not found")
+	}
+	return entry, nil
+}
+
+func (s *AuditServiceImpl) GetAll(query *services.AuditLogQuery) ([]models.AuditLog, int64, error) {
+	filter := models.AuditLogFilter{
+		ActorID:    query.ActorID,
+		Action:     query.Action,
+		TargetType: query.TargetType,
+		TargetID:   query.TargetID,
+	}
+
+	if query.From != "" {
+		from, err := parseAuditTime(query.From, false)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: from must be a date (YYYY-MM-DD) or an RFC 3339 timestamp", services.ErrInvalidAuditQuery)
+		}
+		filter.From = &from
+	}
+	if query.To != "" {
+		to, err := parseAuditTime(query.To, true)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: to must be a date (YYYY-MM-DD) or an RFC 3339 timestamp", services.ErrInvalidAuditQuery)
+		}
+		filter.To = &to
+	}
+	if filter.From != nil && filter.To != nil && filter.To.Before(*filter.From) {
+		return nil, 0, fmt.Errorf("%w: to must not be before from", services.ErrInvalidAuditQuery)
+	}
+
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.Limit < 1 {
+		query.Limit = 50
+	}
+	if query.Limit > 100 {
+		query.Limit = 100
+	}
+
+	return s.auditRepo.GetAll(filter, query.Page, query.Limit)
+}
+
+// parseAuditTime reads an RFC 3339 timestamp or a UTC date. Dates used as an upper bound
+// cover their whole day.
+func parseAuditTime(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		return day.Add(24*time.Hour - time.Nanosecond), nil
+	}
+	return day, nil
+}
+
+// auditDiff compares the JSON representations of before and after field by field.
+// Embedded objects are compared per field, as "template.title". Loaded associations (objects
+// with an ID) are left out; their foreign keys are compared instead.
+func auditDiff(before, after interface{}) (models.AuditChanges, error) {
+	old, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := models.AuditChanges{}
+	add := func(field string, oldValue, newValue interface{}) {
+		if reflect.DeepEqual(oldValue, newValue) {
+			return
+		}
+		if auditRedactedFields[field[strings.LastIndex(field, ".")+1:]] {
+			oldValue, newValue = redactAuditValue(oldValue), redactAuditValue(newValue)
+		}
+		changes[field] = models.AuditChange{Before: oldValue, After: newValue}
+	}
+
+	for field, oldValue := range old {
+		add(field, oldValue, updated[field])
+	}
+	for field, newValue := range updated {
+		if _, ok := old[field]; !ok {
+			add(field, nil, newValue)
+		}
+	}
+
+	return changes, nil
+}
+
+// auditFields decodes a value into its JSON fields, flattening embedded objects
+func auditFields(value interface{}) (map[string]interface{}, error) {
+	if value == nil || (reflect.ValueOf(value).Kind() == reflect.Ptr && reflect.ValueOf(value).IsNil()) {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]interface{}, len(decoded))
+	flattenAuditFields(fields, "", decoded)
+	return fields, nil
+}
+
+func flattenAuditFields(fields map[string]interface{}, prefix string, object map[string]interface{}) {
+	for name, value := range object {
+		if auditIgnoredFields[name] || isAssociation(value) {
+			continue
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			flattenAuditFields(fields, prefix+name+".", nested)
+			continue
+		}
+		if value != nil {
+			fields[prefix+name] = value
+		}
+	}
+}
+
+// isAssociation reports whether a JSON value is a stored entity, or a list of them,
+// loaded alongside the one being audited
+func isAssociation(value interface{}) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		id, ok := v["id"].(float64)
+		return ok && id != 0
+	case []interface{}:
+		return len(v) > 0 && isAssociation(v[0])
+	}
+	return false
+}
+
+func redactAuditValue(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return auditRedacted
+}
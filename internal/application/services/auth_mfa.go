@@ -0,0 +1,325 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/services"
+	"github.com/juank/attendance-backend/pkg/totp"
+	"github.com/juank/attendance-backend/pkg/utils"
+)
+
+// Purposes of a login challenge token
+const (
+	mfaPurposeVerify = "verify" // the user has MFA and must enter a code
+	mfaPurposeEnroll = "enroll" // the user's role requires MFA and they have not enrolled yet
+)
+
+const recoveryCodeCount = 10
+
+var errInvalidChallenge = errors.New("invalid or expired challenge token")
+
+// mfaState is the part of a user recorded in the audit log when MFA changes
+type mfaState struct {
+	MFAEnabled bool `json:"mfa_enabled"`
+}
+
+// recoveryCodesReplaced is the audit payload of new recovery codes, which are never recorded
+var recoveryCodesReplaced = map[string]interface{}{"recovery_codes": true}
+
+// challenge answers a correct password with a challenge token instead of the session tokens
+func (s *AuthServiceImpl) challenge(user *models.User) (*services.TokenResponse, error) {
+	purpose := mfaPurposeVerify
+	if !user.MFAEnabled {
+		purpose = mfaPurposeEnroll
+	}
+	token, err := utils.GenerateChallengeToken(user.ID, purpose, s.keys, s.cfg.MFA.ChallengeExpiration)
+	if err != nil {
+		return nil, err
+	}
+	return &services.TokenResponse{
+		MFARequired:           user.MFAEnabled,
+		MFAEnrollmentRequired: !user.MFAEnabled,
+		ChallengeToken:        token,
+	}, nil
+}
+
+// challengeUser resolves a challenge token to its user, who must still be active and not
+// locked out
+func (s *AuthServiceImpl) challengeUser(client *services.Actor, token, purpose string) (*models.User, error) {
+	if wait := s.ipFailures.Wait(client.IP); wait > 0 {
+		return nil, &services.LoginThrottledError{RetryAfter: wait}
+	}
+
+	userID, got, err := utils.ValidateChallengeToken(token, s.keys)
+	if err != nil || got != purpose {
+		return nil, errInvalidChallenge
+	}
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil || !user.IsActive {
+		return nil, errInvalidChallenge
+	}
+	// MFA may have been enabled or reset since the challenge was issued
+	if user.MFAEnabled != (purpose == mfaPurposeVerify) {
+		return nil, errInvalidChallenge
+	}
+
+	if wait := s.accountWait(user, time.Now()); wait > 0 {
+		return nil, &services.LoginThrottledError{RetryAfter: wait}
+	}
+	return user, nil
+}
+
+// completeLogin clears the failed attempts and starts a session
+func (s *AuthServiceImpl) completeLogin(client *services.Actor, user *models.User) (*services.TokenResponse, error) {
+	if user.FailedLoginAttempts > 0 || user.LockedUntil != nil {
+		if err := s.userRepo.ResetLoginFailures(user.ID); err != nil {
+			return nil, err
+		}
+	}
+	return s.generateTokens(user, client, "", time.Time{})
+}
+
+func (s *AuthServiceImpl) VerifyMFA(client *services.Actor, req *services.MFAVerifyRequest) (*services.TokenResponse, error) {
+	user, err := s.challengeUser(client, req.ChallengeToken, mfaPurposeVerify)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkSecondFactor(client, user, req.Code); err != nil {
+		return nil, err
+	}
+	return s.completeLogin(client, user)
+}
+
+func (s *AuthServiceImpl) BeginMFAEnrollment(client *services.Actor, req *services.MFAChallengeRequest) (*services.MFASetup, error) {
+	user, err := s.challengeUser(client, req.ChallengeToken, mfaPurposeEnroll)
+	if err != nil {
+		return nil, err
+	}
+	return s.setupMFA(user)
+}
+
+func (s *AuthServiceImpl) CompleteMFAEnrollment(client *services.Actor, req *services.MFAVerifyRequest) (*services.TokenResponse, error) {
+	user, err := s.challengeUser(client, req.ChallengeToken, mfaPurposeEnroll)
+	if err != nil {
+		return nil, err
+	}
+
+	actor := *client
+	actor.UserID, actor.Role = user.ID, user.Role
+	codes, err := s.activateMFA(&actor, user, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.completeLogin(client, user)
+	if err != nil {
+		return nil, err
+	}
+	tokens.RecoveryCodes = codes
+	return tokens, nil
+}
+
+func (s *AuthServiceImpl) GetMFAStatus(actor *services.Actor) (*services.MFAStatus, error) {
+	user, err := s.userRepo.GetByID(actor.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	status := &services.MFAStatus{
+		Enabled:  user.MFAEnabled,
+		Required: s.roles.RequiresMFA(user.Role),
+	}
+	if user.MFAEnabled {
+		if status.RecoveryCodesUnused, err = s.recoveryRepo.CountUnused(user.ID); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+func (s *AuthServiceImpl) SetupMFA(actor *services.Actor) (*services.MFASetup, error) {
+	user, err := s.userRepo.GetByID(actor.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	return s.setupMFA(user)
+}
+
+func (s *AuthServiceImpl) ActivateMFA(actor *services.Actor, req *services.MFACodeRequest) (*services.RecoveryCodesResponse, error) {
+	user, err := s.userRepo.GetByID(actor.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	codes, err := s.activateMFA(actor, user, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	return &services.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func (s *AuthServiceImpl) DisableMFA(actor *services.Actor, req *services.DisableMFARequest) error {
+	user, err := s.userRepo.GetByID(actor.UserID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if !user.MFAEnabled {
+		return errors.New("two-factor authentication is not enabled")
+	}
+	if s.roles.RequiresMFA(user.Role) {
+		return errors.New("two-factor authentication is required for your role")
+	}
+	if !utils.CheckPasswordHash(req.Password, user.Password) {
+		return errors.New("invalid password")
+	}
+	if err := s.checkSecondFactor(actor, user, req.Code); err != nil {
+		return err
+	}
+
+	if err := s.removeMFA(user.ID); err != nil {
+		return err
+	}
+	s.audit.Record(actor, models.AuditMFADisable, models.AuditTargetUser, user.ID, mfaState{true}, mfaState{false})
+	return nil
+}
+
+func (s *AuthServiceImpl) RegenerateRecoveryCodes(actor *services.Actor, req *services.MFACodeRequest) (*services.RecoveryCodesResponse, error) {
+	user, err := s.userRepo.GetByID(actor.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if !user.MFAEnabled {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+	if err := s.checkSecondFactor(actor, user, req.Code); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.replaceRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(actor, models.AuditMFARecoveryCodes, models.AuditTargetUser, user.ID, nil, recoveryCodesReplaced)
+	return &services.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func (s *AuthServiceImpl) ResetMFA(actor *services.Actor, userID uint) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if !s.roles.CanAssign(actor.Role, user.Role) {
+		return services.ErrUserNotManageable
+	}
+	if !user.MFAEnabled && user.TOTPSecret == "" {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	if err := s.removeMFA(user.ID); err != nil {
+		return err
+	}
+	s.audit.Record(actor, models.AuditMFAReset, models.AuditTargetUser, user.ID, mfaState{user.MFAEnabled}, mfaState{false})
+	return nil
+}
+
+// setupMFA stores a new, not yet active, secret for the user
+func (s *AuthServiceImpl) setupMFA(user *models.User) (*services.MFASetup, error) {
+	if user.MFAEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.SetMFA(user.ID, secret, false); err != nil {
+		return nil, err
+	}
+
+	return &services.MFASetup{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(s.cfg.MFA.Issuer, user.Email, secret),
+	}, nil
+}
+
+// activateMFA enables the secret stored by setupMFA once code proves the user's app
+// generates its codes, and returns the first recovery codes
+func (s *AuthServiceImpl) activateMFA(actor *services.Actor, user *models.User, code string) ([]string, error) {
+	if user.MFAEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+	if user.TOTPSecret == "" {
+		return nil, errors.New("two-factor authentication setup has not been started")
+	}
+
+	counter, ok := totp.Validate(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid code", services.ErrMFA)
+	}
+	if used, err := s.userRepo.UseTOTPCounter(user.ID, counter); err != nil {
+		return nil, err
+	} else if !used {
+		return nil, fmt.Errorf("%w: code already used", services.ErrMFA)
+	}
+
+	if err := s.userRepo.SetMFA(user.ID, user.TOTPSecret, true); err != nil {
+		return nil, err
+	}
+	codes, err := s.replaceRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.MFAEnabled = true
+	s.audit.Record(actor, models.AuditMFAEnable, models.AuditTargetUser, user.ID, mfaState{false}, mfaState{true})
+	return codes, nil
+}
+
+// checkSecondFactor accepts a current TOTP code or an unused recovery code. Wrong codes
+// count as failed logins, so they lead to the same delays and lockout as wrong passwords.
+func (s *AuthServiceImpl) checkSecondFactor(client *services.Actor, user *models.User, code string) error {
+	now := time.Now()
+
+	var ok bool
+	var err error
+	if totp.IsRecoveryCode(code) {
+		ok, err = s.recoveryRepo.Use(user.ID, utils.HashToken(totp.NormalizeRecoveryCode(code)), now)
+	} else if counter, valid := totp.Validate(user.TOTPSecret, code, now); valid {
+		// A code already accepted once, say an intercepted one, does not work again
+		ok, err = s.userRepo.UseTOTPCounter(user.ID, counter)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		s.ipFailures.Fail(client.IP)
+		s.recordFailure(client, user, now)
+		return fmt.Errorf("%w: invalid code", services.ErrMFA)
+	}
+	return nil
+}
+
+func (s *AuthServiceImpl) replaceRecoveryCodes(userID uint) ([]string, error) {
+	codes, err := totp.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = utils.HashToken(totp.NormalizeRecoveryCode(code))
+	}
+	if err := s.recoveryRepo.Replace(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *AuthServiceImpl) removeMFA(userID uint) error {
+	if err := s.userRepo.SetMFA(userID, "", false); err != nil {
+		return err
+	}
+	return s.recoveryRepo.DeleteByUserID(userID)
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/juank/attendance-backend/config"
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/services"
+	"github.com/juank/attendance-backend/pkg/logger"
+	"github.com/juank/attendance-backend/pkg/oidc"
+	"github.com/juank/attendance-backend/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// ssoLink is the audit payload of linking a user to an identity provider account
+type ssoLink struct {
+	Subject string `json:"subject"`
+}
+
+// nameLimit is the size of the user name columns
+const nameLimit = 100
+
+func (s *AuthServiceImpl) BeginOIDCLogin(ctx context.Context) (*services.OIDCAuthorization, error) {
+	if s.sso == nil {
+		return nil, services.ErrOIDCDisabled
+	}
+
+	values := make([]string, 3)
+	for i := range values {
+		value, err := oidc.RandomString()
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+
+	authURL, err := s.sso.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		logger.Error("Failed to reach the identity provider", zap.Error(err))
+		return nil, services.ErrOIDCProvider
+	}
+
+	now := time.Now()
+	if err := s.oidcRepo.DeleteExpired(now); err != nil {
+		logger.Error("Failed to delete expired single sign-on requests", zap.Error(err))
+	}
+	if err := s.oidcRepo.Create(&models.OIDCAuthRequest{
+		StateHash:    utils.HashToken(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    now.Add(s.cfg.OIDC.StateExpiration),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &services.OIDCAuthorization{AuthorizationURL: authURL, State: state}, nil
+}
+
+func (s *AuthServiceImpl) CompleteOIDCLogin(ctx context.Context, client *services.Actor, req *services.OIDCCallbackRequest) (*services.TokenResponse, error) {
+	if s.sso == nil {
+		return nil, services.ErrOIDCDisabled
+	}
+
+	request, err := s.oidcRepo.Consume(utils.HashToken(req.State), time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid or expired state", services.ErrOIDC)
+	}
+
+	claims, err := s.sso.Login(ctx, req.Code, request.CodeVerifier, request.Nonce)
+	if err != nil {
+		logger.Warn("Single sign-on login rejected", zap.String("ip", client.IP), zap.Error(err))
+		return nil, fmt.Errorf("%w: the identity provider did not confirm the login", services.ErrOIDC)
+	}
+
+	user, err := s.ssoUser(client, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	// The identity provider replaces the password, not the second factor
+	if user.MFAEnabled || s.roles.RequiresMFA(user.Role) {
+		return s.challenge(user)
+	}
+	return s.completeLogin(client, user)
+}
+
+// ssoUser finds the user of an identity provider account, linking or creating it on its
+// first login, and applies the group mappings. Inactive users are rejected before anything
+// about them changes.
+func (s *AuthServiceImpl) ssoUser(client *services.Actor, claims *oidc.Claims) (*models.User, error) {
+	if user, err := s.userRepo.GetByOIDCSubject(claims.Subject); err == nil {
+		if !user.IsActive {
+			return nil, errors.New("user is inactive")
+		}
+		return user, s.applyGroups(client, user, claims.Groups)
+	}
+
+	// Linking by an unverified email would hand the account to whoever typed that address
+	// into the identity provider
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, fmt.Errorf("%w: the identity provider did not share a verified email", services.ErrOIDC)
+	}
+
+	actor := *client
+	email := normalizeEmail(claims.Email)
+	user, err := s.userRepo.GetByEmail(email)
+	if err == nil {
+		if !user.IsActive {
+			return nil, errors.New("user is inactive")
+		}
+		if user.OIDCSubject != nil {
+			return nil, fmt.Errorf("%w: the account is linked to another identity", services.ErrOIDC)
+		}
+		if err := s.userRepo.SetOIDCSubject(user.ID, claims.Subject); err != nil {
+			return nil, err
+		}
+		user.OIDCSubject = &claims.Subject
+
+		actor.UserID, actor.Role = user.ID, user.Role
+		s.audit.Record(&actor, models.AuditUserSSOLink, models.AuditTargetUser, user.ID, nil, ssoLink{claims.Subject})
+		return user, s.applyGroups(client, user, claims.Groups)
+	}
+
+	if !s.cfg.OIDC.AutoProvision {
+		return nil, fmt.Errorf("%w: no account for this identity", services.ErrOIDC)
+	}
+
+	// Deleted users keep their email, which cannot be reused
+	taken, err := s.userRepo.GetExistingEmails([]string{email})
+	if err != nil {
+		return nil, err
+	}
+	if len(taken) > 0 {
+		return nil, fmt.Errorf("%w: the email belongs to a deleted account", services.ErrOIDC)
+	}
+
+	firstName, lastName := ssoName(claims)
+	user = &models.User{
+		Email:       email,
+		FirstName:   firstName,
+		LastName:    lastName,
+		Role:        models.RoleEmployee,
+		IsActive:    true,
+		OIDCSubject: &claims.Subject,
+	}
+	if role, ok := s.mappedRole(claims.Groups); ok {
+		user.Role = role
+	}
+	if department, ok := s.mappedDepartment(claims.Groups); ok {
+		user.DepartmentID = &department.ID
+	}
+
+	// No password: CheckPasswordHash never accepts an empty hash
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, err
+	}
+
+	actor.UserID, actor.Role = user.ID, user.Role
+	s.audit.Record(&actor, models.AuditUserSSOProvision, models.AuditTargetUser, user.ID, nil, user)
+	return user, nil
+}
+
+// applyGroups updates the user's role and department to those their groups map to. Users
+// in no mapped group keep theirs, so assignments made in the application survive.
+func (s *AuthServiceImpl) applyGroups(client *services.Actor, user *models.User, groups []string) error {
+	before := *user
+	if role, ok := s.mappedRole(groups); ok {
+		user.Role = role
+	}
+	if department, ok := s.mappedDepartment(groups); ok {
+		user.DepartmentID, user.Department = &department.ID, department
+	}
+	roleChanged := user.Role != before.Role
+	if !roleChanged && equalIDs(user.DepartmentID, before.DepartmentID) {
+		return nil
+	}
+
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+	// Access tokens carry the role
+	if roleChanged {
+		if err := s.tokens.RevokeUser(user.ID); err != nil {
+			return err
+		}
+	}
+
+	actor := *client
+	actor.UserID, actor.Role = user.ID, before.Role
+	s.audit.Record(&actor, models.AuditUserUpdate, models.AuditTargetUser, user.ID, &before, user)
+	return nil
+}
+
+func (s *AuthServiceImpl) mappedRole(groups []string) (models.Role, bool) {
+	value, ok := mapGroups(s.cfg.OIDC.RoleMapping, groups)
+	if !ok {
+		return "", false
+	}
+	role := models.Role(value)
+	if !s.roles.Exists(role) {
+		logger.Warn("OIDC_ROLE_MAPPING names an unknown role", zap.String("role", value))
+		return "", false
+	}
+	return role, true
+}
+
+func (s *AuthServiceImpl) mappedDepartment(groups []string) (*models.Department, bool) {
+	name, ok := mapGroups(s.cfg.OIDC.DepartmentMapping, groups)
+	if !ok {
+		return nil, false
+	}
+	department, err := s.deptRepo.GetByName(name)
+	if err != nil {
+		logger.Warn("OIDC_DEPARTMENT_MAPPING names an unknown department", zap.String("department", name))
+		return nil, false
+	}
+	return department, true
+}
+
+// mapGroups returns the value of the first rule whose group the user belongs to
+func mapGroups(mapping []config.GroupMapping, groups []string) (string, bool) {
+	for _, rule := range mapping {
+		for _, group := range groups {
+			if group == rule.Group {
+				return rule.Value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// ssoName takes the user's name from the ID token, falling back to the email
+func ssoName(claims *oidc.Claims) (string, string) {
+	first, last := claims.GivenName, claims.FamilyName
+	if first == "" && last == "" {
+		first, last, _ = strings.Cut(strings.TrimSpace(claims.Name), " ")
+	}
+	if first == "" {
+		first, _, _ = strings.Cut(claims.Email, "@")
+	}
+	return truncate(first, nameLimit), truncate(strings.TrimSpace(last), nameLimit)
+}
+
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	// Do not split a multi-byte character
+	for limit > 0 && !utf8.RuneStart(s[limit]) {
+		limit--
+	}
+	return s[:limit]
+}
+
+func equalIDs(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
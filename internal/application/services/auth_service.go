@@ -3,37 +3,101 @@ package services
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/juank/attendance-backend/config"
 	"github.com/juank/attendance-backend/internal/domain/models"
 	"github.com/juank/attendance-backend/internal/domain/repositories"
 	"github.com/juank/attendance-backend/internal/domain/services"
+	"github.com/juank/attendance-backend/pkg/jwtkeys"
+	"github.com/juank/attendance-backend/pkg/logger"
+	"github.com/juank/attendance-backend/pkg/mail"
+	"github.com/juank/attendance-backend/pkg/oidc"
+	"github.com/juank/attendance-backend/pkg/ratelimit"
 	"github.com/juank/attendance-backend/pkg/utils"
+	"go.uber.org/zap"
 )
 
+// loginLock is the part of a user recorded in the audit log when a login lockout starts
+// or is lifted
+type loginLock struct {
+	FailedLoginAttempts int        `json:"failed_login_attempts"`
+	LockedUntil         *time.Time `json:"locked_until"`
+}
+
 type AuthServiceImpl struct {
 	userRepo         repositories.UserRepository
 	refreshTokenRepo repositories.RefreshTokenRepository
+	resetRepo        repositories.PasswordResetTokenRepository
+	recoveryRepo     repositories.MFARecoveryCodeRepository
+	oidcRepo         repositories.OIDCAuthRequestRepository
+	deptRepo         repositories.DepartmentRepository
+	roles            services.Authorizer
+	tokens           services.TokenRevocation
+	keys             *jwtkeys.KeySet
+	sso              *oidc.Provider // nil when single sign-on is not configured
+	mailer           mail.Sender
+	audit            services.AuditRecorder
 	cfg              *config.Config
+	accountPolicy    ratelimit.Policy
+	ipFailures       *ratelimit.FailureTracker
+	unknownFailures  *ratelimit.FailureTracker // failures per email without an account
 }
 
 func NewAuthService(
 	userRepo repositories.UserRepository,
 	refreshTokenRepo repositories.RefreshTokenRepository,
+	resetRepo repositories.PasswordResetTokenRepository,
+	recoveryRepo repositories.MFARecoveryCodeRepository,
+	oidcRepo repositories.OIDCAuthRequestRepository,
+	deptRepo repositories.DepartmentRepository,
+	roles services.Authorizer,
+	tokens services.TokenRevocation,
+	keys *jwtkeys.KeySet,
+	sso *oidc.Provider,
+	mailer mail.Sender,
+	audit services.AuditRecorder,
 	cfg *config.Config,
 ) services.AuthService {
+	policy := ratelimit.Policy{
+		Delay:       cfg.Login.Delay,
+		MaxDelay:    cfg.Login.MaxDelay,
+		MaxFailures: cfg.Login.MaxAttempts,
+		Lockout:     cfg.Login.LockoutDuration,
+	}
+	ipPolicy := policy
+	ipPolicy.MaxFailures = cfg.Login.IPMaxAttempts
+
 	return &AuthServiceImpl{
 		userRepo:         userRepo,
 		refreshTokenRepo: refreshTokenRepo,
+		resetRepo:        resetRepo,
+		recoveryRepo:     recoveryRepo,
+		oidcRepo:         oidcRepo,
+		deptRepo:         deptRepo,
+		roles:            roles,
+		tokens:           tokens,
+		keys:             keys,
+		sso:              sso,
+		mailer:           mailer,
+		audit:            audit,
 		cfg:              cfg,
+		accountPolicy:    policy,
+		ipFailures:       ratelimit.NewFailureTracker(ipPolicy),
+		unknownFailures:  ratelimit.NewFailureTracker(policy),
 	}
 }
 
-func (s *AuthServiceImpl) Register(req *services.RegisterRequest) (*models.User, error) {
-	// Verificar si el email ya existe
-	existingUser, _ := s.userRepo.GetByEmail(req.Email)
-	if existingUser != nil {
+func (s *AuthServiceImpl) Register(client *services.Actor, req *services.RegisterRequest) (*models.User, error) {
+	// Verificar si el email ya existe, también entre cuentas eliminadas
+	email := normalizeEmail(req.Email)
+	taken, err := emailTaken(s.userRepo, email)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
 		return nil, errors.New("email already registered")
 	}
 
@@ -44,7 +108,7 @@ func (s *AuthServiceImpl) Register(req *services.RegisterRequest) (*models.User,
 	}
 
 	user := &models.User{
-		Email:     req.Email,
+		Email:     email,
 		Password:  hashedPassword,
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
@@ -56,16 +120,41 @@ func (s *AuthServiceImpl) Register(req *services.RegisterRequest) (*models.User,
 		return nil, err
 	}
 
+	// Self-registrations are attributed to the new account
+	actor := *client
+	actor.UserID, actor.Role = user.ID, user.Role
+	s.audit.Record(&actor, models.AuditUserRegister, models.AuditTargetUser, user.ID, nil, user)
+
 	return user, nil
 }
 
-func (s *AuthServiceImpl) Login(req *services.LoginRequest) (*services.TokenResponse, error) {
-	user, err := s.userRepo.GetByEmail(req.Email)
+func (s *AuthServiceImpl) Login(client *services.Actor, req *services.LoginRequest) (*services.TokenResponse, error) {
+	// El límite por IP frena a quien prueba muchas cuentas desde el mismo origen
+	if wait := s.ipFailures.Wait(client.IP); wait > 0 {
+		return nil, &services.LoginThrottledError{RetryAfter: wait}
+	}
+
+	email := normalizeEmail(req.Email)
+	user, err := s.userRepo.GetByEmail(email)
 	if err != nil {
+		// Un email sin cuenta se limita igual que una cuenta para no revelar si existe
+		if wait := s.unknownFailures.Wait(email); wait > 0 {
+			return nil, &services.LoginThrottledError{RetryAfter: wait}
+		}
+		s.ipFailures.Fail(client.IP)
+		s.unknownFailures.Fail(email)
 		return nil, errors.New("invalid credentials")
 	}
 
+	// Una cuenta bloqueada rechaza incluso la contraseña correcta
+	now := time.Now()
+	if wait := s.accountWait(user, now); wait > 0 {
+		return nil, &services.LoginThrottledError{RetryAfter: wait}
+	}
+
 	if !utils.CheckPasswordHash(req.Password, user.Password) {
+		s.ipFailures.Fail(client.IP)
+		s.recordFailure(client, user, now)
 		return nil, errors.New("invalid credentials")
 	}
 
@@ -73,13 +162,58 @@ func (s *AuthServiceImpl) Login(req *services.LoginRequest) (*services.TokenResp
 		return nil, errors.New("user is inactive")
 	}
 
-	return s.generateTokens(user)
+	// Con MFA, los fallos se limpian solo tras el segundo factor; si no, bastaría la
+	// contraseña para seguir probando códigos sin bloqueo
+	if user.MFAEnabled || s.roles.RequiresMFA(user.Role) {
+		return s.challenge(user)
+	}
+
+	return s.completeLogin(client, user)
 }
 
-func (s *AuthServiceImpl) RefreshToken(tokenString string) (*services.TokenResponse, error) {
-	// Validar refresh token
-	_, err := utils.ValidateToken(tokenString, s.cfg.JWT.Secret)
+// accountWait returns how long the account must wait before its next login attempt
+func (s *AuthServiceImpl) accountWait(user *models.User, now time.Time) time.Duration {
+	if user.LockedUntil != nil && user.LockedUntil.After(now) {
+		return user.LockedUntil.Sub(now)
+	}
+	if user.LastFailedLoginAt == nil || s.accountPolicy.Expired(*user.LastFailedLoginAt, now) {
+		return 0
+	}
+	if wait := s.accountPolicy.RetryAt(user.FailedLoginAttempts, *user.LastFailedLoginAt).Sub(now); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// recordFailure counts a wrong password for the account and locks it once the limit is
+// reached. Storage errors are logged so they never reveal more than "invalid credentials".
+func (s *AuthServiceImpl) recordFailure(client *services.Actor, user *models.User, now time.Time) {
+	attempts, err := s.userRepo.RecordLoginFailure(user.ID, now, now.Add(-s.accountPolicy.Lockout))
 	if err != nil {
+		logger.Error("Failed to record login failure", zap.Uint("user_id", user.ID), zap.Error(err))
+		return
+	}
+	if !s.accountPolicy.Locked(attempts) {
+		return
+	}
+
+	until := now.Add(s.accountPolicy.Lockout)
+	if err := s.userRepo.Lock(user.ID, until); err != nil {
+		logger.Error("Failed to lock account", zap.Uint("user_id", user.ID), zap.Error(err))
+		return
+	}
+	logger.Warn("Account locked after failed logins",
+		zap.Uint("user_id", user.ID),
+		zap.Int("attempts", attempts),
+		zap.String("ip", client.IP),
+	)
+	s.audit.Record(client, models.AuditUserLock, models.AuditTargetUser, user.ID,
+		loginLock{}, loginLock{attempts, &until})
+}
+
+func (s *AuthServiceImpl) RefreshToken(client *services.Actor, tokenString string) (*services.TokenResponse, error) {
+	// Validar refresh token
+	if err := utils.ValidateRefreshToken(tokenString, s.keys); err != nil {
 		return nil, errors.New("invalid refresh token")
 	}
 
@@ -90,6 +224,9 @@ func (s *AuthServiceImpl) RefreshToken(tokenString string) (*services.TokenRespo
 	}
 
 	if storedToken.Revoked {
+		if isReusedRefreshToken(storedToken, time.Now()) {
+			s.revokeReusedSession(client, storedToken)
+		}
 		return nil, errors.New("refresh token revoked")
 	}
 
@@ -98,38 +235,101 @@ func (s *AuthServiceImpl) RefreshToken(tokenString string) (*services.TokenRespo
 	if err != nil {
 		return nil, errors.New("user not found")
 	}
+	if !user.IsActive {
+		return nil, errors.New("user is inactive")
+	}
 
-	// Revocar token anterior (rotación de refresh tokens)
-	if err := s.refreshTokenRepo.Revoke(storedToken.ID); err != nil {
+	// Revocar token anterior (rotación de refresh tokens). Si otra petición se adelantó,
+	// es un refresco simultáneo (dos pestañas) o un cierre de sesión, no un robo.
+	rotated, err := s.refreshTokenRepo.Rotate(storedToken.ID, time.Now())
+	if err != nil {
 		return nil, err
 	}
+	if !rotated {
+		return nil, errors.New("refresh token revoked")
+	}
 
-	return s.generateTokens(user)
+	return s.generateTokens(user, client, storedToken.FamilyID, storedToken.SessionStartedAt)
+}
+
+// refreshReuseGrace is how long after a rotation the old refresh token may still be
+// presented without counting as reuse, so that clients refreshing concurrently (several
+// tabs) are rejected without ending the session
+const refreshReuseGrace = 30 * time.Second
+
+// isReusedRefreshToken reports whether a revoked refresh token is being reused: it was
+// exchanged for a new one some time ago. Tokens revoked by a logout, a password change or
+// an administrator were never exchanged and are simply rejected.
+func isReusedRefreshToken(token *models.RefreshToken, now time.Time) bool {
+	return token.LastUsedAt != nil && now.Sub(*token.LastUsedAt) >= refreshReuseGrace
+}
+
+// revokeReusedSession ends the session of a refresh token presented after it was rotated.
+// Only a copy of the token can be presented twice, so whoever holds the session's current
+// token may be an attacker, or the attacker may hold it after this request. Sessions that
+// already ended are left alone.
+func (s *AuthServiceImpl) revokeReusedSession(client *services.Actor, token *models.RefreshToken) {
+	active, err := s.refreshTokenRepo.RevokeUserFamily(token.UserID, token.FamilyID, time.Now())
+	if err != nil {
+		logger.Error("Failed to revoke reused session", zap.String("session_id", token.FamilyID), zap.Error(err))
+		return
+	}
+	if !active {
+		return
+	}
+	if err := s.tokens.RevokeSession(token.UserID, token.FamilyID); err != nil {
+		logger.Error("Failed to revoke access tokens of reused session", zap.String("session_id", token.FamilyID), zap.Error(err))
+	}
+	logger.Warn("Refresh token reuse detected; session revoked",
+		zap.Uint("user_id", token.UserID),
+		zap.String("session_id", token.FamilyID),
+		zap.String("ip", client.IP),
+	)
+	s.audit.Record(client, models.AuditSessionReuse, models.AuditTargetUser, token.UserID,
+		nil, sessionRef{token.FamilyID})
 }
 
 func (s *AuthServiceImpl) Logout(tokenString string) error {
-	// Simplemente revocamos el refresh token si se proporciona
-	// En una implementación stateless pura, el logout es del lado del cliente (borrar token)
-	// Pero aquí invalidamos el refresh token
+	// Revocamos la sesión del refresh token si se proporciona: su refresh token y los
+	// access tokens que emitió
 	storedToken, err := s.refreshTokenRepo.GetByToken(tokenString)
 	if err == nil && storedToken != nil {
-		return s.refreshTokenRepo.Revoke(storedToken.ID)
+		if err := s.refreshTokenRepo.RevokeFamily(storedToken.FamilyID); err != nil {
+			return err
+		}
+		return s.tokens.RevokeSession(storedToken.UserID, storedToken.FamilyID)
 	}
 	return nil
 }
 
-func (s *AuthServiceImpl) generateTokens(user *models.User) (*services.TokenResponse, error) {
-	accessToken, refreshToken, err := utils.GenerateTokenPair(user.ID, user.Email, string(user.Role), s.cfg)
+// generateTokens issues a token pair for a session of the client. An empty familyID
+// starts a new session.
+func (s *AuthServiceImpl) generateTokens(user *models.User, client *services.Actor, familyID string, startedAt time.Time) (*services.TokenResponse, error) {
+	now := time.Now()
+	if familyID == "" {
+		familyID, startedAt = uuid.NewString(), now
+	}
+
+	accessToken, refreshToken, err := utils.GenerateTokenPair(user.ID, user.Email, string(user.Role), familyID, user.TokenVersion, s.keys, s.cfg)
 	if err != nil {
 		return nil, err
 	}
 
+	userAgent := client.UserAgent
+	if len(userAgent) > auditUserAgentLimit {
+		userAgent = userAgent[:auditUserAgentLimit]
+	}
+
 	// Guardar refresh token
 	rt := &models.RefreshToken{
-		UserID:    user.ID,
-		Token:     refreshToken,
-		ExpiresAt: time.Now().Add(s.cfg.JWT.RefreshExpiration),
-		Revoked:   false,
+		UserID:           user.ID,
+		Token:            refreshToken,
+		ExpiresAt:        now.Add(s.cfg.JWT.RefreshExpiration),
+		Revoked:          false,
+		FamilyID:         familyID,
+		SessionStartedAt: startedAt,
+		IP:               client.IP,
+		UserAgent:        userAgent,
 	}
 
 	if err := s.refreshTokenRepo.Create(rt); err != nil {
@@ -141,3 +341,111 @@ func (s *AuthServiceImpl) generateTokens(user *models.User) (*services.TokenResp
 		RefreshToken: refreshToken,
 	}, nil
 }
+
+// passwordResetCooldown is the minimum time between two reset emails to the same account
+const passwordResetCooldown = time.Minute
+
+func (s *AuthServiceImpl) ForgotPassword(client *services.Actor, req *services.ForgotPasswordRequest) {
+	// Se procesa en segundo plano para que el tiempo de respuesta no revele si el email existe
+	go s.sendPasswordReset(client, normalizeEmail(req.Email))
+}
+
+func (s *AuthServiceImpl) sendPasswordReset(client *services.Actor, email string) {
+	user, err := s.userRepo.GetByEmail(email)
+	if err != nil || !user.IsActive {
+		return
+	}
+
+	now := time.Now()
+	if latest, err := s.resetRepo.GetLatestByUserID(user.ID); err == nil && now.Sub(latest.CreatedAt) < passwordResetCooldown {
+		return
+	}
+
+	token, hash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		logger.Error("Failed to generate password reset token", zap.Uint("user_id", user.ID), zap.Error(err))
+		return
+	}
+
+	// Solo el último enlace enviado sigue siendo válido
+	if err := s.resetRepo.InvalidateByUserID(user.ID, now); err != nil {
+		logger.Error("Failed to invalidate password reset tokens", zap.Uint("user_id", user.ID), zap.Error(err))
+		return
+	}
+	reset := &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: now.Add(s.cfg.PasswordReset.Expiration),
+		IP:        client.IP,
+	}
+	if err := s.resetRepo.Create(reset); err != nil {
+		logger.Error("Failed to store password reset token", zap.Uint("user_id", user.ID), zap.Error(err))
+		return
+	}
+
+	link, err := resetLink(s.cfg.PasswordReset.URL, token)
+	if err != nil {
+		logger.Error("Invalid PASSWORD_RESET_URL", zap.Error(err))
+		return
+	}
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"We received a request to reset the password of your account. Open this link to choose a new one:\n\n"+
+			"%s\n\n"+
+			"The link expires in %s and can only be used once. If you didn't ask for it, you can ignore this email; your password won't change.\n",
+			user.FirstName, link, s.cfg.PasswordReset.Expiration),
+	}
+	if err := s.mailer.Send(msg); err != nil {
+		logger.Error("Failed to send password reset email", zap.Uint("user_id", user.ID), zap.Error(err))
+	}
+}
+
+// resetLink adds the token to the frontend's reset page URL
+func resetLink(base, token string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+func (s *AuthServiceImpl) ResetPassword(client *services.Actor, req *services.ResetPasswordRequest) error {
+	invalid := errors.New("invalid or expired reset token")
+
+	reset, err := s.resetRepo.GetByHash(utils.HashToken(req.Token))
+	if err != nil {
+		return invalid
+	}
+	now := time.Now()
+	if reset.UsedAt != nil || now.After(reset.ExpiresAt) || !reset.User.IsActive {
+		return invalid
+	}
+
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	// El token se consume junto con el cambio de contraseña: si algo falla, el enlace
+	// sigue sirviendo. Las sesiones abiertas pueden ser de quien conocía la contraseña
+	// anterior, así que se revocan en la misma transacción.
+	used, err := s.resetRepo.Redeem(reset, hashedPassword, now)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return invalid
+	}
+	user := &reset.User
+	s.tokens.Forget(user.ID)
+
+	actor := *client
+	actor.UserID, actor.Role = user.ID, user.Role
+	s.audit.Record(&actor, models.AuditUserPasswordReset, models.AuditTargetUser, user.ID, nil, passwordChanged)
+	return nil
+}
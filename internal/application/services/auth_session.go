@@ -0,0 +1,128 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/services"
+)
+
+// sessionRef is the audit payload naming the session an operation applied to
+type sessionRef struct {
+	SessionID string `json:"session_id"`
+}
+
+func (s *AuthServiceImpl) ListSessions(userID uint, currentSessionID string) ([]services.Session, error) {
+	tokens, err := s.refreshTokenRepo.GetActiveByUserID(userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// Newest first, so the first token of each family is its current one
+	sessions := make([]services.Session, 0, len(tokens))
+	seen := make(map[string]bool, len(tokens))
+	for _, token := range tokens {
+		if seen[token.FamilyID] {
+			continue
+		}
+		seen[token.FamilyID] = true
+		sessions = append(sessions, services.Session{
+			ID:         token.FamilyID,
+			Device:     describeDevice(token.UserAgent),
+			UserAgent:  token.UserAgent,
+			IP:         token.IP,
+			SignedInAt: token.SessionStartedAt,
+			LastUsedAt: token.CreatedAt,
+			ExpiresAt:  token.ExpiresAt,
+			Current:    currentSessionID != "" && token.FamilyID == currentSessionID,
+		})
+	}
+	return sessions, nil
+}
+
+func (s *AuthServiceImpl) RevokeSession(actor *services.Actor, sessionID string) error {
+	revoked, err := s.refreshTokenRepo.RevokeUserFamily(actor.UserID, sessionID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errors.New("session not found")
+	}
+	if err := s.tokens.RevokeSession(actor.UserID, sessionID); err != nil {
+		return err
+	}
+
+	s.audit.Record(actor, models.AuditSessionRevoke, models.AuditTargetUser, actor.UserID, sessionRef{sessionID}, nil)
+	return nil
+}
+
+func (s *AuthServiceImpl) RevokeAllSessions(actor *services.Actor, userID uint) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if !s.roles.CanAssign(actor.Role, user.Role) {
+		return services.ErrUserNotManageable
+	}
+
+	if err := s.refreshTokenRepo.RevokeByUserID(user.ID); err != nil {
+		return err
+	}
+	if err := s.tokens.RevokeUser(user.ID); err != nil {
+		return err
+	}
+
+	s.audit.Record(actor, models.AuditUserForceLogout, models.AuditTargetUser, user.ID, nil, nil)
+	return nil
+}
+
+// describeDevice names the browser and operating system of a user agent, falling back to
+// the first product token for other clients (mobile apps, curl...)
+func describeDevice(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	browser := ""
+	for _, candidate := range []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	} {
+		if strings.Contains(userAgent, candidate.token) {
+			browser = candidate.name
+			break
+		}
+	}
+
+	platform := ""
+	for _, candidate := range []struct{ token, name string }{
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(userAgent, candidate.token) {
+			platform = candidate.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform
+	}
+	product := strings.Fields(userAgent)[0]
+	return strings.SplitN(product, "/", 2)[0]
+}
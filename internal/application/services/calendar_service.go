@@ -0,0 +1,343 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/repositories"
+	"github.com/juank/attendance-backend/internal/domain/services"
+	"github.com/juank/attendance-backend/pkg/ical"
+	"github.com/juank/attendance-backend/pkg/recurrence"
+	"github.com/juank/attendance-backend/pkg/utils"
+)
+
+const (
+	// calendarFeedLookback is how far back the user feed includes past events
+	calendarFeedLookback = 90 * 24 * time.Hour
+	// calendarFeedTTL is the refresh interval suggested to subscribed clients
+	calendarFeedTTL   = time.Hour
+	calendarUIDDomain = "attendance-backend"
+)
+
+type CalendarServiceImpl struct {
+	tokenRepo       repositories.CalendarTokenRepository
+	eventRepo       repositories.EventRepository
+	seriesRepo      repositories.EventSeriesRepository
+	participantRepo repositories.EventParticipantRepository
+	roles           services.Authorizer
+	audit           services.AuditRecorder
+}
+
+func NewCalendarService(
+	tokenRepo repositories.CalendarTokenRepository,
+	eventRepo repositories.EventRepository,
+	seriesRepo repositories.EventSeriesRepository,
+	participantRepo repositories.EventParticipantRepository,
+	roles services.Authorizer,
+	audit services.AuditRecorder,
+) services.CalendarService {
+	return &CalendarServiceImpl{
+		tokenRepo:       tokenRepo,
+		eventRepo:       eventRepo,
+		seriesRepo:      seriesRepo,
+		participantRepo: participantRepo,
+		roles:           roles,
+		audit:           audit,
+	}
+}
+
+func (s *CalendarServiceImpl) GetToken(userID uint) (*models.CalendarToken, error) {
+	token, err := s.tokenRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, errors.New("calendar token not found")
+	}
+	return token, nil
+}
+
+func (s *CalendarServiceImpl) RotateToken(actor *services.Actor) (string, *models.CalendarToken, error) {
+	plain, hash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	previous, _ := s.tokenRepo.GetByUserID(actor.UserID)
+	token := &models.CalendarToken{
+		UserID:    actor.UserID,
+		TokenHash: hash,
+	}
+	if err := s.tokenRepo.Replace(token); err != nil {
+		return "", nil, err
+	}
+
+	s.audit.Record(actor, models.AuditCalendarTokenRotate, models.AuditTargetCalendarToken, token.ID, previous, token)
+	return plain, token, nil
+}
+
+func (s *CalendarServiceImpl) RevokeToken(actor *services.Actor) error {
+	token, err := s.GetToken(actor.UserID)
+	if err != nil {
+		return err
+	}
+	if err := s.tokenRepo.DeleteByUserID(actor.UserID); err != nil {
+		return err
+	}
+
+	s.audit.Record(actor, models.AuditCalendarTokenRevoke, models.AuditTargetCalendarToken, token.ID, token, nil)
+	return nil
+}
+
+func (s *CalendarServiceImpl) Authenticate(token string) (*models.User, error) {
+	if token == "" {
+		return nil, errors.New("invalid calendar token")
+	}
+
+	stored, err := s.tokenRepo.GetByHash(utils.HashToken(token))
+	if err != nil || !stored.User.IsActive {
+		return nil, errors.New("invalid calendar token")
+	}
+
+	// Usage tracking is informative only, a failed write must not break the feed
+	_ = s.tokenRepo.Touch(stored.ID, time.Now())
+
+	return &stored.User, nil
+}
+
+func (s *CalendarServiceImpl) GetUserFeed(user *models.User) (*ical.Calendar, error) {
+	events, err := s.eventRepo.GetStartingFrom(time.Now().Add(-calendarFeedLookback))
+	if err != nil {
+		return nil, err
+	}
+
+	visible, err := s.visibleEvents(user, events)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ical.Calendar{
+		Name:   fmt.Sprintf("Events - %s %s", user.FirstName, user.LastName),
+		TTL:    calendarFeedTTL,
+		Events: s.calendarEvents(visible),
+	}, nil
+}
+
+func (s *CalendarServiceImpl) GetEventCalendar(user *models.User, eventID uint, wholeSeries bool) (*ical.Calendar, error) {
+	event, err := s.eventRepo.GetByID(eventID)
+	if err != nil {
+		return nil, errors.New("event not found")
+	}
+
+	// Restricted events are hidden from users outside the roster
+	visible, err := s.visibleEvents(user, []models.Event{*event})
+	if err != nil {
+		return nil, err
+	}
+	if len(visible) == 0 {
+		return nil, errors.New("event not found")
+	}
+
+	calendar := &ical.Calendar{Name: event.Title}
+
+	if wholeSeries && event.SeriesID != nil {
+		if series, err := s.seriesRepo.GetByID(*event.SeriesID); err == nil {
+			occurrences, err := s.eventRepo.GetBySeriesID(series.ID, time.Time{})
+			if err != nil {
+				return nil, err
+			}
+			if occurrences, err = s.visibleEvents(user, occurrences); err != nil {
+				return nil, err
+			}
+			calendar.Name = series.Title
+			calendar.Events = seriesCalendarEvents(series, occurrences)
+			return calendar, nil
+		}
+	}
+
+	calendar.Events = []ical.Event{standaloneCalendarEvent(event)}
+	return calendar, nil
+}
+
+// visibleEvents drops the restricted events whose roster does not cover the user.
+// Users who manage events see every event.
+func (s *CalendarServiceImpl) visibleEvents(user *models.User, events []models.Event) ([]models.Event, error) {
+	if s.roles.HasPermission(user.Role, models.PermEventsWrite) {
+		return events, nil
+	}
+
+	var restricted []uint
+	for i := range events {
+		if events[i].IsRestricted {
+			restricted = append(restricted, events[i].ID)
+		}
+	}
+
+	participants, err := s.participantRepo.GetByEventIDs(restricted)
+	if err != nil {
+		return nil, err
+	}
+	expected := make(map[uint]bool)
+	for i := range participants {
+		if participants[i].Matches(user) {
+			expected[participants[i].EventID] = true
+		}
+	}
+
+	visible := make([]models.Event, 0, len(events))
+	for i := range events {
+		if !events[i].IsRestricted || expected[events[i].ID] {
+			visible = append(visible, events[i])
+		}
+	}
+	return visible, nil
+}
+
+// calendarEvents converts events into VEVENTs. Occurrences of a series are folded into a
+// single recurring VEVENT plus overrides for the occurrences that differ from the rule.
+func (s *CalendarServiceImpl) calendarEvents(events []models.Event) []ical.Event {
+	items := make([]ical.Event, 0, len(events))
+	bySeries := make(map[uint][]models.Event)
+	var seriesIDs []uint
+
+	for i := range events {
+		if events[i].SeriesID == nil {
+			items = append(items, standaloneCalendarEvent(&events[i]))
+			continue
+		}
+		id := *events[i].SeriesID
+		if _, ok := bySeries[id]; !ok {
+			seriesIDs = append(seriesIDs, id)
+		}
+		bySeries[id] = append(bySeries[id], events[i])
+	}
+
+	for _, id := range seriesIDs {
+		series, err := s.seriesRepo.GetByID(id)
+		if err != nil {
+			// The series was deleted; what is left of it are plain events
+			for i := range bySeries[id] {
+				items = append(items, standaloneCalendarEvent(&bySeries[id][i]))
+			}
+			continue
+		}
+		items = append(items, seriesCalendarEvents(series, bySeries[id])...)
+	}
+
+	return items
+}
+
+// seriesCalendarEvents builds the recurring VEVENT of a series. Occurrences that were
+// edited on their own become overrides (RECURRENCE-ID); those whose date is no longer
+// part of the rule are exported as separate events.
+func seriesCalendarEvents(series *models.EventSeries, occurrences []models.Event) []ical.Event {
+	rule, err := recurrence.Parse(series.RRule)
+	if err != nil {
+		items := make([]ical.Event, 0, len(occurrences))
+		for i := range occurrences {
+			items = append(items, standaloneCalendarEvent(&occurrences[i]))
+		}
+		return items
+	}
+
+	template := &series.Template
+	loc := template.Location()
+	start := template.StartTime.In(loc)
+	ruleStart := func(date time.Time) time.Time {
+		return time.Date(date.Year(), date.Month(), date.Day(), start.Hour(), start.Minute(), start.Second(), 0, loc)
+	}
+
+	master := ical.Event{
+		UID:         seriesCalendarUID(series.ID),
+		Summary:     template.Title,
+		Description: template.Description,
+		Start:       start,
+		TimeZone:    template.Timezone,
+		RRule:       rule.ICal(loc),
+		Modified:    series.UpdatedAt,
+	}
+	if !template.EndTime.IsZero() {
+		master.End = start.Add(template.EndTime.Sub(template.StartTime))
+	}
+	for _, value := range series.ExceptionDates {
+		if date, err := time.Parse(models.OccurrenceDateLayout, value); err == nil {
+			master.ExDates = append(master.ExDates, ruleStart(date))
+		}
+	}
+
+	// Instants produced by the rule over the span of the given occurrences
+	inRule := make(map[int64]bool)
+	var first, last time.Time
+	for i := range occurrences {
+		if occurrences[i].OccurrenceDate == nil {
+			continue
+		}
+		t := ruleStart(*occurrences[i].OccurrenceDate)
+		if first.IsZero() || t.Before(first) {
+			first = t
+		}
+		if t.After(last) {
+			last = t
+		}
+	}
+	if !first.IsZero() {
+		for _, t := range rule.Between(start, first, last) {
+			inRule[t.Unix()] = true
+		}
+	}
+
+	items := []ical.Event{master}
+	for i := range occurrences {
+		occurrence := &occurrences[i]
+		if occurrence.OccurrenceDate == nil {
+			items = append(items, standaloneCalendarEvent(occurrence))
+			continue
+		}
+
+		original := ruleStart(*occurrence.OccurrenceDate)
+		if !inRule[original.Unix()] {
+			items = append(items, standaloneCalendarEvent(occurrence))
+			continue
+		}
+		if followsTemplate(occurrence, template, original, master.End.Sub(master.Start)) {
+			continue
+		}
+
+		override := standaloneCalendarEvent(occurrence)
+		override.UID = master.UID
+		override.TimeZone = template.Timezone
+		override.RecurrenceID = &original
+		items = append(items, override)
+	}
+
+	return items
+}
+
+// followsTemplate reports whether an occurrence looks exactly like the rule instance
+// the recurring VEVENT already describes
+func followsTemplate(occurrence, template *models.Event, start time.Time, duration time.Duration) bool {
+	if occurrence.Title != template.Title || occurrence.Description != template.Description {
+		return false
+	}
+	if !occurrence.StartTime.Equal(start) {
+		return false
+	}
+	if occurrence.EndTime.IsZero() || template.EndTime.IsZero() {
+		return occurrence.EndTime.IsZero() == template.EndTime.IsZero()
+	}
+	return occurrence.EndTime.Equal(start.Add(duration))
+}
+
+func standaloneCalendarEvent(event *models.Event) ical.Event {
+	return ical.Event{
+		UID:         fmt.Sprintf("event-%d@%s", event.ID, calendarUIDDomain),
+		Summary:     event.Title,
+		Description: event.Description,
+		Start:       event.StartTime,
+		End:         event.EndTime,
+		Modified:    event.UpdatedAt,
+	}
+}
+
+func seriesCalendarUID(seriesID uint) string {
+	return fmt.Sprintf("series-%d@%s", seriesID, calendarUIDDomain)
+}
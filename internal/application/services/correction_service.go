@@ -0,0 +1,292 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/repositories"
+	"github.com/juank/attendance-backend/internal/domain/services"
+)
+
+type CorrectionServiceImpl struct {
+	correctionRepo repositories.AttendanceCorrectionRepository
+	attendanceRepo repositories.AttendanceRepository
+	userRepo       repositories.UserRepository
+	deptRepo       repositories.DepartmentRepository
+	roles          services.Authorizer
+	webhooks       services.WebhookPublisher
+	audit          services.AuditRecorder
+}
+
+func NewCorrectionService(
+	correctionRepo repositories.AttendanceCorrectionRepository,
+	attendanceRepo repositories.AttendanceRepository,
+	userRepo repositories.UserRepository,
+	deptRepo repositories.DepartmentRepository,
+	roles services.Authorizer,
+	webhooks services.WebhookPublisher,
+	audit services.AuditRecorder,
+) services.CorrectionService {
+	return &CorrectionServiceImpl{
+		correctionRepo: correctionRepo,
+		attendanceRepo: attendanceRepo,
+		userRepo:       userRepo,
+		deptRepo:       deptRepo,
+		roles:          roles,
+		webhooks:       webhooks,
+		audit:          audit,
+	}
+}
+
+func (s *CorrectionServiceImpl) Create(actor *services.Actor, attendanceID uint, req *services.CreateCorrectionRequest) (*models.AttendanceCorrection, error) {
+	if req.Status == nil && req.CheckIn == nil && req.CheckOut == nil {
+		return nil, errors.New("at least one of status, check_in or check_out is required")
+	}
+
+	attendance, err := s.attendanceRepo.GetByID(attendanceID)
+	if err != nil || attendance.UserID != actor.UserID {
+		return nil, errors.New("attendance not found")
+	}
+
+	if req.Status != nil && !models.IsValidAttendanceStatus(*req.Status) {
+		return nil, errors.New("invalid status")
+	}
+
+	checkIn := attendance.CheckIn
+	if req.CheckIn != nil {
+		checkIn = *req.CheckIn
+	}
+	if req.CheckOut != nil && req.CheckOut.Before(checkIn) {
+		return nil, errors.New("check_out must be after check_in")
+	}
+
+	pending, err := s.correctionRepo.HasPending(attendanceID)
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return nil, errors.New("attendance already has a pending correction")
+	}
+
+	correction := &models.AttendanceCorrection{
+		AttendanceID:      attendanceID,
+		RequesterID:       actor.UserID,
+		RequestedStatus:   req.Status,
+		RequestedCheckIn:  req.CheckIn,
+		RequestedCheckOut: req.CheckOut,
+		Reason:            req.Reason,
+		Status:            models.CorrectionPending,
+	}
+
+	if err := s.correctionRepo.Create(correction); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(actor, models.AuditCorrectionCreate, models.AuditTargetCorrection, correction.ID, nil, correction)
+	return correction, nil
+}
+
+func (s *CorrectionServiceImpl) GetUserCorrections(userID uint, page, limit int) ([]models.AttendanceCorrection, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	return s.correctionRepo.GetByRequesterID(userID, page, limit)
+}
+
+func (s *CorrectionServiceImpl) Cancel(actor *services.Actor, id uint) (*models.AttendanceCorrection, error) {
+	correction, err := s.correctionRepo.GetByID(id)
+	if err != nil || correction.RequesterID != actor.UserID {
+		return nil, errors.New("correction not found")
+	}
+
+	if correction.Status != models.CorrectionPending {
+		return nil, errors.New("only pending corrections can be cancelled")
+	}
+
+	before := *correction
+	correction.Status = models.CorrectionCancelled
+	cancelled, err := s.correctionRepo.Resolve(correction)
+	if err != nil {
+		return nil, err
+	}
+	if !cancelled {
+		return nil, errors.New("only pending corrections can be cancelled")
+	}
+
+	s.audit.Record(actor, models.AuditCorrectionCancel, models.AuditTargetCorrection, correction.ID, &before, correction)
+	return correction, nil
+}
+
+func (s *CorrectionServiceImpl) GetPendingForReviewer(reviewerID uint, role models.Role) ([]models.AttendanceCorrection, error) {
+	if s.roles.HasPermission(role, models.PermCorrectionsReviewAll) {
+		return s.correctionRepo.GetPending(nil)
+	}
+
+	departmentIDs, err := managedDepartmentIDs(s.deptRepo, reviewerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(departmentIDs) == 0 {
+		return []models.AttendanceCorrection{}, nil
+	}
+
+	return s.correctionRepo.GetPending(departmentIDs)
+}
+
+func (s *CorrectionServiceImpl) Approve(actor *services.Actor, id uint, req *services.ReviewCorrectionRequest) (*models.AttendanceCorrection, error) {
+	correction, err := s.getReviewable(actor, id)
+	if err != nil {
+		return nil, err
+	}
+
+	attendance := correction.Attendance
+	if attendance == nil {
+		return nil, errors.New("attendance not found")
+	}
+	previous := *attendance
+
+	revision := &models.AttendanceRevision{
+		AttendanceID:     attendance.ID,
+		CorrectionID:     &correction.ID,
+		ChangedByID:      actor.UserID,
+		PreviousStatus:   attendance.Status,
+		PreviousCheckIn:  attendance.CheckIn,
+		PreviousCheckOut: attendance.CheckOut,
+		PreviousNotes:    attendance.Notes,
+	}
+
+	applyCorrection(attendance, correction)
+
+	revision.NewStatus = attendance.Status
+	revision.NewCheckIn = attendance.CheckIn
+	revision.NewCheckOut = attendance.CheckOut
+	revision.NewNotes = attendance.Notes
+
+	// The revision keeps the original values; it is stored with the change it describes
+	before := markReviewed(actor, correction, models.CorrectionApproved, req.Note)
+	approved, err := s.correctionRepo.Approve(correction, attendance, revision)
+	if err != nil {
+		return nil, err
+	}
+	if !approved {
+		return nil, errors.New("correction has already been reviewed")
+	}
+
+	s.audit.Record(actor, models.AuditCorrectionApprove, models.AuditTargetCorrection, correction.ID, &before, correction)
+	s.audit.Record(actor, models.AuditCorrectionApprove, models.AuditTargetAttendance, attendance.ID, &previous, attendance)
+
+	s.webhooks.Publish(models.WebhookAttendanceCorrected, &services.AttendanceCorrectedData{
+		CorrectionID: correction.ID,
+		Attendance:   attendance,
+		Revision:     revision,
+	})
+
+	return correction, nil
+}
+
+func (s *CorrectionServiceImpl) Reject(actor *services.Actor, id uint, req *services.ReviewCorrectionRequest) (*models.AttendanceCorrection, error) {
+	correction, err := s.getReviewable(actor, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.finishReview(actor, correction, models.CorrectionRejected, req.Note); err != nil {
+		return nil, err
+	}
+
+	return correction, nil
+}
+
+func (s *CorrectionServiceImpl) GetHistory(attendanceID, viewerID uint, role models.Role) ([]models.AttendanceRevision, error) {
+	attendance, err := s.attendanceRepo.GetByID(attendanceID)
+	if err != nil {
+		return nil, errors.New("attendance not found")
+	}
+
+	if attendance.UserID != viewerID {
+		viewAll := s.roles.HasPermission(role, models.PermCorrectionsReviewAll) || s.roles.HasPermission(role, models.PermAttendanceRead)
+		if err := checkDepartmentReviewer(s.userRepo, attendance.UserID, viewerID, viewAll); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.correctionRepo.GetRevisions(attendanceID)
+}
+
+// getReviewable loads a pending correction the reviewer is allowed to decide on
+func (s *CorrectionServiceImpl) getReviewable(actor *services.Actor, id uint) (*models.AttendanceCorrection, error) {
+	correction, err := s.correctionRepo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("correction not found")
+	}
+
+	if correction.Status != models.CorrectionPending {
+		return nil, errors.New("correction has already been reviewed")
+	}
+
+	reviewAll := s.roles.HasPermission(actor.Role, models.PermCorrectionsReviewAll)
+	if err := checkDepartmentReviewer(s.userRepo, correction.RequesterID, actor.UserID, reviewAll); err != nil {
+		return nil, err
+	}
+
+	return correction, nil
+}
+
+func (s *CorrectionServiceImpl) finishReview(actor *services.Actor, correction *models.AttendanceCorrection, status models.CorrectionStatus, note string) error {
+	before := markReviewed(actor, correction, status, note)
+	resolved, err := s.correctionRepo.Resolve(correction)
+	if err != nil {
+		return err
+	}
+	if !resolved {
+		return errors.New("correction has already been reviewed")
+	}
+
+	action := models.AuditCorrectionApprove
+	if status == models.CorrectionRejected {
+		action = models.AuditCorrectionReject
+	}
+	s.audit.Record(actor, action, models.AuditTargetCorrection, correction.ID, &before, correction)
+	return nil
+}
+
+// markReviewed records the outcome of the review on the correction and returns its
+// previous state
+func markReviewed(actor *services.Actor, correction *models.AttendanceCorrection, status models.CorrectionStatus, note string) models.AttendanceCorrection {
+	before := *correction
+	now := time.Now()
+	reviewerID := actor.UserID
+	correction.Status = status
+	correction.ReviewerID = &reviewerID
+	correction.ReviewedAt = &now
+	correction.ReviewNote = note
+	return before
+}
+
+// applyCorrection updates the attendance with the requested values. A new check-in
+// without an explicit status re-derives the status from the event rules, and the
+// time on site is recomputed whenever either end changes.
+func applyCorrection(attendance *models.Attendance, correction *models.AttendanceCorrection) {
+	event := &attendance.Event
+
+	if correction.RequestedCheckIn != nil {
+		attendance.CheckIn = *correction.RequestedCheckIn
+		if correction.RequestedStatus == nil {
+			attendance.Status = string(event.AttendanceStatusAt(attendance.CheckIn))
+		}
+	}
+
+	if correction.RequestedStatus != nil {
+		attendance.Status = string(*correction.RequestedStatus)
+	}
+
+	if correction.RequestedCheckOut != nil {
+		attendance.RecordCheckOut(*correction.RequestedCheckOut, event)
+	} else if correction.RequestedCheckIn != nil && attendance.CheckOut != nil {
+		attendance.RecordCheckOut(*attendance.CheckOut, event)
+	}
+}
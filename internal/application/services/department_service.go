@@ -8,15 +8,17 @@ import (
 
 type DepartmentServiceImpl struct {
 	deptRepo repositories.DepartmentRepository
+	audit    services.AuditRecorder
 }
 
-func NewDepartmentService(deptRepo repositories.DepartmentRepository) services.DepartmentService {
+func NewDepartmentService(deptRepo repositories.DepartmentRepository, audit services.AuditRecorder) services.DepartmentService {
 	return &DepartmentServiceImpl{
 		deptRepo: deptRepo,
+		audit:    audit,
 	}
 }
 
-func (s *DepartmentServiceImpl) Create(req *services.CreateDepartmentRequest) (*models.Department, error) {
+func (s *DepartmentServiceImpl) Create(actor *services.Actor, req *services.CreateDepartmentRequest) (*models.Department, error) {
 	dept := &models.Department{
 		Name:        req.Name,
 		Description: req.Description,
@@ -27,6 +29,7 @@ func (s *DepartmentServiceImpl) Create(req *services.CreateDepartmentRequest) (*
 		return nil, err
 	}
 
+	s.audit.Record(actor, models.AuditDepartmentCreate, models.AuditTargetDepartment, dept.ID, nil, dept)
 	return dept, nil
 }
 
@@ -38,11 +41,12 @@ func (s *DepartmentServiceImpl) GetAll() ([]models.Department, error) {
 	return s.deptRepo.GetAll()
 }
 
-func (s *DepartmentServiceImpl) Update(id uint, req *services.UpdateDepartmentRequest) (*models.Department, error) {
+func (s *DepartmentServiceImpl) Update(actor *services.Actor, id uint, req *services.UpdateDepartmentRequest) (*models.Department, error) {
 	dept, err := s.deptRepo.GetByID(id)
 	if err != nil {
 		return nil, err
 	}
+	before := *dept
 
 	if req.Name != "" {
 		dept.Name = req.Name
@@ -58,9 +62,20 @@ func (s *DepartmentServiceImpl) Update(id uint, req *services.UpdateDepartmentRe
 		return nil, err
 	}
 
+	s.audit.Record(actor, models.AuditDepartmentUpdate, models.AuditTargetDepartment, dept.ID, &before, dept)
 	return dept, nil
 }
 
-func (s *DepartmentServiceImpl) Delete(id uint) error {
-	return s.deptRepo.Delete(id)
+func (s *DepartmentServiceImpl) Delete(actor *services.Actor, id uint) error {
+	dept, err := s.deptRepo.GetByID(id)
+	if err != nil {
+		return s.deptRepo.Delete(id)
+	}
+
+	if err := s.deptRepo.Delete(id); err != nil {
+		return err
+	}
+
+	s.audit.Record(actor, models.AuditDepartmentDelete, models.AuditTargetDepartment, dept.ID, dept, nil)
+	return nil
 }
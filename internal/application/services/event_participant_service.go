@@ -0,0 +1,154 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/repositories"
+	"github.com/juank/attendance-backend/internal/domain/services"
+)
+
+type EventParticipantServiceImpl struct {
+	participantRepo repositories.EventParticipantRepository
+	eventRepo       repositories.EventRepository
+	userRepo        repositories.UserRepository
+	deptRepo        repositories.DepartmentRepository
+	roles           services.Authorizer
+	audit           services.AuditRecorder
+}
+
+func NewEventParticipantService(
+	participantRepo repositories.EventParticipantRepository,
+	eventRepo repositories.EventRepository,
+	userRepo repositories.UserRepository,
+	deptRepo repositories.DepartmentRepository,
+	roles services.Authorizer,
+	audit services.AuditRecorder,
+) services.EventParticipantService {
+	return &EventParticipantServiceImpl{
+		participantRepo: participantRepo,
+		eventRepo:       eventRepo,
+		userRepo:        userRepo,
+		deptRepo:        deptRepo,
+		roles:           roles,
+		audit:           audit,
+	}
+}
+
+func (s *EventParticipantServiceImpl) AddParticipants(actor *services.Actor, eventID uint, req *services.AddParticipantsRequest) ([]models.EventParticipant, error) {
+	if len(req.UserIDs) == 0 && len(req.DepartmentIDs) == 0 && len(req.Roles) == 0 {
+		return nil, errors.New("at least one user, department or role is required")
+	}
+
+	if _, err := s.eventRepo.GetByID(eventID); err != nil {
+		return nil, errors.New("event not found")
+	}
+
+	// Validate every reference before writing anything
+	for _, userID := range req.UserIDs {
+		if _, err := s.userRepo.GetByID(userID); err != nil {
+			return nil, fmt.Errorf("user %d not found", userID)
+		}
+	}
+	for _, deptID := range req.DepartmentIDs {
+		if _, err := s.deptRepo.GetByID(deptID); err != nil {
+			return nil, fmt.Errorf("department %d not found", deptID)
+		}
+	}
+	for _, role := range req.Roles {
+		if !s.roles.Exists(role) {
+			return nil, fmt.Errorf("invalid role: %s", role)
+		}
+	}
+
+	existing, err := s.participantRepo.GetByEventID(eventID)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(existing))
+	for _, p := range existing {
+		seen[participantKey(&p)] = true
+	}
+
+	var candidates []models.EventParticipant
+	for i := range req.UserIDs {
+		candidates = append(candidates, models.EventParticipant{EventID: eventID, Type: models.ParticipantUser, UserID: &req.UserIDs[i]})
+	}
+	for i := range req.DepartmentIDs {
+		candidates = append(candidates, models.EventParticipant{EventID: eventID, Type: models.ParticipantDepartment, DepartmentID: &req.DepartmentIDs[i]})
+	}
+	for i := range req.Roles {
+		candidates = append(candidates, models.EventParticipant{EventID: eventID, Type: models.ParticipantRole, Role: &req.Roles[i]})
+	}
+
+	created := []models.EventParticipant{}
+	for i := range candidates {
+		key := participantKey(&candidates[i])
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if err := s.participantRepo.Create(&candidates[i]); err != nil {
+			return nil, err
+		}
+		created = append(created, candidates[i])
+		s.audit.Record(actor, models.AuditParticipantAdd, models.AuditTargetParticipant, candidates[i].ID, nil, &candidates[i])
+	}
+
+	return created, nil
+}
+
+func (s *EventParticipantServiceImpl) GetParticipants(eventID uint) ([]models.EventParticipant, error) {
+	return s.participantRepo.GetByEventID(eventID)
+}
+
+func (s *EventParticipantServiceImpl) RemoveParticipant(actor *services.Actor, eventID, participantID uint) error {
+	participant, err := s.participantRepo.GetByID(participantID)
+	if err != nil || participant.EventID != eventID {
+		return errors.New("participant not found")
+	}
+	if err := s.participantRepo.Delete(participantID); err != nil {
+		return err
+	}
+
+	s.audit.Record(actor, models.AuditParticipantRemove, models.AuditTargetParticipant, participant.ID, participant, nil)
+	return nil
+}
+
+func (s *EventParticipantServiceImpl) GetExpectedUsers(eventID uint) ([]models.User, error) {
+	return s.participantRepo.GetUsers(eventID)
+}
+
+func (s *EventParticipantServiceImpl) IsExpected(eventID, userID uint) (bool, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return false, errors.New("user not found")
+	}
+
+	participants, err := s.participantRepo.GetByEventID(eventID)
+	if err != nil {
+		return false, err
+	}
+
+	for i := range participants {
+		if participants[i].Matches(user) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// participantKey identifies a roster entry by what it assigns, to avoid duplicates
+func participantKey(p *models.EventParticipant) string {
+	switch p.Type {
+	case models.ParticipantUser:
+		return fmt.Sprintf("user:%d", *p.UserID)
+	case models.ParticipantDepartment:
+		return fmt.Sprintf("department:%d", *p.DepartmentID)
+	case models.ParticipantRole:
+		return fmt.Sprintf("role:%s", *p.Role)
+	}
+	return ""
+}
@@ -0,0 +1,433 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/repositories"
+	"github.com/juank/attendance-backend/internal/domain/services"
+	"github.com/juank/attendance-backend/pkg/logger"
+	"github.com/juank/attendance-backend/pkg/recurrence"
+	"go.uber.org/zap"
+)
+
+// EventSeriesService manages recurring events. Each occurrence is a regular Event, so
+// attendance, rosters and QR codes keep working per occurrence. Occurrences are created
+// ahead of time up to a rolling horizon that the recurrence job keeps extending.
+type EventSeriesService struct {
+	seriesRepo      repositories.EventSeriesRepository
+	eventRepo       repositories.EventRepository
+	attendanceRepo  repositories.AttendanceRepository
+	participantRepo repositories.EventParticipantRepository
+	webhooks        services.WebhookPublisher
+	audit           services.AuditRecorder
+	horizon         time.Duration
+}
+
+func NewEventSeriesService(
+	seriesRepo repositories.EventSeriesRepository,
+	eventRepo repositories.EventRepository,
+	attendanceRepo repositories.AttendanceRepository,
+	participantRepo repositories.EventParticipantRepository,
+	webhooks services.WebhookPublisher,
+	audit services.AuditRecorder,
+	horizon time.Duration,
+) *EventSeriesService {
+	return &EventSeriesService{
+		seriesRepo:      seriesRepo,
+		eventRepo:       eventRepo,
+		attendanceRepo:  attendanceRepo,
+		participantRepo: participantRepo,
+		webhooks:        webhooks,
+		audit:           audit,
+		horizon:         horizon,
+	}
+}
+
+func (s *EventSeriesService) Create(actor *services.Actor, series *models.EventSeries) error {
+	if err := prepareEventSeries(series); err != nil {
+		return err
+	}
+	series.GeneratedUntil = nil
+
+	if err := s.seriesRepo.Create(series); err != nil {
+		return err
+	}
+	s.audit.Record(actor, models.AuditSeriesCreate, models.AuditTargetSeries, series.ID, nil, series)
+
+	return s.extend(series, time.Now())
+}
+
+func (s *EventSeriesService) GetByID(id uint) (*models.EventSeries, error) {
+	series, err := s.seriesRepo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("event series not found")
+	}
+	return series, nil
+}
+
+func (s *EventSeriesService) GetAll() ([]models.EventSeries, error) {
+	return s.seriesRepo.GetAll()
+}
+
+// GetOccurrences returns every occurrence generated so far, past ones included
+func (s *EventSeriesService) GetOccurrences(id uint) ([]models.Event, error) {
+	if _, err := s.GetByID(id); err != nil {
+		return nil, err
+	}
+	return s.eventRepo.GetBySeriesID(id, time.Time{})
+}
+
+// Update edits the whole series. Occurrences that already started are history and stay as
+// they are; upcoming ones are rewritten from the new template and rule, except those edited
+// on their own.
+func (s *EventSeriesService) Update(actor *services.Actor, series *models.EventSeries) error {
+	existing, err := s.GetByID(series.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := prepareEventSeries(series); err != nil {
+		return err
+	}
+	series.CreatedAt = existing.CreatedAt
+	series.GeneratedUntil = existing.GeneratedUntil
+
+	if err := s.seriesRepo.Update(series); err != nil {
+		return err
+	}
+	s.audit.Record(actor, models.AuditSeriesUpdate, models.AuditTargetSeries, series.ID, existing, series)
+
+	return s.sync(series, time.Now())
+}
+
+// UpdateFromOccurrence applies the changes made to one occurrence to the whole series.
+// The new time of day and duration are carried over to every upcoming occurrence.
+func (s *EventSeriesService) UpdateFromOccurrence(actor *services.Actor, eventID uint, event *models.Event) (*models.EventSeries, error) {
+	occurrence, err := s.eventRepo.GetByID(eventID)
+	if err != nil {
+		return nil, errors.New("event not found")
+	}
+	if occurrence.SeriesID == nil {
+		return nil, fmt.Errorf("%w: event is not part of a series", ErrInvalidEvent)
+	}
+
+	series, err := s.GetByID(*occurrence.SeriesID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateEventSchedule(event); err != nil {
+		return nil, err
+	}
+
+	loc := event.Location()
+	first := series.Template.StartTime.In(loc)
+	start := event.StartTime.In(loc)
+
+	template := *event
+	template.StartTime = time.Date(first.Year(), first.Month(), first.Day(), start.Hour(), start.Minute(), start.Second(), 0, loc)
+	template.EndTime = time.Time{}
+	if !event.EndTime.IsZero() {
+		template.EndTime = template.StartTime.Add(event.EndTime.Sub(event.StartTime))
+	}
+	series.Template = template
+
+	if err := s.Update(actor, series); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// Delete removes the series and its upcoming occurrences. Occurrences that already have
+// attendance are kept as standalone events.
+func (s *EventSeriesService) Delete(actor *services.Actor, id uint) error {
+	series, err := s.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	upcoming, err := s.eventRepo.GetBySeriesID(id, time.Now())
+	if err != nil {
+		return err
+	}
+	var removed, detached []uint
+	for _, occurrence := range upcoming {
+		if s.hasAttendance(occurrence.ID) {
+			detached = append(detached, occurrence.ID)
+		} else {
+			removed = append(removed, occurrence.ID)
+		}
+	}
+
+	if err := s.seriesRepo.Delete(id, removed, detached); err != nil {
+		return err
+	}
+
+	s.audit.Record(actor, models.AuditSeriesDelete, models.AuditTargetSeries, series.ID, series, nil)
+	return nil
+}
+
+// AddException skips the occurrence of the given date (YYYY-MM-DD), deleting it if it
+// was already generated
+func (s *EventSeriesService) AddException(actor *services.Actor, id uint, date string) (*models.EventSeries, error) {
+	series, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	day, err := time.Parse(models.OccurrenceDateLayout, date)
+	if err != nil {
+		return nil, fmt.Errorf("%w: date must be YYYY-MM-DD", ErrInvalidEvent)
+	}
+
+	occurrences, err := s.eventRepo.GetBySeriesID(id, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	var skipped []uint
+	for _, occurrence := range occurrences {
+		if occurrence.OccurrenceDate == nil || !occurrence.OccurrenceDate.Equal(day) {
+			continue
+		}
+		if s.hasAttendance(occurrence.ID) {
+			return nil, errors.New("occurrence already has attendance")
+		}
+		skipped = append(skipped, occurrence.ID)
+	}
+
+	before := *series
+	added := !series.ExceptionDates.Contains(day)
+	if added {
+		series.ExceptionDates = append(series.ExceptionDates, date)
+	}
+	if added || len(skipped) > 0 {
+		if err := s.seriesRepo.SkipOccurrences(series, skipped); err != nil {
+			return nil, err
+		}
+	}
+	if added {
+		s.audit.Record(actor, models.AuditSeriesException, models.AuditTargetSeries, series.ID, &before, series)
+	}
+
+	return series, nil
+}
+
+// ExtendAll generates the occurrences of every series up to the horizon
+func (s *EventSeriesService) ExtendAll(now time.Time) (int, error) {
+	all, err := s.seriesRepo.GetAll()
+	if err != nil {
+		return 0, err
+	}
+
+	// A failing series is logged and retried on the next run without holding up the rest
+	extended := 0
+	for i := range all {
+		if err := s.extend(&all[i], now); err != nil {
+			logger.Error("Failed to extend event series",
+				zap.Uint("series_id", all[i].ID),
+				zap.Error(err),
+			)
+			continue
+		}
+		extended++
+	}
+	return extended, nil
+}
+
+// extend creates the occurrences between the last generated one and the horizon
+func (s *EventSeriesService) extend(series *models.EventSeries, now time.Time) error {
+	to := now.Add(s.horizon)
+	if series.GeneratedUntil != nil && !to.After(*series.GeneratedUntil) {
+		return nil
+	}
+
+	// Occurrences that would already be over are never created, even for a series
+	// starting in the past
+	from := series.Template.StartTime
+	if from.Before(now) {
+		from = now
+	}
+	if series.GeneratedUntil != nil {
+		from = *series.GeneratedUntil
+	}
+
+	if err := s.generate(series, from, to); err != nil {
+		return err
+	}
+
+	series.GeneratedUntil = &to
+	return s.seriesRepo.Update(series)
+}
+
+// sync rewrites the upcoming occurrences after the series changed
+func (s *EventSeriesService) sync(series *models.EventSeries, now time.Time) error {
+	rule, err := recurrence.Parse(series.RRule)
+	if err != nil {
+		return err
+	}
+
+	to := now.Add(s.horizon)
+	if series.GeneratedUntil != nil && series.GeneratedUntil.After(to) {
+		to = *series.GeneratedUntil
+	}
+
+	wanted := make(map[string]time.Time)
+	start := series.Template.StartTime.In(series.Template.Location())
+	for _, t := range rule.Between(start, now, to) {
+		if !series.ExceptionDates.Contains(t) {
+			wanted[t.Format(models.OccurrenceDateLayout)] = t
+		}
+	}
+
+	upcoming, err := s.eventRepo.GetBySeriesID(series.ID, now)
+	if err != nil {
+		return err
+	}
+
+	for i := range upcoming {
+		occurrence := &upcoming[i]
+		key := ""
+		if occurrence.OccurrenceDate != nil {
+			key = occurrence.OccurrenceDate.Format(models.OccurrenceDateLayout)
+		}
+
+		t, ok := wanted[key]
+		delete(wanted, key)
+
+		if occurrence.IsDetached {
+			continue
+		}
+		if !ok {
+			if err := s.removeOccurrence(occurrence); err != nil {
+				return err
+			}
+			continue
+		}
+
+		series.ApplyTo(occurrence, t)
+		if err := prepareEventQR(occurrence); err != nil {
+			return err
+		}
+		if err := s.eventRepo.Update(occurrence); err != nil {
+			return err
+		}
+	}
+
+	if err := s.generate(series, now, to); err != nil {
+		return err
+	}
+
+	series.GeneratedUntil = &to
+	return s.seriesRepo.Update(series)
+}
+
+// generate creates the occurrences starting in [from, to] that don't exist yet. New
+// occurrences inherit the roster of the latest occurrence of the series.
+func (s *EventSeriesService) generate(series *models.EventSeries, from, to time.Time) error {
+	rule, err := recurrence.Parse(series.RRule)
+	if err != nil {
+		return err
+	}
+
+	var roster []models.EventParticipant
+	if last, err := s.eventRepo.GetLastInSeries(series.ID); err == nil {
+		if roster, err = s.participantRepo.GetByEventID(last.ID); err != nil {
+			return err
+		}
+	}
+
+	start := series.Template.StartTime.In(series.Template.Location())
+	for _, t := range rule.Between(start, from, to) {
+		if series.ExceptionDates.Contains(t) {
+			continue
+		}
+
+		occurrence := series.NewOccurrence(t)
+		if err := prepareEventQR(&occurrence); err != nil {
+			return err
+		}
+
+		created, err := s.eventRepo.CreateOccurrence(&occurrence, roster)
+		if err != nil {
+			return err
+		}
+		if !created {
+			continue
+		}
+
+		s.webhooks.Publish(models.WebhookEventCreated, &occurrence)
+	}
+
+	return nil
+}
+
+// removeOccurrence deletes an occurrence, or detaches it from the series when it already
+// has attendance so that the records keep their event
+func (s *EventSeriesService) removeOccurrence(occurrence *models.Event) error {
+	if !s.hasAttendance(occurrence.ID) {
+		return s.eventRepo.Delete(occurrence.ID)
+	}
+	occurrence.IsDetached = true
+	return s.eventRepo.Update(occurrence)
+}
+
+func (s *EventSeriesService) hasAttendance(eventID uint) bool {
+	userIDs, err := s.attendanceRepo.GetUserIDsByEventID(eventID)
+	// When in doubt, keep the occurrence
+	return err != nil || len(userIDs) > 0
+}
+
+// prepareEventSeries validates the template and rule of a series and normalizes them
+func prepareEventSeries(series *models.EventSeries) error {
+	template := &series.Template
+
+	if strings.TrimSpace(template.Title) == "" {
+		return fmt.Errorf("%w: title is required", ErrInvalidEvent)
+	}
+	if template.StartTime.IsZero() {
+		return fmt.Errorf("%w: start_time is required", ErrInvalidEvent)
+	}
+	if err := validateEventSchedule(template); err != nil {
+		return err
+	}
+	if err := validateEventGeofence(template); err != nil {
+		return err
+	}
+	if err := prepareEventQR(template); err != nil {
+		return err
+	}
+
+	rule, err := recurrence.Parse(series.RRule)
+	if err != nil {
+		return fmt.Errorf("%w: rrule: %v", ErrInvalidEvent, err)
+	}
+	series.RRule = rule.String()
+
+	dates := make(models.DateList, 0, len(series.ExceptionDates))
+	for _, date := range series.ExceptionDates {
+		day, err := time.Parse(models.OccurrenceDateLayout, date)
+		if err != nil {
+			return fmt.Errorf("%w: exception dates must be YYYY-MM-DD", ErrInvalidEvent)
+		}
+		if !dates.Contains(day) {
+			dates = append(dates, date)
+		}
+	}
+	series.ExceptionDates = dates
+
+	// Each occurrence gets its own QR secret and series fields; deactivating is done per occurrence
+	template.ID = 0
+	template.QRSecret = ""
+	template.ClosedAt = nil
+	template.SeriesID = nil
+	template.OccurrenceDate = nil
+	template.IsDetached = false
+	template.IsActive = true
+	series.Title = template.Title
+
+	return nil
+}
@@ -1,34 +1,215 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/juank/attendance-backend/internal/domain/models"
 	"github.com/juank/attendance-backend/internal/domain/repositories"
+	"github.com/juank/attendance-backend/internal/domain/services"
+	"github.com/juank/attendance-backend/pkg/geo"
+	"github.com/juank/attendance-backend/pkg/utils"
 )
 
+// ErrInvalidEvent is returned when an event fails schedule validation
+var ErrInvalidEvent = errors.New("invalid event")
+
 type EventService struct {
-	eventRepo repositories.EventRepository
+	eventRepo  repositories.EventRepository
+	seriesRepo repositories.EventSeriesRepository
+	webhooks   services.WebhookPublisher
+	audit      services.AuditRecorder
 }
 
-func NewEventService(eventRepo repositories.EventRepository) *EventService {
-	return &EventService{eventRepo: eventRepo}
+func NewEventService(eventRepo repositories.EventRepository, seriesRepo repositories.EventSeriesRepository, webhooks services.WebhookPublisher, audit services.AuditRecorder) *EventService {
+	return &EventService{eventRepo: eventRepo, seriesRepo: seriesRepo, webhooks: webhooks, audit: audit}
 }
 
-func (s *EventService) Create(event *models.Event) error {
-	return s.eventRepo.Create(event)
+func (s *EventService) Create(actor *services.Actor, event *models.Event) error {
+	if err := validateEventSchedule(event); err != nil {
+		return err
+	}
+	if err := validateEventGeofence(event); err != nil {
+		return err
+	}
+	if err := prepareEventQR(event); err != nil {
+		return err
+	}
+	// Occurrences are only created through their series
+	event.SeriesID = nil
+	event.OccurrenceDate = nil
+	event.IsDetached = false
+	if err := s.eventRepo.Create(event); err != nil {
+		return err
+	}
+
+	s.audit.Record(actor, models.AuditEventCreate, models.AuditTargetEvent, event.ID, nil, event)
+	s.webhooks.Publish(models.WebhookEventCreated, event)
+	return nil
 }
 
 func (s *EventService) GetByID(id uint) (*models.Event, error) {
 	return s.eventRepo.GetByID(id)
 }
 
-func (s *EventService) Update(event *models.Event) error {
-	return s.eventRepo.Update(event)
+func (s *EventService) Update(actor *services.Actor, event *models.Event) error {
+	if err := validateEventSchedule(event); err != nil {
+		return err
+	}
+	if err := validateEventGeofence(event); err != nil {
+		return err
+	}
+
+	existing, err := s.eventRepo.GetByID(event.ID)
+	if err != nil {
+		return errors.New("event not found")
+	}
+	// Server-managed fields are never taken from the request body
+	event.ClosedAt = existing.ClosedAt
+	event.CreatedAt = existing.CreatedAt
+	event.QRSecret = existing.QRSecret
+	event.SeriesID = existing.SeriesID
+	event.OccurrenceDate = existing.OccurrenceDate
+	// An occurrence edited on its own no longer follows series edits
+	event.IsDetached = existing.SeriesID != nil
+
+	if err := prepareEventQR(event); err != nil {
+		return err
+	}
+
+	if err := s.eventRepo.Update(event); err != nil {
+		return err
+	}
+
+	s.audit.Record(actor, models.AuditEventUpdate, models.AuditTargetEvent, event.ID, existing, event)
+	return nil
+}
+
+func (s *EventService) Delete(actor *services.Actor, id uint) error {
+	event, err := s.eventRepo.GetByID(id)
+	if err != nil {
+		return s.eventRepo.Delete(id)
+	}
+
+	if err := s.deleteEvent(event); err != nil {
+		return err
+	}
+
+	s.audit.Record(actor, models.AuditEventDelete, models.AuditTargetEvent, event.ID, event, nil)
+	return nil
 }
 
-func (s *EventService) Delete(id uint) error {
-	return s.eventRepo.Delete(id)
+// deleteEvent deletes the event. Deleted occurrences are added to the exceptions of their
+// series in the same transaction so the series does not generate them again.
+func (s *EventService) deleteEvent(event *models.Event) error {
+	if event.SeriesID != nil && event.OccurrenceDate != nil {
+		if series, err := s.seriesRepo.GetByID(*event.SeriesID); err == nil && !series.ExceptionDates.Contains(*event.OccurrenceDate) {
+			series.ExceptionDates = append(series.ExceptionDates, event.OccurrenceDate.Format(models.OccurrenceDateLayout))
+			return s.seriesRepo.SkipOccurrences(series, []uint{event.ID})
+		}
+	}
+	return s.eventRepo.Delete(event.ID)
 }
 
 func (s *EventService) GetAll() ([]models.Event, error) {
 	return s.eventRepo.GetAll()
 }
+
+// validateEventSchedule checks the timezone and lateness rules of an event
+func validateEventSchedule(event *models.Event) error {
+	if event.Timezone == "" {
+		event.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(event.Timezone); err != nil {
+		return fmt.Errorf("%w: invalid timezone", ErrInvalidEvent)
+	}
+
+	if !event.EndTime.IsZero() && event.EndTime.Before(event.StartTime) {
+		return fmt.Errorf("%w: end_time must be after start_time", ErrInvalidEvent)
+	}
+
+	lateAfter := models.DefaultLateAfterMinutes
+	if event.LateAfterMinutes != nil {
+		if *event.LateAfterMinutes < 0 {
+			return fmt.Errorf("%w: late_after_minutes cannot be negative", ErrInvalidEvent)
+		}
+		lateAfter = *event.LateAfterMinutes
+	} else {
+		event.LateAfterMinutes = &lateAfter
+	}
+
+	if event.AbsentAfterMinutes != nil && *event.AbsentAfterMinutes < lateAfter {
+		return fmt.Errorf("%w: absent_after_minutes must be greater than or equal to late_after_minutes", ErrInvalidEvent)
+	}
+
+	return nil
+}
+
+// validateEventGeofence checks that an enabled geofence has a valid circle or polygon
+func validateEventGeofence(event *models.Event) error {
+	if event.GeofenceMode == "" {
+		event.GeofenceMode = models.GeofenceOff
+	}
+
+	switch event.GeofenceMode {
+	case models.GeofenceOff:
+		return nil
+	case models.GeofenceFlag, models.GeofenceReject:
+	default:
+		return fmt.Errorf("%w: geofence_mode must be off, flag or reject", ErrInvalidEvent)
+	}
+
+	if len(event.GeofencePolygon) > 0 {
+		if len(event.GeofencePolygon) < 3 {
+			return fmt.Errorf("%w: geofence_polygon needs at least 3 points", ErrInvalidEvent)
+		}
+		for _, p := range event.GeofencePolygon {
+			if !p.Valid() {
+				return fmt.Errorf("%w: geofence_polygon has invalid coordinates", ErrInvalidEvent)
+			}
+		}
+		return nil
+	}
+
+	if event.GeofenceLatitude == nil || event.GeofenceLongitude == nil || event.GeofenceRadiusMeters == nil {
+		return fmt.Errorf("%w: geofence requires a polygon or latitude, longitude and radius", ErrInvalidEvent)
+	}
+	center := geo.Point{Lat: *event.GeofenceLatitude, Lng: *event.GeofenceLongitude}
+	if !center.Valid() {
+		return fmt.Errorf("%w: geofence center has invalid coordinates", ErrInvalidEvent)
+	}
+	if *event.GeofenceRadiusMeters <= 0 {
+		return fmt.Errorf("%w: geofence_radius_meters must be positive", ErrInvalidEvent)
+	}
+
+	return nil
+}
+
+// prepareEventQR validates the QR settings of an event and makes sure dynamic
+// events have a signing secret
+func prepareEventQR(event *models.Event) error {
+	if event.QRMode == "" {
+		event.QRMode = models.QRModeStatic
+	}
+	if event.QRMode != models.QRModeStatic && event.QRMode != models.QRModeDynamic {
+		return fmt.Errorf("%w: qr_mode must be static or dynamic", ErrInvalidEvent)
+	}
+
+	if event.QRRotationSeconds == 0 {
+		event.QRRotationSeconds = models.DefaultQRRotationSeconds
+	}
+	if event.QRRotationSeconds < 5 || event.QRRotationSeconds > 300 {
+		return fmt.Errorf("%w: qr_rotation_seconds must be between 5 and 300", ErrInvalidEvent)
+	}
+
+	if event.IsDynamicQR() && event.QRSecret == "" {
+		secret, err := utils.GenerateSecret(32)
+		if err != nil {
+			return err
+		}
+		event.QRSecret = secret
+	}
+
+	return nil
+}
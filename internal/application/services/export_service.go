@@ -0,0 +1,155 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/repositories"
+	"github.com/juank/attendance-backend/internal/domain/services"
+	"github.com/juank/attendance-backend/pkg/export"
+)
+
+// exportTimeLayout is used for check-in and check-out, in the event timezone
+const exportTimeLayout = "2006-01-02 15:04:05"
+
+var exportHeader = []string{
+	"Event", "First Name", "Last Name", "Email", "Department", "Status",
+	"Check In", "Check Out", "Timezone", "Notes", "Location", "Latitude", "Longitude",
+}
+
+type ExportServiceImpl struct {
+	attendanceRepo repositories.AttendanceRepository
+	eventRepo      repositories.EventRepository
+	userRepo       repositories.UserRepository
+}
+
+func NewExportService(
+	attendanceRepo repositories.AttendanceRepository,
+	eventRepo repositories.EventRepository,
+	userRepo repositories.UserRepository,
+) services.ExportService {
+	return &ExportServiceImpl{
+		attendanceRepo: attendanceRepo,
+		eventRepo:      eventRepo,
+		userRepo:       userRepo,
+	}
+}
+
+func (s *ExportServiceImpl) ExportEventAttendance(eventID uint, format services.ExportFormat) (*services.AttendanceExport, error) {
+	if _, err := s.eventRepo.GetByID(eventID); err != nil {
+		return nil, errors.New("event not found")
+	}
+
+	filter := models.AttendanceExportFilter{EventID: eventID}
+	return s.newExport(fmt.Sprintf("attendance-event-%d", eventID), filter, format)
+}
+
+func (s *ExportServiceImpl) ExportAttendanceRange(startDate, endDate time.Time, format services.ExportFormat) (*services.AttendanceExport, error) {
+	start, end, err := reportRange(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := models.AttendanceExportFilter{StartDate: &start, EndDate: &end}
+	name := fmt.Sprintf("attendance-%s_%s", startDate.Format(reportDateLayout), endDate.Format(reportDateLayout))
+	return s.newExport(name, filter, format)
+}
+
+func (s *ExportServiceImpl) ExportUserAttendance(userID uint, startDate, endDate *time.Time, format services.ExportFormat) (*services.AttendanceExport, error) {
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	filter := models.AttendanceExportFilter{UserID: userID}
+	if startDate != nil && endDate != nil {
+		start, end, err := reportRange(*startDate, *endDate)
+		if err != nil {
+			return nil, err
+		}
+		filter.StartDate, filter.EndDate = &start, &end
+	}
+
+	return s.newExport(fmt.Sprintf("attendance-user-%d", userID), filter, format)
+}
+
+func (s *ExportServiceImpl) newExport(name string, filter models.AttendanceExportFilter, format services.ExportFormat) (*services.AttendanceExport, error) {
+	var contentType string
+	switch format {
+	case services.ExportCSV:
+		contentType = "text/csv; charset=utf-8"
+	case services.ExportXLSX:
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return nil, errors.New("unsupported export format, use csv or xlsx")
+	}
+
+	return &services.AttendanceExport{
+		FileName:    name + "." + string(format),
+		ContentType: contentType,
+		Write: func(w io.Writer) error {
+			return s.write(w, filter, format)
+		},
+	}, nil
+}
+
+func (s *ExportServiceImpl) write(w io.Writer, filter models.AttendanceExportFilter, format services.ExportFormat) error {
+	var out export.RowWriter
+	if format == services.ExportXLSX {
+		var err error
+		if out, err = export.NewXLSXWriter(w, "Attendance"); err != nil {
+			return err
+		}
+	} else {
+		out = export.NewCSVWriter(w)
+	}
+
+	if err := out.WriteRow(exportHeader); err != nil {
+		return err
+	}
+
+	locations := make(map[string]*time.Location)
+	err := s.attendanceRepo.StreamForExport(filter, func(row *models.AttendanceExportRow) error {
+		loc, ok := locations[row.EventTimezone]
+		if !ok {
+			loc = (&models.Event{Timezone: row.EventTimezone}).Location()
+			locations[row.EventTimezone] = loc
+		}
+
+		checkOut := ""
+		if row.CheckOut != nil {
+			checkOut = row.CheckOut.In(loc).Format(exportTimeLayout)
+		}
+
+		return out.WriteRow([]string{
+			row.EventTitle,
+			row.FirstName,
+			row.LastName,
+			row.Email,
+			row.DepartmentName,
+			row.Status,
+			row.CheckIn.In(loc).Format(exportTimeLayout),
+			checkOut,
+			loc.String(),
+			row.Notes,
+			row.Location,
+			formatCoordinate(row.Latitude),
+			formatCoordinate(row.Longitude),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	return out.Close()
+}
+
+func formatCoordinate(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', 6, 64)
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/repositories"
+	"github.com/juank/attendance-backend/internal/domain/services"
+)
+
+type LeaveServiceImpl struct {
+	leaveRepo      repositories.LeaveRequestRepository
+	userRepo       repositories.UserRepository
+	deptRepo       repositories.DepartmentRepository
+	attendanceRepo repositories.AttendanceRepository
+	roles          services.Authorizer
+	audit          services.AuditRecorder
+}
+
+func NewLeaveService(
+	leaveRepo repositories.LeaveRequestRepository,
+	userRepo repositories.UserRepository,
+	deptRepo repositories.DepartmentRepository,
+	attendanceRepo repositories.AttendanceRepository,
+	roles services.Authorizer,
+	audit services.AuditRecorder,
+) services.LeaveService {
+	return &LeaveServiceImpl{
+		leaveRepo:      leaveRepo,
+		userRepo:       userRepo,
+		deptRepo:       deptRepo,
+		attendanceRepo: attendanceRepo,
+		roles:          roles,
+		audit:          audit,
+	}
+}
+
+func (s *LeaveServiceImpl) Create(actor *services.Actor, req *services.CreateLeaveRequest) (*models.LeaveRequest, error) {
+	if !models.IsValidLeaveType(req.Type) {
+		return nil, errors.New("invalid leave type")
+	}
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return nil, errors.New("invalid start_date format, use YYYY-MM-DD")
+	}
+	endDate, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		return nil, errors.New("invalid end_date format, use YYYY-MM-DD")
+	}
+	if endDate.Before(startDate) {
+		return nil, errors.New("end_date must not be before start_date")
+	}
+
+	overlapping, err := s.leaveRepo.HasOverlapping(actor.UserID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	if overlapping {
+		return nil, errors.New("leave overlaps an existing request")
+	}
+
+	leave := &models.LeaveRequest{
+		UserID:    actor.UserID,
+		Type:      req.Type,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Reason:    req.Reason,
+		Status:    models.LeavePending,
+	}
+
+	if err := s.leaveRepo.Create(leave); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(actor, models.AuditLeaveCreate, models.AuditTargetLeaveRequest, leave.ID, nil, leave)
+	return leave, nil
+}
+
+func (s *LeaveServiceImpl) GetByID(id uint) (*models.LeaveRequest, error) {
+	return s.leaveRepo.GetByID(id)
+}
+
+func (s *LeaveServiceImpl) GetUserLeaves(userID uint, page, limit int) ([]models.LeaveRequest, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	return s.leaveRepo.GetByUserID(userID, page, limit)
+}
+
+func (s *LeaveServiceImpl) Cancel(actor *services.Actor, id uint) (*models.LeaveRequest, error) {
+	leave, err := s.leaveRepo.GetByID(id)
+	if err != nil || leave.UserID != actor.UserID {
+		return nil, errors.New("leave request not found")
+	}
+
+	if leave.Status != models.LeavePending {
+		return nil, errors.New("only pending leave requests can be cancelled")
+	}
+
+	before := *leave
+	leave.Status = models.LeaveCancelled
+	if err := s.leaveRepo.Update(leave); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(actor, models.AuditLeaveCancel, models.AuditTargetLeaveRequest, leave.ID, &before, leave)
+	return leave, nil
+}
+
+func (s *LeaveServiceImpl) GetPendingForReviewer(reviewerID uint, role models.Role) ([]models.LeaveRequest, error) {
+	if s.roles.HasPermission(role, models.PermLeaveReviewAll) {
+		return s.leaveRepo.GetPending(nil)
+	}
+
+	departmentIDs, err := managedDepartmentIDs(s.deptRepo, reviewerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(departmentIDs) == 0 {
+		return []models.LeaveRequest{}, nil
+	}
+
+	return s.leaveRepo.GetPending(departmentIDs)
+}
+
+func (s *LeaveServiceImpl) Approve(actor *services.Actor, id uint, req *services.ReviewLeaveRequest) (*models.LeaveRequest, error) {
+	return s.review(actor, id, models.LeaveApproved, req.Note)
+}
+
+func (s *LeaveServiceImpl) Reject(actor *services.Actor, id uint, req *services.ReviewLeaveRequest) (*models.LeaveRequest, error) {
+	return s.review(actor, id, models.LeaveRejected, req.Note)
+}
+
+// review moves a pending request to its final status once the reviewer is allowed to decide on it.
+// Approving also turns the absences already recorded in the window into leave, in the same transaction
+func (s *LeaveServiceImpl) review(actor *services.Actor, id uint, status models.LeaveStatus, note string) (*models.LeaveRequest, error) {
+	leave, err := s.leaveRepo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("leave request not found")
+	}
+
+	if leave.Status != models.LeavePending {
+		return nil, errors.New("leave request has already been reviewed")
+	}
+
+	reviewAll := s.roles.HasPermission(actor.Role, models.PermLeaveReviewAll)
+	if err := checkDepartmentReviewer(s.userRepo, leave.UserID, actor.UserID, reviewAll); err != nil {
+		return nil, err
+	}
+
+	before := *leave
+	now := time.Now()
+	reviewerID := actor.UserID
+	leave.Status = status
+	leave.ReviewerID = &reviewerID
+	leave.ReviewedAt = &now
+	leave.ReviewNote = note
+
+	var previous, changed []models.Attendance
+	if status == models.LeaveApproved {
+		if previous, changed, err = s.recordedAbsencesOnLeave(leave); err != nil {
+			return nil, err
+		}
+	}
+
+	revisions := make([]models.AttendanceRevision, len(changed))
+	for i := range changed {
+		leaveID := leave.ID
+		revisions[i] = models.AttendanceRevision{
+			AttendanceID:     changed[i].ID,
+			LeaveRequestID:   &leaveID,
+			ChangedByID:      actor.UserID,
+			PreviousStatus:   previous[i].Status,
+			PreviousCheckIn:  previous[i].CheckIn,
+			PreviousCheckOut: previous[i].CheckOut,
+			PreviousNotes:    previous[i].Notes,
+			NewStatus:        changed[i].Status,
+			NewCheckIn:       changed[i].CheckIn,
+			NewCheckOut:      changed[i].CheckOut,
+			NewNotes:         changed[i].Notes,
+		}
+	}
+
+	reviewed, err := s.leaveRepo.Review(leave, changed, revisions)
+	if err != nil {
+		return nil, err
+	}
+	if !reviewed {
+		return nil, errors.New("leave request has already been reviewed")
+	}
+
+	action := models.AuditLeaveApprove
+	if status == models.LeaveRejected {
+		action = models.AuditLeaveReject
+	}
+	s.audit.Record(actor, action, models.AuditTargetLeaveRequest, leave.ID, &before, leave)
+	for i := range changed {
+		s.audit.Record(actor, action, models.AuditTargetAttendance, changed[i].ID, &previous[i], &changed[i])
+	}
+	return leave, nil
+}
+
+// recordedAbsencesOnLeave returns the absences the absence job recorded for events inside the
+// leave window, as they are now and as they become once turned into on_leave. Absences recorded
+// by a scan, e.g. for arriving after the cutoff, are left alone
+func (s *LeaveServiceImpl) recordedAbsencesOnLeave(leave *models.LeaveRequest) ([]models.Attendance, []models.Attendance, error) {
+	// Widen the range by a day on each side; the exact day is checked in the event's time zone
+	from := leave.StartDate.AddDate(0, 0, -1)
+	to := leave.EndDate.AddDate(0, 0, 2)
+
+	absences, err := s.attendanceRepo.GetByUserStatusInRange(leave.UserID, models.StatusAbsent, from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var previous, changed []models.Attendance
+	for _, attendance := range absences {
+		if !attendance.AutoRecorded || !leave.Covers(attendance.Event.StartTime, attendance.Event.Location()) {
+			continue
+		}
+
+		previous = append(previous, attendance)
+		attendance.Status = string(models.StatusOnLeave)
+		attendance.Notes = leaveNote(leave)
+		changed = append(changed, attendance)
+	}
+
+	return previous, changed, nil
+}
+
+func leaveNote(leave *models.LeaveRequest) string {
+	return fmt.Sprintf("On approved %s leave (request #%d)", leave.Type, leave.ID)
+}
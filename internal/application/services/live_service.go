@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/repositories"
+	"github.com/juank/attendance-backend/internal/domain/services"
+	"github.com/juank/attendance-backend/pkg/logger"
+	"github.com/juank/attendance-backend/pkg/pubsub"
+	"go.uber.org/zap"
+)
+
+const (
+	// liveHeartbeatInterval is below the idle timeout of common proxies (60s)
+	liveHeartbeatInterval = 25 * time.Second
+	// liveMinQRRefresh throttles QR lookups when a code is already past its expiry
+	liveMinQRRefresh = time.Second
+)
+
+// LivePublisherImpl publishes to the in-memory hub, so only clients connected to this
+// instance receive the updates
+type LivePublisherImpl struct {
+	hub            *pubsub.Hub
+	attendanceRepo repositories.AttendanceRepository
+	userRepo       repositories.UserRepository
+}
+
+func NewLivePublisher(hub *pubsub.Hub, attendanceRepo repositories.AttendanceRepository, userRepo repositories.UserRepository) services.LivePublisher {
+	return &LivePublisherImpl{
+		hub:            hub,
+		attendanceRepo: attendanceRepo,
+		userRepo:       userRepo,
+	}
+}
+
+func (p *LivePublisherImpl) PublishAttendance(attendance *models.Attendance) {
+	if p.hub.Subscribers(attendance.EventID) == 0 {
+		return
+	}
+
+	counts, err := liveCounts(p.attendanceRepo, attendance.EventID)
+	if err != nil {
+		logger.Error("Failed to count attendance for live stream", zap.Uint("event_id", attendance.EventID), zap.Error(err))
+		return
+	}
+
+	// The projector shows who just arrived; copy so the caller's record is left untouched
+	record := *attendance
+	if record.User.ID == 0 {
+		if user, err := p.userRepo.GetByID(record.UserID); err == nil {
+			record.User = *user
+		}
+	}
+
+	p.hub.Publish(attendance.EventID, &services.LiveUpdate{
+		Type: services.LiveAttendance,
+		Data: &services.LiveAttendanceData{Attendance: &record, Counts: counts},
+	})
+}
+
+func (p *LivePublisherImpl) PublishQRRotated(qr *models.QRCode) {
+	p.hub.Publish(qr.EventID, &services.LiveUpdate{Type: services.LiveQRRotated, Data: qr})
+}
+
+type LiveServiceImpl struct {
+	hub            *pubsub.Hub
+	eventRepo      repositories.EventRepository
+	attendanceRepo repositories.AttendanceRepository
+	qrService      services.QRService
+}
+
+func NewLiveService(
+	hub *pubsub.Hub,
+	eventRepo repositories.EventRepository,
+	attendanceRepo repositories.AttendanceRepository,
+	qrService services.QRService,
+) services.LiveService {
+	return &LiveServiceImpl{
+		hub:            hub,
+		eventRepo:      eventRepo,
+		attendanceRepo: attendanceRepo,
+		qrService:      qrService,
+	}
+}
+
+func (s *LiveServiceImpl) Watch(ctx context.Context, eventID uint, send func(update *services.LiveUpdate) error) error {
+	event, err := s.eventRepo.GetByID(eventID)
+	if err != nil {
+		return errors.New("event not found")
+	}
+
+	// Subscribe before reading the snapshot so no attendance falls in between
+	updates, unsubscribe := s.hub.Subscribe(eventID)
+	defer unsubscribe()
+
+	counts, err := liveCounts(s.attendanceRepo, eventID)
+	if err != nil {
+		return err
+	}
+	qr, err := s.qrService.GetActive(eventID)
+	if err != nil {
+		return err
+	}
+
+	snapshot := &services.LiveUpdate{
+		Type: services.LiveSnapshot,
+		Data: &services.LiveSnapshotData{EventID: event.ID, Title: event.Title, Counts: counts, QR: qr},
+	}
+	if err := send(snapshot); err != nil {
+		return err
+	}
+
+	// Only dynamic codes move on by themselves; static ones change when someone issues a new one
+	var currentToken string
+	qrTimer := time.NewTimer(liveMinQRRefresh)
+	defer qrTimer.Stop()
+	if qr != nil {
+		currentToken = qr.Token
+	}
+	if event.IsDynamicQR() {
+		resetTimer(qrTimer, untilRefresh(qr))
+	} else {
+		qrTimer.Stop()
+	}
+	heartbeat := time.NewTicker(liveHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case msg, ok := <-updates:
+			if !ok {
+				return errors.New("live stream fell behind")
+			}
+			update, ok := msg.(*services.LiveUpdate)
+			if !ok {
+				continue
+			}
+			if update.Type == services.LiveQRRotated {
+				rotated := update.Data.(*models.QRCode)
+				if rotated.Token == currentToken {
+					continue
+				}
+				currentToken = rotated.Token
+				if event.IsDynamicQR() {
+					resetTimer(qrTimer, untilRefresh(rotated))
+				}
+			}
+			if err := send(update); err != nil {
+				return err
+			}
+
+		case <-qrTimer.C:
+			// The dynamic code moved to its next window
+			qr, err := s.qrService.GetActive(eventID)
+			if err != nil {
+				return err
+			}
+			qrTimer.Reset(untilRefresh(qr))
+			if qr == nil || qr.Token == currentToken {
+				continue
+			}
+			currentToken = qr.Token
+			if err := send(&services.LiveUpdate{Type: services.LiveQRRotated, Data: qr}); err != nil {
+				return err
+			}
+
+		case <-heartbeat.C:
+			if err := send(&services.LiveUpdate{Type: services.LiveHeartbeat}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func liveCounts(repo repositories.AttendanceRepository, eventID uint) (models.AttendanceCounts, error) {
+	var counts models.AttendanceCounts
+	rows, err := repo.CountByEvent(eventID)
+	if err != nil {
+		return counts, err
+	}
+	for _, row := range rows {
+		counts.Add(row.Status, row.Count)
+	}
+	return counts, nil
+}
+
+func untilRefresh(qr *models.QRCode) time.Duration {
+	if qr == nil {
+		return liveMinQRRefresh
+	}
+	if wait := time.Until(qr.ExpiresAt); wait > liveMinQRRefresh {
+		return wait
+	}
+	return liveMinQRRefresh
+}
+
+// resetTimer reschedules a timer that may have fired without being drained
+func resetTimer(timer *time.Timer, d time.Duration) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+	timer.Reset(d)
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/repositories"
+	"github.com/juank/attendance-backend/internal/domain/services"
+	"github.com/juank/attendance-backend/pkg/qrimage"
+)
+
+// posterLevel leaves room for wear and glare on printed codes
+const posterLevel = qrimage.LevelQuartile
+
+type QRImageServiceImpl struct {
+	qrService services.QRService
+	eventRepo repositories.EventRepository
+}
+
+func NewQRImageService(qrService services.QRService, eventRepo repositories.EventRepository) services.QRImageService {
+	return &QRImageServiceImpl{
+		qrService: qrService,
+		eventRepo: eventRepo,
+	}
+}
+
+func (s *QRImageServiceImpl) RenderActive(eventID uint, format services.QRImageFormat, opts *services.QRImageOptions) (*services.QRImage, error) {
+	options := qrimage.Options{Size: opts.Size, Level: qrimage.Level(opts.Level)}
+	if err := options.Normalize(); err != nil {
+		return nil, fmt.Errorf("%w: %v", services.ErrInvalidQRImage, err)
+	}
+
+	qr, err := s.qrService.GetOrCreateActive(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Caption {
+		event, err := s.eventRepo.GetByID(eventID)
+		if err != nil {
+			return nil, errors.New("event not found")
+		}
+		options.Caption = event.Title
+	}
+
+	image := &services.QRImage{QR: qr}
+	switch format {
+	case services.QRImagePNG:
+		image.ContentType = qrimage.PNGContentType
+		image.Content, err = qrimage.PNG(qr.Token, options)
+	case services.QRImageSVG:
+		image.ContentType = qrimage.SVGContentType
+		image.Content, err = qrimage.SVG(qr.Token, options)
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %q", services.ErrInvalidQRImage, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return image, nil
+}
+
+func (s *QRImageServiceImpl) RenderPoster(eventID uint) (*services.QRImage, error) {
+	qr, err := s.qrService.GetOrCreatePrintable(eventID)
+	if err != nil {
+		return nil, err
+	}
+	event, err := s.eventRepo.GetByID(eventID)
+	if err != nil {
+		return nil, errors.New("event not found")
+	}
+
+	poster := &qrimage.Poster{
+		Title:   event.Title,
+		Details: posterDetails(event),
+		Content: qr.Token,
+		Footer:  "Scan this code with the attendance app to check in",
+		Level:   posterLevel,
+	}
+
+	var buf bytes.Buffer
+	if err := poster.WritePDF(&buf); err != nil {
+		return nil, err
+	}
+	return &services.QRImage{Content: buf.Bytes(), ContentType: qrimage.PDFContentType, QR: qr}, nil
+}
+
+// posterDetails describes when the event takes place, in its own time zone
+func posterDetails(event *models.Event) []string {
+	loc := event.Location()
+	start := event.StartTime.In(loc)
+
+	when := start.Format("Monday, January 2, 2006 · 15:04")
+	if !event.EndTime.IsZero() {
+		end := event.EndTime.In(loc)
+		if end.YearDay() == start.YearDay() && end.Year() == start.Year() {
+			when += end.Format(" – 15:04")
+		} else {
+			when += end.Format(" – Monday, January 2, 2006 · 15:04")
+		}
+	}
+
+	details := []string{fmt.Sprintf("%s (%s)", when, loc.String())}
+	if event.Description != "" {
+		details = append(details, event.Description)
+	}
+	return details
+}
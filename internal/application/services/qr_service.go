@@ -8,21 +8,42 @@ import (
 	"github.com/juank/attendance-backend/internal/domain/models"
 	"github.com/juank/attendance-backend/internal/domain/repositories"
 	domainServices "github.com/juank/attendance-backend/internal/domain/services"
+	"github.com/juank/attendance-backend/pkg/utils"
 )
 
 const QRExpirationMinutes = 10
 
+// DynamicQRSkewWindows is how many rotation windows before or after the current one
+// are still accepted, to tolerate clock skew and scanning delays
+const DynamicQRSkewWindows = 1
+
 type QRServiceImpl struct {
-	qrRepo repositories.QRCodeRepository
+	qrRepo    repositories.QRCodeRepository
+	eventRepo repositories.EventRepository
+	live      domainServices.LivePublisher
+	audit     domainServices.AuditRecorder
 }
 
-func NewQRService(qrRepo repositories.QRCodeRepository) domainServices.QRService {
+func NewQRService(qrRepo repositories.QRCodeRepository, eventRepo repositories.EventRepository, live domainServices.LivePublisher, audit domainServices.AuditRecorder) domainServices.QRService {
 	return &QRServiceImpl{
-		qrRepo: qrRepo,
+		qrRepo:    qrRepo,
+		eventRepo: eventRepo,
+		live:      live,
+		audit:     audit,
 	}
 }
 
 func (s *QRServiceImpl) GetOrCreateActive(eventID uint) (*models.QRCode, error) {
+	event, err := s.eventRepo.GetByID(eventID)
+	if err != nil {
+		return nil, errors.New("event not found")
+	}
+
+	// Dynamic codes are derived on the fly, nothing is stored
+	if event.IsDynamicQR() {
+		return s.currentDynamic(event, time.Now()), nil
+	}
+
 	// Try to get active QR code
 	qr, err := s.qrRepo.GetActive(eventID)
 	if err == nil && qr != nil {
@@ -31,39 +52,93 @@ func (s *QRServiceImpl) GetOrCreateActive(eventID uint) (*models.QRCode, error)
 	}
 
 	// No active QR or error, create new one
-	return s.GenerateNew(eventID)
+	return s.generate(eventID)
 }
 
-func (s *QRServiceImpl) GenerateNew(eventID uint) (*models.QRCode, error) {
-	// Deactivate all existing QR codes for this event
-	err := s.qrRepo.DeactivateAllForEvent(eventID)
+func (s *QRServiceImpl) GetActive(eventID uint) (*models.QRCode, error) {
+	event, err := s.eventRepo.GetByID(eventID)
+	if err != nil {
+		return nil, errors.New("event not found")
+	}
+
+	if event.IsDynamicQR() {
+		return s.currentDynamic(event, time.Now()), nil
+	}
+
+	qr, err := s.qrRepo.GetActive(eventID)
+	if err != nil {
+		return nil, nil
+	}
+	return qr, nil
+}
+
+func (s *QRServiceImpl) GenerateNew(actor *domainServices.Actor, eventID uint) (*models.QRCode, error) {
+	qr, err := s.generate(eventID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Generate new QR code
-	token := uuid.New().String()
-	expiresAt := time.Now().Add(QRExpirationMinutes * time.Minute)
+	s.audit.Record(actor, models.AuditQRGenerate, models.AuditTargetEvent, eventID, nil, qr)
+	return qr, nil
+}
 
-	qr := &models.QRCode{
-		Token:     token,
-		EventID:   eventID,
-		ExpiresAt: expiresAt,
-		IsActive:  true,
+// generate deactivates the codes of an event and issues a new one
+func (s *QRServiceImpl) generate(eventID uint) (*models.QRCode, error) {
+	event, err := s.eventRepo.GetByID(eventID)
+	if err != nil {
+		return nil, errors.New("event not found")
 	}
 
-	err = s.qrRepo.Create(qr)
+	// Deactivate all existing QR codes for this event
+	err = s.qrRepo.DeactivateAllForEvent(eventID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Clean up expired QR codes (async cleanup)
-	go s.qrRepo.DeleteExpired()
+	if event.IsDynamicQR() {
+		// Rotating the secret invalidates every code derived from the previous one
+		if err := s.rotateSecret(event); err != nil {
+			return nil, err
+		}
+		qr := s.currentDynamic(event, time.Now())
+		s.live.PublishQRRotated(qr)
+		return qr, nil
+	}
 
-	return qr, nil
+	return s.createStatic(eventID, time.Now().Add(QRExpirationMinutes*time.Minute))
+}
+
+func (s *QRServiceImpl) GetOrCreatePrintable(eventID uint) (*models.QRCode, error) {
+	event, err := s.eventRepo.GetByID(eventID)
+	if err != nil {
+		return nil, errors.New("event not found")
+	}
+	if event.IsDynamicQR() {
+		return nil, errors.New("posters require a static QR code")
+	}
+
+	validUntil := printableUntil(event)
+	if !validUntil.After(time.Now()) {
+		return nil, errors.New("event has already ended")
+	}
+
+	qr, err := s.qrRepo.GetActive(eventID)
+	if err == nil && qr != nil && !qr.ExpiresAt.Before(validUntil) {
+		return qr, nil
+	}
+
+	// Replace the short-lived code so the screen and the poster show the same one
+	if err := s.qrRepo.DeactivateAllForEvent(eventID); err != nil {
+		return nil, err
+	}
+	return s.createStatic(eventID, validUntil)
 }
 
 func (s *QRServiceImpl) ValidateToken(token string) (*models.QRCode, error) {
+	if utils.IsRotatingToken(token) {
+		return s.validateDynamic(token)
+	}
+
 	qr, err := s.qrRepo.GetByToken(token)
 	if err != nil {
 		return nil, errors.New("invalid QR code")
@@ -76,6 +151,99 @@ func (s *QRServiceImpl) ValidateToken(token string) (*models.QRCode, error) {
 	return qr, nil
 }
 
-func (s *QRServiceImpl) DeactivateActiveForEvent(eventID uint) error {
-	return s.qrRepo.DeactivateAllForEvent(eventID)
+func (s *QRServiceImpl) DeactivateActiveForEvent(actor *domainServices.Actor, eventID uint) error {
+	event, err := s.eventRepo.GetByID(eventID)
+	if err == nil && event.IsDynamicQR() {
+		if err := s.rotateSecret(event); err != nil {
+			return err
+		}
+	}
+
+	if err := s.qrRepo.DeactivateAllForEvent(eventID); err != nil {
+		return err
+	}
+
+	s.audit.Record(actor, models.AuditQRDeactivate, models.AuditTargetEvent, eventID, nil, nil)
+	return nil
+}
+
+// validateDynamic checks the signature and time window of a rotating token
+func (s *QRServiceImpl) validateDynamic(token string) (*models.QRCode, error) {
+	eventID, _, err := utils.ParseRotatingToken(token)
+	if err != nil {
+		return nil, errors.New("invalid QR code")
+	}
+
+	event, err := s.eventRepo.GetByID(eventID)
+	if err != nil || !event.IsDynamicQR() || event.QRSecret == "" {
+		return nil, errors.New("invalid QR code")
+	}
+
+	now := time.Now()
+	if !event.IsActive || !utils.VerifyRotatingToken(token, event.QRSecret, now, event.QRRotationPeriod(), DynamicQRSkewWindows) {
+		return nil, errors.New("QR code expired or inactive")
+	}
+
+	qr := s.currentDynamic(event, now)
+	qr.Token = token
+	return qr, nil
+}
+
+// currentDynamic builds the (unsaved) QR code for the rotation window containing now
+func (s *QRServiceImpl) currentDynamic(event *models.Event, now time.Time) *models.QRCode {
+	period := event.QRRotationPeriod()
+	counter := utils.RotationCounter(now, period)
+	windowStart := time.Unix(counter*int64(period/time.Second), 0)
+
+	return &models.QRCode{
+		Token:     utils.GenerateRotatingToken(event.QRSecret, event.ID, counter),
+		EventID:   event.ID,
+		Event:     *event,
+		ExpiresAt: windowStart.Add(period),
+		IsActive:  true,
+		CreatedAt: windowStart,
+	}
+}
+
+// createStatic stores a new static QR code; previous ones must already be deactivated
+func (s *QRServiceImpl) createStatic(eventID uint, expiresAt time.Time) (*models.QRCode, error) {
+	qr := &models.QRCode{
+		Token:     uuid.New().String(),
+		EventID:   eventID,
+		ExpiresAt: expiresAt,
+		IsActive:  true,
+	}
+
+	if err := s.qrRepo.Create(qr); err != nil {
+		return nil, err
+	}
+
+	// Clean up expired QR codes (async cleanup)
+	go s.qrRepo.DeleteExpired()
+
+	s.live.PublishQRRotated(qr)
+
+	return qr, nil
+}
+
+// printableUntil is when a printed code stops being needed: the event end, or the end of
+// its day when it has no end time
+func printableUntil(event *models.Event) time.Time {
+	if !event.EndTime.IsZero() {
+		return event.EndTime
+	}
+	start := event.StartTime.In(event.Location())
+	return time.Date(start.Year(), start.Month(), start.Day()+1, 0, 0, 0, 0, start.Location())
+}
+
+func (s *QRServiceImpl) rotateSecret(event *models.Event) error {
+	secret, err := utils.GenerateSecret(32)
+	if err != nil {
+		return err
+	}
+	if err := s.eventRepo.UpdateQRSecret(event.ID, secret); err != nil {
+		return err
+	}
+	event.QRSecret = secret
+	return nil
 }
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/repositories"
+	"github.com/juank/attendance-backend/internal/domain/services"
+)
+
+const reportDateLayout = "2006-01-02"
+
+type ReportServiceImpl struct {
+	attendanceRepo repositories.AttendanceRepository
+	deptRepo       repositories.DepartmentRepository
+	roles          services.Authorizer
+}
+
+func NewReportService(attendanceRepo repositories.AttendanceRepository, deptRepo repositories.DepartmentRepository, roles services.Authorizer) services.ReportService {
+	return &ReportServiceImpl{
+		attendanceRepo: attendanceRepo,
+		deptRepo:       deptRepo,
+		roles:          roles,
+	}
+}
+
+func (s *ReportServiceImpl) GetDepartmentSummaries(viewerID uint, role models.Role, from, to time.Time) ([]models.DepartmentSummary, error) {
+	start, end, err := reportRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var departments []models.Department
+	if s.roles.HasPermission(role, models.PermReportsReadAll) {
+		departments, err = s.deptRepo.GetAll()
+	} else {
+		departments, err = s.deptRepo.GetByManagerID(viewerID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]models.DepartmentSummary, 0, len(departments))
+	if len(departments) == 0 {
+		return summaries, nil
+	}
+
+	ids := make([]uint, 0, len(departments))
+	for _, dept := range departments {
+		ids = append(ids, dept.ID)
+	}
+
+	counts, err := s.attendanceRepo.CountByDepartments(ids, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	byDepartment := make(map[uint]*models.AttendanceCounts, len(departments))
+	for _, row := range counts {
+		if byDepartment[row.DepartmentID] == nil {
+			byDepartment[row.DepartmentID] = &models.AttendanceCounts{}
+		}
+		byDepartment[row.DepartmentID].Add(row.Status, row.Count)
+	}
+
+	for _, dept := range departments {
+		summary := models.DepartmentSummary{DepartmentID: dept.ID, DepartmentName: dept.Name}
+		if c := byDepartment[dept.ID]; c != nil {
+			summary.AttendanceCounts = *c
+		}
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
+
+func (s *ReportServiceImpl) GetDepartmentReport(departmentID, viewerID uint, role models.Role, from, to time.Time) (*models.DepartmentReport, error) {
+	start, end, err := reportRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	dept, err := s.deptRepo.GetByID(departmentID)
+	if err != nil {
+		return nil, errors.New("department not found")
+	}
+
+	if !s.roles.HasPermission(role, models.PermReportsReadAll) && (dept.ManagerID == nil || *dept.ManagerID != viewerID) {
+		return nil, errors.New("you do not manage this department")
+	}
+
+	attendances, err := s.attendanceRepo.GetByDepartmentInRange(departmentID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.DepartmentReport{
+		DepartmentID:   dept.ID,
+		DepartmentName: dept.Name,
+		From:           from.Format(reportDateLayout),
+		To:             to.Format(reportDateLayout),
+	}
+
+	// Every current member is listed, even without records in the range
+	users := make(map[uint]*models.UserAttendanceSummary, len(dept.Users))
+	for _, u := range dept.Users {
+		users[u.ID] = &models.UserAttendanceSummary{UserID: u.ID, FirstName: u.FirstName, LastName: u.LastName, Email: u.Email}
+	}
+	events := make(map[uint]*models.EventAttendanceSummary)
+	weeks := make(map[string]*models.WeeklyAttendanceSummary)
+
+	for _, a := range attendances {
+		report.Totals.Add(a.Status, 1)
+
+		user := users[a.UserID]
+		if user == nil {
+			user = &models.UserAttendanceSummary{UserID: a.UserID, FirstName: a.User.FirstName, LastName: a.User.LastName, Email: a.User.Email}
+			users[a.UserID] = user
+		}
+		user.Add(a.Status, 1)
+
+		event := events[a.EventID]
+		if event == nil {
+			event = &models.EventAttendanceSummary{EventID: a.EventID, Title: a.Event.Title, StartTime: a.Event.StartTime}
+			events[a.EventID] = event
+		}
+		event.Add(a.Status, 1)
+
+		key := weekStart(a.CheckIn.In(a.Event.Location())).Format(reportDateLayout)
+		week := weeks[key]
+		if week == nil {
+			week = &models.WeeklyAttendanceSummary{WeekStart: key}
+			weeks[key] = week
+		}
+		week.Add(a.Status, 1)
+	}
+
+	report.ByUser = make([]models.UserAttendanceSummary, 0, len(users))
+	for _, u := range users {
+		report.ByUser = append(report.ByUser, *u)
+	}
+	sort.Slice(report.ByUser, func(i, j int) bool {
+		a, b := report.ByUser[i], report.ByUser[j]
+		if a.LastName != b.LastName {
+			return a.LastName < b.LastName
+		}
+		if a.FirstName != b.FirstName {
+			return a.FirstName < b.FirstName
+		}
+		return a.UserID < b.UserID
+	})
+
+	report.ByEvent = make([]models.EventAttendanceSummary, 0, len(events))
+	for _, e := range events {
+		report.ByEvent = append(report.ByEvent, *e)
+	}
+	sort.Slice(report.ByEvent, func(i, j int) bool {
+		return report.ByEvent[i].StartTime.Before(report.ByEvent[j].StartTime)
+	})
+
+	report.ByWeek = make([]models.WeeklyAttendanceSummary, 0, len(weeks))
+	for _, w := range weeks {
+		report.ByWeek = append(report.ByWeek, *w)
+	}
+	sort.Slice(report.ByWeek, func(i, j int) bool {
+		return report.ByWeek[i].WeekStart < report.ByWeek[j].WeekStart
+	})
+
+	return report, nil
+}
+
+// reportRange turns an inclusive range of calendar days into check-in bounds
+func reportRange(from, to time.Time) (time.Time, time.Time, error) {
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, errors.New("end_date must not be before start_date")
+	}
+	return from, to.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
+
+// weekStart returns the Monday of the week containing t, at midnight
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return day.AddDate(0, 0, -offset)
+}
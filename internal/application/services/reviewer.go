@@ -0,0 +1,44 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/juank/attendance-backend/internal/domain/repositories"
+)
+
+// checkDepartmentReviewer allows reviewers of every department (reviewAll) and the manager
+// of the subject user's department to review requests filed by that user. Nobody can
+// review their own requests.
+func checkDepartmentReviewer(userRepo repositories.UserRepository, subjectID, reviewerID uint, reviewAll bool) error {
+	if subjectID == reviewerID {
+		return errors.New("you cannot review your own request")
+	}
+	if reviewAll {
+		return nil
+	}
+
+	user, err := userRepo.GetByID(subjectID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if user.Department == nil || user.Department.ManagerID == nil || *user.Department.ManagerID != reviewerID {
+		return errors.New("only the department manager can review this request")
+	}
+
+	return nil
+}
+
+// managedDepartmentIDs returns the IDs of the departments managed by a user
+func managedDepartmentIDs(deptRepo repositories.DepartmentRepository, managerID uint) ([]uint, error) {
+	departments, err := deptRepo.GetByManagerID(managerID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(departments))
+	for _, dept := range departments {
+		ids = append(ids, dept.ID)
+	}
+	return ids, nil
+}
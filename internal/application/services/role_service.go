@@ -0,0 +1,251 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/repositories"
+	"github.com/juank/attendance-backend/internal/domain/services"
+	"github.com/juank/attendance-backend/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// roleCacheTTL bounds how long other instances keep serving a role edited elsewhere
+const roleCacheTTL = 30 * time.Second
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+// RoleServiceImpl answers permission checks, which run on every request, from an
+// in-memory copy of the roles table
+type RoleServiceImpl struct {
+	roleRepo repositories.RoleRepository
+	audit    services.AuditRecorder
+
+	mu       sync.RWMutex
+	roles    map[models.Role]*models.RoleDefinition
+	loadedAt time.Time
+}
+
+func NewRoleService(roleRepo repositories.RoleRepository, audit services.AuditRecorder) services.RoleService {
+	return &RoleServiceImpl{
+		roleRepo: roleRepo,
+		audit:    audit,
+	}
+}
+
+func (s *RoleServiceImpl) HasPermission(role models.Role, permission models.Permission) bool {
+	definition, ok := s.lookup(role)
+	return ok && definition.Has(permission)
+}
+
+func (s *RoleServiceImpl) CanAssign(actorRole, role models.Role) bool {
+	target, ok := s.lookup(role)
+	if !ok {
+		return false
+	}
+	actor, ok := s.lookup(actorRole)
+	if !ok {
+		return false
+	}
+	for _, permission := range target.Granted() {
+		if !actor.Has(permission) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *RoleServiceImpl) Exists(role models.Role) bool {
+	_, ok := s.lookup(role)
+	return ok
+}
+
+func (s *RoleServiceImpl) RequiresMFA(role models.Role) bool {
+	definition, ok := s.lookup(role)
+	return ok && definition.RequireMFA
+}
+
+func (s *RoleServiceImpl) GetAll() ([]models.RoleDefinition, error) {
+	return s.roleRepo.GetAll()
+}
+
+func (s *RoleServiceImpl) GetByID(id uint) (*models.RoleDefinition, error) {
+	role, err := s.roleRepo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("role not found")
+	}
+	return role, nil
+}
+
+func (s *RoleServiceImpl) GetPermissions(role models.Role) (models.PermissionList, error) {
+	definition, ok := s.lookup(role)
+	if !ok {
+		return nil, errors.New("role not found")
+	}
+	return definition.Granted(), nil
+}
+
+func (s *RoleServiceImpl) Create(actor *services.Actor, req *services.CreateRoleRequest) (*models.RoleDefinition, error) {
+	if !roleNamePattern.MatchString(string(req.Name)) {
+		return nil, fmt.Errorf("%w: name must be 2 to 50 lowercase letters, digits or underscores", services.ErrInvalidRole)
+	}
+	if _, err := s.roleRepo.GetByName(req.Name); err == nil {
+		return nil, errors.New("role already exists")
+	}
+
+	permissions, err := s.checkPermissions(actor.Role, req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &models.RoleDefinition{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: permissions,
+		RequireMFA:  req.RequireMFA,
+	}
+	if err := s.roleRepo.Create(role); err != nil {
+		return nil, err
+	}
+
+	s.invalidate()
+	s.audit.Record(actor, models.AuditRoleCreate, models.AuditTargetRole, role.ID, nil, role)
+	return role, nil
+}
+
+func (s *RoleServiceImpl) Update(actor *services.Actor, id uint, req *services.UpdateRoleRequest) (*models.RoleDefinition, error) {
+	role, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	before := *role
+
+	// Only roles the actor could assign may be edited at all, so nobody can weaken MFA or
+	// permissions of a role above their own
+	if !s.CanAssign(actor.Role, role.Name) {
+		return nil, services.ErrRoleNotAssignable
+	}
+
+	if req.Description != nil {
+		role.Description = *req.Description
+	}
+	if req.RequireMFA != nil {
+		role.RequireMFA = *req.RequireMFA
+	}
+	if req.Permissions != nil {
+		if role.Name == models.RoleAdmin {
+			return nil, errors.New("admin permissions cannot be changed")
+		}
+		permissions, err := s.checkPermissions(actor.Role, req.Permissions)
+		if err != nil {
+			return nil, err
+		}
+		role.Permissions = permissions
+	}
+
+	if err := s.roleRepo.Update(role); err != nil {
+		return nil, err
+	}
+
+	s.invalidate()
+	s.audit.Record(actor, models.AuditRoleUpdate, models.AuditTargetRole, role.ID, &before, role)
+	return role, nil
+}
+
+func (s *RoleServiceImpl) Delete(actor *services.Actor, id uint) error {
+	role, err := s.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if role.IsSystem {
+		return errors.New("system roles cannot be deleted")
+	}
+
+	users, err := s.roleRepo.CountUsers(role.Name)
+	if err != nil {
+		return err
+	}
+	if users > 0 {
+		return errors.New("role is assigned to users")
+	}
+
+	if err := s.roleRepo.Delete(id); err != nil {
+		return err
+	}
+
+	s.invalidate()
+	s.audit.Record(actor, models.AuditRoleDelete, models.AuditTargetRole, role.ID, role, nil)
+	return nil
+}
+
+// checkPermissions validates and deduplicates a permission list, rejecting permissions
+// the actor does not hold
+func (s *RoleServiceImpl) checkPermissions(actorRole models.Role, requested []models.Permission) (models.PermissionList, error) {
+	permissions := make(models.PermissionList, 0, len(requested))
+	for _, permission := range requested {
+		if !permission.Valid() {
+			return nil, fmt.Errorf("%w: unknown permission %q", services.ErrInvalidRole, permission)
+		}
+		if permissions.Contains(permission) {
+			continue
+		}
+		if !s.HasPermission(actorRole, permission) {
+			return nil, errors.New("cannot grant permissions you do not have")
+		}
+		permissions = append(permissions, permission)
+	}
+	return permissions, nil
+}
+
+func (s *RoleServiceImpl) lookup(role models.Role) (*models.RoleDefinition, bool) {
+	s.mu.RLock()
+	fresh := s.roles != nil && time.Since(s.loadedAt) < roleCacheTTL
+	definition, ok := s.roles[role]
+	s.mu.RUnlock()
+	if fresh {
+		return definition, ok
+	}
+
+	s.reload()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	definition, ok = s.roles[role]
+	return definition, ok
+}
+
+func (s *RoleServiceImpl) reload() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Another request may have reloaded while this one waited for the lock
+	if s.roles != nil && time.Since(s.loadedAt) < roleCacheTTL {
+		return
+	}
+
+	roles, err := s.roleRepo.GetAll()
+	if err != nil {
+		// Keep answering from the previous copy, if any, rather than locking everyone out
+		logger.Error("Failed to load roles", zap.Error(err))
+		if s.roles != nil {
+			s.loadedAt = time.Now()
+		}
+		return
+	}
+
+	s.roles = make(map[models.Role]*models.RoleDefinition, len(roles))
+	for i := range roles {
+		s.roles[roles[i].Name] = &roles[i]
+	}
+	s.loadedAt = time.Now()
+}
+
+func (s *RoleServiceImpl) invalidate() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/repositories"
+	"github.com/juank/attendance-backend/internal/domain/services"
+	"github.com/juank/attendance-backend/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// tokenStateTTL bounds how long other instances keep honoring tokens revoked elsewhere
+const tokenStateTTL = 30 * time.Second
+
+// tokenState is what Check needs to know about a user
+type tokenState struct {
+	version         int
+	active          bool
+	revokedSessions map[string]bool
+	loadedAt        time.Time
+}
+
+// TokenRevocationImpl checks every authenticated request against an in-memory copy of
+// each user's token version and revoked sessions. Revocations made through this instance
+// take effect at once; other instances see them within tokenStateTTL.
+type TokenRevocationImpl struct {
+	userRepo    repositories.UserRepository
+	revokedRepo repositories.RevokedSessionRepository
+	accessTTL   time.Duration
+
+	mu         sync.Mutex
+	states     map[uint]*tokenState
+	generation uint64 // raised by every Forget, so loads that raced with one are discarded
+	prunedAt   time.Time
+}
+
+// NewTokenRevocation creates the revocation checks; accessTTL is the lifetime of access
+// tokens, after which a revoked session needs no record
+func NewTokenRevocation(userRepo repositories.UserRepository, revokedRepo repositories.RevokedSessionRepository, accessTTL time.Duration) services.TokenRevocation {
+	return &TokenRevocationImpl{
+		userRepo:    userRepo,
+		revokedRepo: revokedRepo,
+		accessTTL:   accessTTL,
+		states:      make(map[uint]*tokenState),
+	}
+}
+
+func (s *TokenRevocationImpl) Check(userID uint, tokenVersion int, sessionID string) error {
+	state, err := s.state(userID)
+	if err != nil {
+		return err
+	}
+	if !state.active || tokenVersion < state.version || (sessionID != "" && state.revokedSessions[sessionID]) {
+		return services.ErrTokenRevoked
+	}
+	return nil
+}
+
+func (s *TokenRevocationImpl) RevokeUser(userID uint) error {
+	err := s.userRepo.IncrementTokenVersion(userID)
+	s.Forget(userID)
+	return err
+}
+
+func (s *TokenRevocationImpl) RevokeSession(userID uint, sessionID string) error {
+	now := time.Now()
+	err := s.revokedRepo.Create(&models.RevokedSession{
+		SessionID: sessionID,
+		UserID:    userID,
+		ExpiresAt: now.Add(s.accessTTL),
+	})
+	s.Forget(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.revokedRepo.DeleteExpired(now); err != nil {
+		logger.Error("Failed to delete expired session revocations", zap.Error(err))
+	}
+	return nil
+}
+
+func (s *TokenRevocationImpl) Forget(userID uint) {
+	s.mu.Lock()
+	delete(s.states, userID)
+	s.generation++
+	s.mu.Unlock()
+}
+
+func (s *TokenRevocationImpl) state(userID uint) (*tokenState, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	cached := s.states[userID]
+	generation := s.generation
+	s.mu.Unlock()
+	if cached != nil && now.Sub(cached.loadedAt) < tokenStateTTL {
+		return cached, nil
+	}
+
+	state, err := s.load(userID, now)
+	if err != nil {
+		// Keep answering from the previous copy, if any, rather than locking the user out
+		if cached != nil {
+			logger.Error("Failed to load token state", zap.Uint("user_id", userID), zap.Error(err))
+			return cached, nil
+		}
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.generation == generation {
+		s.states[userID] = state
+	}
+	s.prune(now)
+	return state, nil
+}
+
+func (s *TokenRevocationImpl) load(userID uint, now time.Time) (*tokenState, error) {
+	version, active, err := s.userRepo.GetTokenVersion(userID)
+	if err != nil {
+		return nil, err
+	}
+	state := &tokenState{version: version, active: active, loadedAt: now}
+	if !active {
+		return state, nil
+	}
+
+	sessionIDs, err := s.revokedRepo.GetIDsByUserID(userID, now)
+	if err != nil {
+		return nil, err
+	}
+	state.revokedSessions = make(map[string]bool, len(sessionIDs))
+	for _, id := range sessionIDs {
+		state.revokedSessions[id] = true
+	}
+	return state, nil
+}
+
+// prune drops stale entries, at most once per tokenStateTTL. Callers hold s.mu.
+func (s *TokenRevocationImpl) prune(now time.Time) {
+	if now.Sub(s.prunedAt) < tokenStateTTL {
+		return
+	}
+	s.prunedAt = now
+	for id, state := range s.states {
+		if now.Sub(state.loadedAt) >= tokenStateTTL {
+			delete(s.states, id)
+		}
+	}
+}
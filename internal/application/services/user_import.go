@@ -0,0 +1,269 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/services"
+	"github.com/juank/attendance-backend/pkg/utils"
+)
+
+// MaxImportRows caps the number of users in a single import
+const MaxImportRows = 5000
+
+var requiredImportColumns = []string{"email", "first_name", "last_name"}
+
+// importRecord is a non-blank CSV record and the line it starts on
+type importRecord struct {
+	line   int
+	fields []string
+}
+
+// importRow is a parsed CSV line waiting to be imported
+type importRow struct {
+	result   *services.ImportRowResult
+	user     *models.User
+	password string
+}
+
+func (s *UserServiceImpl) ImportUsers(r io.Reader, opts services.ImportUsersOptions) (*services.ImportUsersResult, error) {
+	records, columns, err := readImportFile(r)
+	if err != nil {
+		return nil, err
+	}
+
+	departments, err := s.deptRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	departmentsByName := make(map[string]*models.Department, len(departments))
+	for i := range departments {
+		departmentsByName[strings.ToLower(departments[i].Name)] = &departments[i]
+	}
+
+	field := func(record importRecord, name string) string {
+		idx, ok := columns[name]
+		if !ok || idx >= len(record.fields) {
+			return ""
+		}
+		return strings.TrimSpace(record.fields[idx])
+	}
+
+	emails := make([]string, 0, len(records))
+	for _, record := range records {
+		emails = append(emails, normalizeEmail(field(record, "email")))
+	}
+	existing, err := s.userRepo.GetExistingEmails(emails)
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[string]bool, len(existing))
+	for _, email := range existing {
+		taken[email] = true
+	}
+
+	result := &services.ImportUsersResult{
+		DryRun:             opts.DryRun,
+		Total:              len(records),
+		CreatedDepartments: []string{},
+		Rows:               make([]services.ImportRowResult, len(records)),
+	}
+
+	rows := make([]importRow, 0, len(records))
+	newDepartments := make(map[string]*models.Department)
+	var newDepartmentList []*models.Department
+	seen := make(map[string]int)
+
+	for i, record := range records {
+		email := normalizeEmail(field(record, "email"))
+		row := &result.Rows[i]
+		row.Line = record.line
+		row.Email = email
+
+		if email == "" {
+			row.Errors = append(row.Errors, "email is required")
+		} else if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+			row.Errors = append(row.Errors, "invalid email")
+		} else if taken[email] {
+			row.Errors = append(row.Errors, "email already registered")
+		} else if line, dup := seen[email]; dup {
+			row.Errors = append(row.Errors, fmt.Sprintf("duplicate email, first seen on line %d", line))
+		} else {
+			seen[email] = row.Line
+		}
+
+		firstName, lastName := field(record, "first_name"), field(record, "last_name")
+		if firstName == "" {
+			row.Errors = append(row.Errors, "first_name is required")
+		}
+		if lastName == "" {
+			row.Errors = append(row.Errors, "last_name is required")
+		}
+
+		role := models.Role(strings.ToLower(field(record, "role")))
+		if role == "" {
+			role = models.RoleEmployee
+		}
+		if !s.roles.Exists(role) {
+			row.Errors = append(row.Errors, fmt.Sprintf("role %q does not exist", role))
+		} else if !s.roles.CanAssign(opts.Actor.Role, role) {
+			row.Errors = append(row.Errors, fmt.Sprintf("role %q has permissions you do not have", role))
+		}
+
+		password := field(record, "password")
+		if password != "" && len(password) < 6 {
+			row.Errors = append(row.Errors, "password must be at least 6 characters")
+		}
+
+		user := &models.User{
+			Email:     email,
+			FirstName: firstName,
+			LastName:  lastName,
+			Role:      role,
+			IsActive:  true,
+		}
+
+		if name := field(record, "department"); name != "" {
+			key := strings.ToLower(name)
+			if dept, ok := departmentsByName[key]; ok {
+				user.DepartmentID = &dept.ID
+				row.Department = dept.Name
+			} else if dept, ok := newDepartments[key]; ok {
+				user.Department = dept
+				row.Department = dept.Name
+			} else if opts.CreateDepartments {
+				dept := &models.Department{Name: name}
+				newDepartments[key] = dept
+				newDepartmentList = append(newDepartmentList, dept)
+				user.Department = dept
+				row.Department = dept.Name
+			} else {
+				row.Errors = append(row.Errors, fmt.Sprintf("department %q does not exist", name))
+			}
+		}
+
+		if len(row.Errors) > 0 {
+			row.Status = services.ImportRowInvalid
+			result.Invalid++
+			continue
+		}
+
+		result.Valid++
+		rows = append(rows, importRow{result: row, user: user, password: password})
+	}
+
+	// All or nothing: a single invalid row keeps the whole file out
+	if result.Invalid > 0 {
+		for _, row := range rows {
+			row.result.Status = services.ImportRowSkipped
+		}
+		return result, nil
+	}
+
+	users := make([]*models.User, 0, len(rows))
+	for _, row := range rows {
+		if opts.DryRun {
+			// Rolled back anyway, so skip the cost of bcrypt for every row
+			row.user.Password = "dry-run"
+		} else {
+			if row.password == "" {
+				if row.password, err = utils.GenerateSecret(8); err != nil {
+					return nil, err
+				}
+				row.result.TemporaryPassword = row.password
+			}
+			if row.user.Password, err = utils.HashPassword(row.password); err != nil {
+				return nil, err
+			}
+		}
+		users = append(users, row.user)
+	}
+
+	if err := s.userRepo.Import(newDepartmentList, users, opts.DryRun); err != nil {
+		return nil, err
+	}
+
+	for _, dept := range newDepartmentList {
+		result.CreatedDepartments = append(result.CreatedDepartments, dept.Name)
+		if !opts.DryRun {
+			s.audit.Record(opts.Actor, models.AuditDepartmentCreate, models.AuditTargetDepartment, dept.ID, nil, dept)
+		}
+	}
+	for _, row := range rows {
+		if opts.DryRun {
+			row.result.Status = services.ImportRowWouldCreate
+			continue
+		}
+		id := row.user.ID
+		row.result.UserID = &id
+		row.result.Status = services.ImportRowCreated
+		s.audit.Record(opts.Actor, models.AuditUserImport, models.AuditTargetUser, id, nil, row.user)
+	}
+	result.Imported = !opts.DryRun
+
+	return result, nil
+}
+
+// readImportFile parses the CSV and returns its data records and the index of each known column
+func readImportFile(r io.Reader) ([]importRecord, map[string]int, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("%w: file is empty", services.ErrInvalidImport)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", services.ErrInvalidImport, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	for _, name := range requiredImportColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, nil, fmt.Errorf("%w: missing column %q", services.ErrInvalidImport, name)
+		}
+	}
+
+	var records []importRecord
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", services.ErrInvalidImport, err)
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+		if len(records) == MaxImportRows {
+			return nil, nil, fmt.Errorf("%w: more than %d rows", services.ErrInvalidImport, MaxImportRows)
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, importRecord{line: line, fields: record})
+	}
+
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("%w: no rows to import", services.ErrInvalidImport)
+	}
+
+	return records, columns, nil
+}
+
+func isBlankRecord(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
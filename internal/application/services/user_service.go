@@ -2,36 +2,62 @@ package services
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/juank/attendance-backend/internal/domain/models"
 	"github.com/juank/attendance-backend/internal/domain/repositories"
 	"github.com/juank/attendance-backend/internal/domain/services"
+	"github.com/juank/attendance-backend/pkg/logger"
 	"github.com/juank/attendance-backend/pkg/utils"
+	"go.uber.org/zap"
 )
 
 type UserServiceImpl struct {
-	userRepo repositories.UserRepository
+	userRepo         repositories.UserRepository
+	deptRepo         repositories.DepartmentRepository
+	refreshTokenRepo repositories.RefreshTokenRepository
+	roles            services.Authorizer
+	tokens           services.TokenRevocation
+	webhooks         services.WebhookPublisher
+	audit            services.AuditRecorder
 }
 
-func NewUserService(userRepo repositories.UserRepository) services.UserService {
+func NewUserService(userRepo repositories.UserRepository, deptRepo repositories.DepartmentRepository, refreshTokenRepo repositories.RefreshTokenRepository, roles services.Authorizer, tokens services.TokenRevocation, webhooks services.WebhookPublisher, audit services.AuditRecorder) services.UserService {
 	return &UserServiceImpl{
-		userRepo: userRepo,
+		userRepo:         userRepo,
+		deptRepo:         deptRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		roles:            roles,
+		tokens:           tokens,
+		webhooks:         webhooks,
+		audit:            audit,
 	}
 }
 
-func (s *UserServiceImpl) Create(req *services.CreateUserRequest) (*models.User, error) {
-	existingUser, _ := s.userRepo.GetByEmail(req.Email)
-	if existingUser != nil {
+func (s *UserServiceImpl) Create(actor *services.Actor, req *services.CreateUserRequest) (*models.User, error) {
+	email := normalizeEmail(req.Email)
+	taken, err := emailTaken(s.userRepo, email)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
 		return nil, errors.New("email already registered")
 	}
 
+	if req.Role == "" {
+		req.Role = models.RoleEmployee
+	}
+	if err := s.checkAssignable(actor.Role, req.Role); err != nil {
+		return nil, err
+	}
+
 	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
 		return nil, err
 	}
 
 	user := &models.User{
-		Email:        req.Email,
+		Email:        email,
 		Password:     hashedPassword,
 		FirstName:    req.FirstName,
 		LastName:     req.LastName,
@@ -44,6 +70,7 @@ func (s *UserServiceImpl) Create(req *services.CreateUserRequest) (*models.User,
 		return nil, err
 	}
 
+	s.audit.Record(actor, models.AuditUserCreate, models.AuditTargetUser, user.ID, nil, user)
 	return user, nil
 }
 
@@ -52,14 +79,18 @@ func (s *UserServiceImpl) GetByID(id uint) (*models.User, error) {
 }
 
 func (s *UserServiceImpl) GetByEmail(email string) (*models.User, error) {
-	return s.userRepo.GetByEmail(email)
+	return s.userRepo.GetByEmail(normalizeEmail(email))
 }
 
-func (s *UserServiceImpl) Update(id uint, req *services.UpdateUserRequest) (*models.User, error) {
+func (s *UserServiceImpl) Update(actor *services.Actor, id uint, req *services.UpdateUserRequest) (*models.User, error) {
 	user, err := s.userRepo.GetByID(id)
 	if err != nil {
 		return nil, err
 	}
+	if !s.roles.CanAssign(actor.Role, user.Role) {
+		return nil, services.ErrUserNotManageable
+	}
+	before := *user
 
 	if req.FirstName != "" {
 		user.FirstName = req.FirstName
@@ -68,11 +99,15 @@ func (s *UserServiceImpl) Update(id uint, req *services.UpdateUserRequest) (*mod
 		user.LastName = req.LastName
 	}
 	if req.Role != nil {
+		if err := s.checkAssignable(actor.Role, *req.Role); err != nil {
+			return nil, err
+		}
 		user.Role = *req.Role
 	}
 	if req.DepartmentID != nil {
 		user.DepartmentID = req.DepartmentID
 	}
+	wasActive := user.IsActive
 	if req.IsActive != nil {
 		user.IsActive = *req.IsActive
 	}
@@ -81,11 +116,65 @@ func (s *UserServiceImpl) Update(id uint, req *services.UpdateUserRequest) (*mod
 		return nil, err
 	}
 
+	// Access tokens carry the role, and deactivated users must lose access at once
+	if user.Role != before.Role || user.IsActive != before.IsActive {
+		if err := s.tokens.RevokeUser(user.ID); err != nil {
+			logger.Error("Failed to revoke access tokens", zap.Uint("user_id", user.ID), zap.Error(err))
+		}
+	}
+
+	s.audit.Record(actor, models.AuditUserUpdate, models.AuditTargetUser, user.ID, &before, user)
+	if wasActive && !user.IsActive {
+		s.webhooks.Publish(models.WebhookUserDeactivated, user)
+	}
+
 	return user, nil
 }
 
-func (s *UserServiceImpl) Delete(id uint) error {
-	return s.userRepo.Delete(id)
+func (s *UserServiceImpl) Delete(actor *services.Actor, id uint) error {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if !s.roles.CanAssign(actor.Role, user.Role) {
+		return services.ErrUserNotManageable
+	}
+
+	if err := s.userRepo.Delete(id); err != nil {
+		return err
+	}
+	s.tokens.Forget(user.ID)
+
+	s.audit.Record(actor, models.AuditUserDelete, models.AuditTargetUser, user.ID, user, nil)
+
+	// Deleted users can no longer sign in, which integrations treat as a deactivation
+	if user.IsActive {
+		s.webhooks.Publish(models.WebhookUserDeactivated, user)
+	}
+	return nil
+}
+
+func (s *UserServiceImpl) Unlock(actor *services.Actor, id uint) (*models.User, error) {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if !s.roles.CanAssign(actor.Role, user.Role) {
+		return nil, services.ErrUserNotManageable
+	}
+
+	if err := s.userRepo.ResetLoginFailures(id); err != nil {
+		return nil, err
+	}
+
+	before := *user
+	user.FailedLoginAttempts = 0
+	user.LastFailedLoginAt = nil
+	user.LockedUntil = nil
+	s.audit.Record(actor, models.AuditUserUnlock, models.AuditTargetUser, user.ID,
+		loginLock{before.FailedLoginAttempts, before.LockedUntil}, loginLock{})
+
+	return user, nil
 }
 
 func (s *UserServiceImpl) GetAll(page, limit int) ([]models.User, int64, error) {
@@ -98,8 +187,8 @@ func (s *UserServiceImpl) GetAll(page, limit int) ([]models.User, int64, error)
 	return s.userRepo.GetAll(page, limit)
 }
 
-func (s *UserServiceImpl) ChangePassword(userID uint, req *services.ChangePasswordRequest) error {
-	user, err := s.userRepo.GetByID(userID)
+func (s *UserServiceImpl) ChangePassword(actor *services.Actor, req *services.ChangePasswordRequest) error {
+	user, err := s.userRepo.GetByID(actor.UserID)
 	if err != nil {
 		return err
 	}
@@ -114,5 +203,48 @@ func (s *UserServiceImpl) ChangePassword(userID uint, req *services.ChangePasswo
 	}
 
 	user.Password = hashedPassword
-	return s.userRepo.Update(user)
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+
+	// Whoever knew the old password may hold a session; every session, this one included,
+	// must sign in again
+	if err := s.refreshTokenRepo.RevokeByUserID(user.ID); err != nil {
+		return err
+	}
+	if err := s.tokens.RevokeUser(user.ID); err != nil {
+		return err
+	}
+
+	s.audit.Record(actor, models.AuditUserPasswordChange, models.AuditTargetUser, user.ID, nil, passwordChanged)
+	return nil
+}
+
+// passwordChanged is the audit payload of password changes, whose values are never recorded
+var passwordChanged = map[string]interface{}{"password": true}
+
+// checkAssignable verifies the role exists and the actor holds all of its permissions
+func (s *UserServiceImpl) checkAssignable(actorRole, role models.Role) error {
+	if !s.roles.Exists(role) {
+		return errors.New("role does not exist")
+	}
+	if !s.roles.CanAssign(actorRole, role) {
+		return services.ErrRoleNotAssignable
+	}
+	return nil
+}
+
+// normalizeEmail returns the form emails are stored and looked up in
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// emailTaken reports whether any account already uses the normalized email, deleted ones
+// included since they still hold it
+func emailTaken(userRepo repositories.UserRepository, email string) (bool, error) {
+	existing, err := userRepo.GetExistingEmails([]string{email})
+	if err != nil {
+		return false, err
+	}
+	return len(existing) > 0, nil
 }
@@ -0,0 +1,391 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/juank/attendance-backend/config"
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/repositories"
+	"github.com/juank/attendance-backend/internal/domain/services"
+	"github.com/juank/attendance-backend/pkg/logger"
+	"github.com/juank/attendance-backend/pkg/utils"
+	"github.com/juank/attendance-backend/pkg/webhook"
+	"go.uber.org/zap"
+)
+
+const (
+	// webhookBatchSize is how many due deliveries a dispatch run claims at most
+	webhookBatchSize = 50
+	// webhookConcurrency is how many deliveries are sent in parallel
+	webhookConcurrency = 4
+	// webhookRetryBase and webhookRetryMax bound the exponential backoff between attempts
+	webhookRetryBase = 30 * time.Second
+	webhookRetryMax  = 6 * time.Hour
+	// webhookResponseLimit is how much of the response body is kept in the attempt log
+	webhookResponseLimit = 1024
+	webhookMinSecretLen  = 16
+)
+
+type WebhookServiceImpl struct {
+	webhookRepo  repositories.WebhookRepository
+	deliveryRepo repositories.WebhookDeliveryRepository
+	audit        services.AuditRecorder
+	client       *http.Client
+	maxAttempts  int
+}
+
+func NewWebhookService(
+	webhookRepo repositories.WebhookRepository,
+	deliveryRepo repositories.WebhookDeliveryRepository,
+	audit services.AuditRecorder,
+	cfg *config.Config,
+) services.WebhookService {
+	maxAttempts := cfg.Webhooks.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	return &WebhookServiceImpl{
+		webhookRepo:  webhookRepo,
+		deliveryRepo: deliveryRepo,
+		audit:        audit,
+		client: &http.Client{
+			Timeout: cfg.Webhooks.Timeout,
+			// Redirects are not followed; the registered URL must answer directly
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		maxAttempts: maxAttempts,
+	}
+}
+
+func (s *WebhookServiceImpl) Create(actor *services.Actor, req *services.CreateWebhookRequest) (*models.Webhook, error) {
+	hook := &models.Webhook{
+		URL:         req.URL,
+		Description: req.Description,
+		EventTypes:  req.EventTypes,
+		Secret:      req.Secret,
+		IsActive:    true,
+	}
+
+	if hook.Secret == "" {
+		secret, err := utils.GenerateSecret(32)
+		if err != nil {
+			return nil, err
+		}
+		hook.Secret = secret
+	}
+
+	if err := validateWebhook(hook); err != nil {
+		return nil, err
+	}
+
+	if err := s.webhookRepo.Create(hook); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(actor, models.AuditWebhookCreate, models.AuditTargetWebhook, hook.ID, nil, hook)
+	return hook, nil
+}
+
+func (s *WebhookServiceImpl) GetByID(id uint) (*models.Webhook, error) {
+	hook, err := s.webhookRepo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("webhook not found")
+	}
+	return hook, nil
+}
+
+func (s *WebhookServiceImpl) GetAll() ([]models.Webhook, error) {
+	return s.webhookRepo.GetAll()
+}
+
+func (s *WebhookServiceImpl) Update(actor *services.Actor, id uint, req *services.UpdateWebhookRequest) (*models.Webhook, error) {
+	hook, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	before := *hook
+
+	if req.URL != nil {
+		hook.URL = *req.URL
+	}
+	if req.Description != nil {
+		hook.Description = *req.Description
+	}
+	if req.EventTypes != nil {
+		hook.EventTypes = req.EventTypes
+	}
+	if req.Secret != nil {
+		hook.Secret = *req.Secret
+	}
+	if req.IsActive != nil {
+		hook.IsActive = *req.IsActive
+	}
+
+	if err := validateWebhook(hook); err != nil {
+		return nil, err
+	}
+
+	if err := s.webhookRepo.Update(hook); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(actor, models.AuditWebhookUpdate, models.AuditTargetWebhook, hook.ID, &before, hook)
+	return hook, nil
+}
+
+func (s *WebhookServiceImpl) Delete(actor *services.Actor, id uint) error {
+	hook, err := s.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.webhookRepo.Delete(id); err != nil {
+		return err
+	}
+
+	s.audit.Record(actor, models.AuditWebhookDelete, models.AuditTargetWebhook, hook.ID, hook, nil)
+	return nil
+}
+
+func (s *WebhookServiceImpl) GetDeliveries(webhookID uint, status models.WebhookDeliveryStatus, page, limit int) ([]models.WebhookDelivery, int64, error) {
+	if _, err := s.GetByID(webhookID); err != nil {
+		return nil, 0, err
+	}
+
+	switch status {
+	case "", models.WebhookDeliveryPending, models.WebhookDeliverySucceeded, models.WebhookDeliveryFailed:
+	default:
+		return nil, 0, fmt.Errorf("%w: status must be pending, succeeded or failed", services.ErrInvalidWebhook)
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	return s.deliveryRepo.GetByWebhookID(webhookID, status, page, limit)
+}
+
+func (s *WebhookServiceImpl) GetDelivery(webhookID, deliveryID uint) (*models.WebhookDelivery, error) {
+	delivery, err := s.deliveryRepo.GetByID(deliveryID)
+	if err != nil || delivery.WebhookID != webhookID {
+		return nil, errors.New("delivery not found")
+	}
+	return delivery, nil
+}
+
+func (s *WebhookServiceImpl) RetryDelivery(actor *services.Actor, webhookID, deliveryID uint) (*models.WebhookDelivery, error) {
+	delivery, err := s.GetDelivery(webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if delivery.Status != models.WebhookDeliveryFailed {
+		return nil, errors.New("only failed deliveries can be retried")
+	}
+
+	// The attempt count is kept, so a retried delivery gets exactly one more attempt
+	before := *delivery
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.NextAttemptAt = time.Now()
+	if err := s.deliveryRepo.Update(delivery); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(actor, models.AuditWebhookRetry, models.AuditTargetWebhookDelivery, delivery.ID, &before, delivery)
+	return delivery, nil
+}
+
+func (s *WebhookServiceImpl) Publish(eventType models.WebhookEventType, data interface{}) {
+	if err := s.enqueue(eventType, data); err != nil {
+		logger.Error("Failed to queue webhook deliveries",
+			zap.String("event_type", string(eventType)),
+			zap.Error(err),
+		)
+	}
+}
+
+func (s *WebhookServiceImpl) enqueue(eventType models.WebhookEventType, data interface{}) error {
+	webhooks, err := s.webhookRepo.GetActiveByEventType(eventType)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+
+	now := time.Now()
+	envelope := services.WebhookEnvelope{
+		ID:        uuid.NewString(),
+		Type:      eventType,
+		CreatedAt: now.UTC(),
+		Data:      data,
+	}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0, len(webhooks))
+	for _, hook := range webhooks {
+		deliveries = append(deliveries, models.WebhookDelivery{
+			WebhookID:     hook.ID,
+			EventID:       envelope.ID,
+			EventType:     eventType,
+			Payload:       payload,
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: now,
+		})
+	}
+
+	return s.deliveryRepo.CreateBatch(deliveries)
+}
+
+func (s *WebhookServiceImpl) DispatchDue(now time.Time) (int, error) {
+	// The lease keeps other dispatchers away while the batch is being sent
+	lease := s.client.Timeout*time.Duration(webhookBatchSize/webhookConcurrency+1) + time.Minute
+	deliveries, err := s.deliveryRepo.ClaimDue(now, webhookBatchSize, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, webhookConcurrency)
+	for i := range deliveries {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(delivery *models.WebhookDelivery) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			s.deliver(delivery)
+		}(&deliveries[i])
+	}
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+// deliver makes one attempt and schedules the next one or settles the delivery
+func (s *WebhookServiceImpl) deliver(delivery *models.WebhookDelivery) {
+	started := time.Now()
+	attempt := &models.WebhookDeliveryAttempt{
+		DeliveryID: delivery.ID,
+		Attempt:    delivery.AttemptCount + 1,
+	}
+
+	hook := delivery.Webhook
+	disabled := hook == nil || hook.DeletedAt.Valid || !hook.IsActive
+	if disabled {
+		attempt.Error = "webhook is deleted or disabled"
+	} else {
+		status, body, err := s.send(hook, delivery, started)
+		attempt.ResponseStatus = status
+		attempt.ResponseBody = body
+		if err != nil {
+			attempt.Error = err.Error()
+		}
+	}
+	attempt.DurationMs = time.Since(started).Milliseconds()
+
+	delivery.AttemptCount = attempt.Attempt
+	delivery.LastAttemptAt = &started
+	delivery.ResponseStatus = attempt.ResponseStatus
+	delivery.LastError = attempt.Error
+
+	switch {
+	case attempt.Error == "":
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &started
+	case disabled || delivery.AttemptCount >= s.maxAttempts:
+		delivery.Status = models.WebhookDeliveryFailed
+	default:
+		delivery.NextAttemptAt = started.Add(webhookBackoff(delivery.AttemptCount))
+	}
+
+	if err := s.deliveryRepo.RecordAttempt(delivery, attempt); err != nil {
+		logger.Error("Failed to record webhook delivery attempt",
+			zap.Uint("delivery_id", delivery.ID),
+			zap.Error(err),
+		)
+	}
+}
+
+// send posts the signed payload. A non-2xx answer is reported as an error.
+func (s *WebhookServiceImpl) send(hook *models.Webhook, delivery *models.WebhookDelivery, now time.Time) (*int, string, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "attendance-backend-webhooks/1.0")
+	req.Header.Set(webhook.EventHeader, string(delivery.EventType))
+	req.Header.Set(webhook.EventIDHeader, delivery.EventID)
+	req.Header.Set(webhook.DeliveryHeader, fmt.Sprint(delivery.ID))
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(hook.Secret, now, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	status := resp.StatusCode
+	if status < 200 || status > 299 {
+		return &status, string(snippet), fmt.Errorf("unexpected response status %d", status)
+	}
+	return &status, string(snippet), nil
+}
+
+// webhookBackoff returns the wait before the next attempt: 30s, 1m, 2m... capped at 6h,
+// with up to 20% jitter so failing deliveries don't retry in lockstep
+func webhookBackoff(attempts int) time.Duration {
+	wait := webhookRetryMax
+	if attempts-1 < 20 {
+		if d := webhookRetryBase << uint(attempts-1); d < webhookRetryMax {
+			wait = d
+		}
+	}
+	return wait + time.Duration(rand.Int63n(int64(wait)/5+1))
+}
+
+func validateWebhook(hook *models.Webhook) error {
+	target, err := url.Parse(hook.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", services.ErrInvalidWebhook)
+	}
+
+	if len(hook.EventTypes) == 0 {
+		return fmt.Errorf("%w: event_types must not be empty", services.ErrInvalidWebhook)
+	}
+	seen := make(map[models.WebhookEventType]bool, len(hook.EventTypes))
+	unique := make(models.WebhookEventTypeList, 0, len(hook.EventTypes))
+	for _, eventType := range hook.EventTypes {
+		if !eventType.Valid() {
+			return fmt.Errorf("%w: unknown event type %q", services.ErrInvalidWebhook, eventType)
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			unique = append(unique, eventType)
+		}
+	}
+	hook.EventTypes = unique
+
+	if len(hook.Secret) < webhookMinSecretLen {
+		return fmt.Errorf("%w: secret must be at least %d characters", services.ErrInvalidWebhook, webhookMinSecretLen)
+	}
+
+	return nil
+}
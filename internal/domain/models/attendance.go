@@ -16,17 +16,43 @@ const (
 )
 
 type Attendance struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	UserID    uint           `gorm:"not null;index" json:"user_id"`
-	User      User           `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	EventID   uint           `gorm:"not null;index" json:"event_id"`
-	Event     Event          `gorm:"foreignKey:EventID" json:"event,omitempty"`
-	CheckIn   time.Time      `gorm:"not null" json:"check_in"`
-	Status    string         `gorm:"type:varchar(20);not null" json:"status"` // present, late, absent
-	Notes     string         `gorm:"type:text" json:"notes"`
-	Location  string         `gorm:"type:varchar(255)" json:"location"`
-	QRToken   string         `gorm:"type:varchar(255);index" json:"qr_token"` // QR code used for marking
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	UserID          uint           `gorm:"not null;index;uniqueIndex:idx_attendance_event_user" json:"user_id"`
+	User            User           `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	EventID         uint           `gorm:"not null;index;uniqueIndex:idx_attendance_event_user" json:"event_id"`
+	Event           Event          `gorm:"foreignKey:EventID" json:"event,omitempty"`
+	CheckIn         time.Time      `gorm:"not null" json:"check_in"` // event start time for materialized absences
+	CheckOut        *time.Time     `json:"check_out"`
+	DurationMinutes *int           `json:"duration_minutes"` // time on site, set on check-out
+	LeftEarly       bool           `gorm:"default:false" json:"left_early"`
+	Status          string         `gorm:"type:varchar(20);not null" json:"status"` // present, late, absent
+	Notes           string         `gorm:"type:text" json:"notes"`
+	Location        string         `gorm:"type:varchar(255)" json:"location"`
+	Latitude        *float64       `json:"latitude"`
+	Longitude       *float64       `json:"longitude"`
+	DistanceMeters  *float64       `json:"distance_meters"` // distance from the event geofence
+	OutsideGeofence bool           `gorm:"default:false" json:"outside_geofence"`
+	QRToken         string         `gorm:"type:varchar(255);index" json:"qr_token"`     // QR code used for marking
+	AutoRecorded    bool           `gorm:"not null;default:false" json:"auto_recorded"` // created by the absence job when the event closed
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// IsCheckedOut reports whether a check-out has already been recorded
+func (a *Attendance) IsCheckedOut() bool {
+	return a.CheckOut != nil
+}
+
+// RecordCheckOut stamps the check-out time, the time spent on site and whether the
+// user left before the event ended
+func (a *Attendance) RecordCheckOut(at time.Time, event *Event) {
+	minutes := int(at.Sub(a.CheckIn).Minutes())
+	if minutes < 0 {
+		minutes = 0
+	}
+
+	a.CheckOut = &at
+	a.DurationMinutes = &minutes
+	a.LeftEarly = !event.EndTime.IsZero() && at.Before(event.EndTime)
 }
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CorrectionStatus string
+
+const (
+	CorrectionPending   CorrectionStatus = "pending"
+	CorrectionApproved  CorrectionStatus = "approved"
+	CorrectionRejected  CorrectionStatus = "rejected"
+	CorrectionCancelled CorrectionStatus = "cancelled"
+)
+
+// AttendanceCorrection is a request from an employee to fix one of their attendance records.
+// Only the requested fields are set.
+type AttendanceCorrection struct {
+	ID                uint              `gorm:"primaryKey" json:"id"`
+	AttendanceID      uint              `gorm:"not null;index" json:"attendance_id"`
+	Attendance        *Attendance       `gorm:"foreignKey:AttendanceID" json:"attendance,omitempty"`
+	RequesterID       uint              `gorm:"not null;index" json:"requester_id"`
+	Requester         *User             `gorm:"foreignKey:RequesterID" json:"requester,omitempty"`
+	RequestedStatus   *AttendanceStatus `gorm:"type:varchar(20)" json:"requested_status"`
+	RequestedCheckIn  *time.Time        `json:"requested_check_in"`
+	RequestedCheckOut *time.Time        `json:"requested_check_out"`
+	Reason            string            `gorm:"type:text;not null" json:"reason"`
+	Status            CorrectionStatus  `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	ReviewerID        *uint             `json:"reviewer_id"`
+	Reviewer          *User             `gorm:"foreignKey:ReviewerID" json:"reviewer,omitempty"`
+	ReviewedAt        *time.Time        `json:"reviewed_at"`
+	ReviewNote        string            `gorm:"type:text" json:"review_note"`
+	CreatedAt         time.Time         `json:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt    `gorm:"index" json:"-"`
+}
+
+// AttendanceRevision keeps the values an attendance record had before a change
+type AttendanceRevision struct {
+	ID               uint       `gorm:"primaryKey" json:"id"`
+	AttendanceID     uint       `gorm:"not null;index" json:"attendance_id"`
+	CorrectionID     *uint      `gorm:"index" json:"correction_id"`
+	LeaveRequestID   *uint      `gorm:"index" json:"leave_request_id"`
+	ChangedByID      uint       `gorm:"not null" json:"changed_by_id"`
+	ChangedBy        *User      `gorm:"foreignKey:ChangedByID" json:"changed_by,omitempty"`
+	PreviousStatus   string     `gorm:"type:varchar(20);not null" json:"previous_status"`
+	PreviousCheckIn  time.Time  `gorm:"not null" json:"previous_check_in"`
+	PreviousCheckOut *time.Time `json:"previous_check_out"`
+	PreviousNotes    string     `gorm:"type:text" json:"previous_notes"`
+	NewStatus        string     `gorm:"type:varchar(20);not null" json:"new_status"`
+	NewCheckIn       time.Time  `gorm:"not null" json:"new_check_in"`
+	NewCheckOut      *time.Time `json:"new_check_out"`
+	NewNotes         string     `gorm:"type:text" json:"new_notes"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// IsValidAttendanceStatus reports whether s is one of the known attendance statuses
+func IsValidAttendanceStatus(s AttendanceStatus) bool {
+	switch s {
+	case StatusPresent, StatusAbsent, StatusLate, StatusOnLeave:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// AttendanceExportFilter selects the records of an export. Zero values are not applied.
+type AttendanceExportFilter struct {
+	EventID   uint
+	UserID    uint
+	StartDate *time.Time // inclusive bounds on the check-in time
+	EndDate   *time.Time
+}
+
+// AttendanceExportRow is an attendance record flattened with its user, department and event
+type AttendanceExportRow struct {
+	AttendanceID   uint
+	FirstName      string
+	LastName       string
+	Email          string
+	DepartmentName string
+	EventTitle     string
+	EventTimezone  string
+	Status         string
+	CheckIn        time.Time
+	CheckOut       *time.Time
+	Notes          string
+	Location       string
+	Latitude       *float64
+	Longitude      *float64
+}
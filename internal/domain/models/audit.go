@@ -0,0 +1,143 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// AuditAction names a mutating operation as "<target>.<verb>"
+type AuditAction string
+
+const (
+	AuditUserCreate          AuditAction = "user.create"
+	AuditUserImport          AuditAction = "user.import"
+	AuditUserRegister        AuditAction = "user.register"
+	AuditUserUpdate          AuditAction = "user.update"
+	AuditUserDelete          AuditAction = "user.delete"
+	AuditUserPasswordChange  AuditAction = "user.password_change"
+	AuditUserPasswordReset   AuditAction = "user.password_reset"
+	AuditUserLock            AuditAction = "user.lock"
+	AuditUserUnlock          AuditAction = "user.unlock"
+	AuditMFAEnable           AuditAction = "user.mfa_enable"
+	AuditMFADisable          AuditAction = "user.mfa_disable"
+	AuditMFAReset            AuditAction = "user.mfa_reset"
+	AuditMFARecoveryCodes    AuditAction = "user.mfa_recovery_codes"
+	AuditUserForceLogout     AuditAction = "user.force_logout"
+	AuditUserSSOProvision    AuditAction = "user.sso_provision"
+	AuditUserSSOLink         AuditAction = "user.sso_link"
+	AuditSessionRevoke       AuditAction = "session.revoke"
+	AuditSessionReuse        AuditAction = "session.reuse_detected"
+	AuditRoleCreate          AuditAction = "role.create"
+	AuditRoleUpdate          AuditAction = "role.update"
+	AuditRoleDelete          AuditAction = "role.delete"
+	AuditDepartmentCreate    AuditAction = "department.create"
+	AuditDepartmentUpdate    AuditAction = "department.update"
+	AuditDepartmentDelete    AuditAction = "department.delete"
+	AuditEventCreate         AuditAction = "event.create"
+	AuditEventUpdate         AuditAction = "event.update"
+	AuditEventDelete         AuditAction = "event.delete"
+	AuditSeriesCreate        AuditAction = "event_series.create"
+	AuditSeriesUpdate        AuditAction = "event_series.update"
+	AuditSeriesDelete        AuditAction = "event_series.delete"
+	AuditSeriesException     AuditAction = "event_series.exception"
+	AuditParticipantAdd      AuditAction = "event_participant.add"
+	AuditParticipantRemove   AuditAction = "event_participant.remove"
+	AuditAttendanceManual    AuditAction = "attendance.manual"
+	AuditAttendanceCheckOut  AuditAction = "attendance.manual_check_out"
+	AuditQRGenerate          AuditAction = "qr_code.generate"
+	AuditQRDeactivate        AuditAction = "qr_code.deactivate"
+	AuditLeaveCreate         AuditAction = "leave_request.create"
+	AuditLeaveCancel         AuditAction = "leave_request.cancel"
+	AuditLeaveApprove        AuditAction = "leave_request.approve"
+	AuditLeaveReject         AuditAction = "leave_request.reject"
+	AuditCorrectionCreate    AuditAction = "attendance_correction.create"
+	AuditCorrectionCancel    AuditAction = "attendance_correction.cancel"
+	AuditCorrectionApprove   AuditAction = "attendance_correction.approve"
+	AuditCorrectionReject    AuditAction = "attendance_correction.reject"
+	AuditWebhookCreate       AuditAction = "webhook.create"
+	AuditWebhookUpdate       AuditAction = "webhook.update"
+	AuditWebhookDelete       AuditAction = "webhook.delete"
+	AuditWebhookRetry        AuditAction = "webhook_delivery.retry"
+	AuditCalendarTokenRotate AuditAction = "calendar_token.rotate"
+	AuditCalendarTokenRevoke AuditAction = "calendar_token.revoke"
+)
+
+// AuditTarget is the kind of entity an audit entry refers to
+type AuditTarget string
+
+const (
+	AuditTargetUser            AuditTarget = "user"
+	AuditTargetRole            AuditTarget = "role"
+	AuditTargetDepartment      AuditTarget = "department"
+	AuditTargetEvent           AuditTarget = "event"
+	AuditTargetSeries          AuditTarget = "event_series"
+	AuditTargetParticipant     AuditTarget = "event_participant"
+	AuditTargetAttendance      AuditTarget = "attendance"
+	AuditTargetLeaveRequest    AuditTarget = "leave_request"
+	AuditTargetCorrection      AuditTarget = "attendance_correction"
+	AuditTargetWebhook         AuditTarget = "webhook"
+	AuditTargetWebhookDelivery AuditTarget = "webhook_delivery"
+	AuditTargetCalendarToken   AuditTarget = "calendar_token"
+)
+
+// AuditChange holds the values of a field before and after an operation. Before is null
+// for creations and After for deletions.
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditChanges maps the JSON name of every changed field to its values
+type AuditChanges map[string]AuditChange
+
+// Value implements driver.Valuer
+func (c AuditChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner
+func (c *AuditChanges) Scan(value interface{}) error {
+	data, err := scanJSONBytes(value)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		*c = nil
+		return nil
+	}
+	return json.Unmarshal(data, c)
+}
+
+// AuditLog is an append-only record of a mutating operation. Entries are never updated or
+// deleted; the migrations install a trigger that rejects both.
+type AuditLog struct {
+	ID         uint         `gorm:"primaryKey" json:"id"`
+	ActorID    *uint        `gorm:"index" json:"actor_id"` // null for system and anonymous operations
+	Actor      *User        `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+	ActorRole  Role         `gorm:"type:varchar(50)" json:"actor_role"`
+	Action     AuditAction  `gorm:"size:64;not null;index" json:"action"`
+	TargetType AuditTarget  `gorm:"size:50;not null;index:idx_audit_target" json:"target_type"`
+	TargetID   *uint        `gorm:"index:idx_audit_target" json:"target_id"`
+	Changes    AuditChanges `gorm:"type:jsonb;not null;default:'{}'" json:"changes"`
+	IP         string       `gorm:"size:45" json:"ip"`
+	UserAgent  string       `gorm:"size:512" json:"user_agent"`
+	CreatedAt  time.Time    `gorm:"index" json:"created_at"`
+}
+
+// AuditLogFilter selects audit entries. Zero values are not applied.
+type AuditLogFilter struct {
+	ActorID    uint
+	Action     AuditAction
+	TargetType AuditTarget
+	TargetID   uint
+	From       *time.Time // inclusive bounds on CreatedAt
+	To         *time.Time
+}
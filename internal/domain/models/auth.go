@@ -6,6 +6,8 @@ import (
 	"gorm.io/gorm"
 )
 
+// RefreshToken is one link of a session. Every refresh revokes the token presented and
+// issues a new one in the same family, so a session is the family and its only active token.
 type RefreshToken struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
 	UserID    uint           `gorm:"not null;index" json:"user_id"`
@@ -16,4 +18,19 @@ type RefreshToken struct {
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	FamilyID         string     `gorm:"size:64;index" json:"family_id"` // the session; kept across rotations
+	SessionStartedAt time.Time  `json:"session_started_at"`             // when the family's first token was issued
+	IP               string     `gorm:"size:45" json:"ip"`
+	UserAgent        string     `gorm:"size:512" json:"user_agent"`
+	LastUsedAt       *time.Time `json:"last_used_at"` // when it was exchanged for a new one
+}
+
+// RevokedSession rejects the access tokens of a session that ended before they expire.
+// ExpiresAt is when the last access token the session may have issued expires.
+type RevokedSession struct {
+	SessionID string    `gorm:"primaryKey;size:64" json:"session_id"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
 }
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// CalendarToken lets calendar clients, which cannot send the Authorization header, read a
+// user's iCalendar feed. Only the SHA-256 of the token is stored; each user has at most one.
+type CalendarToken struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;uniqueIndex" json:"user_id"`
+	User       User       `gorm:"foreignKey:UserID" json:"-"`
+	TokenHash  string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
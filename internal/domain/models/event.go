@@ -2,13 +2,92 @@ package models
 
 import "time"
 
+// DefaultLateAfterMinutes is the grace period applied when an event does not define one
+const DefaultLateAfterMinutes = 15
+
 type Event struct {
-	ID          uint      `gorm:"primaryKey" json:"id"`
-	Title       string    `gorm:"not null" json:"title"`
-	Description string    `json:"description"`
-	StartTime   time.Time `gorm:"not null" json:"start_time"`
-	EndTime     time.Time `json:"end_time"`
-	IsActive    bool      `gorm:"default:true" json:"is_active"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID                   uint         `gorm:"primaryKey" json:"id"`
+	Title                string       `gorm:"not null" json:"title"`
+	Description          string       `json:"description"`
+	StartTime            time.Time    `gorm:"not null" json:"start_time"`
+	EndTime              time.Time    `json:"end_time"`
+	Timezone             string       `gorm:"size:64;not null;default:'UTC'" json:"timezone"` // IANA zone the event is scheduled in
+	LateAfterMinutes     *int         `gorm:"not null;default:15" json:"late_after_minutes"`  // grace period after StartTime
+	AbsentAfterMinutes   *int         `json:"absent_after_minutes"`                           // optional cutoff after StartTime
+	IsActive             bool         `gorm:"default:true" json:"is_active"`
+	IsRestricted         bool         `gorm:"default:false" json:"is_restricted"` // only roster participants may scan
+	QRMode               QRMode       `gorm:"type:varchar(20);not null;default:'static'" json:"qr_mode"`
+	QRRotationSeconds    int          `gorm:"not null;default:15" json:"qr_rotation_seconds"` // dynamic mode only
+	QRSecret             string       `gorm:"size:128" json:"-"`
+	GeofenceMode         GeofenceMode `gorm:"type:varchar(20);not null;default:'off'" json:"geofence_mode"`
+	GeofenceLatitude     *float64     `json:"geofence_latitude"`
+	GeofenceLongitude    *float64     `json:"geofence_longitude"`
+	GeofenceRadiusMeters *float64     `json:"geofence_radius_meters"`
+	GeofencePolygon      GeoPolygon   `gorm:"type:jsonb" json:"geofence_polygon,omitempty"` // takes precedence over the circle
+	ClosedAt             *time.Time   `gorm:"index" json:"closed_at"`                       // set once absences have been materialized
+	SeriesID             *uint        `gorm:"uniqueIndex:idx_event_series_occurrence" json:"series_id"`
+	OccurrenceDate       *time.Time   `gorm:"type:date;uniqueIndex:idx_event_series_occurrence" json:"occurrence_date"` // date in the series rule, kept if rescheduled
+	IsDetached           bool         `gorm:"default:false" json:"is_detached"`                                         // edited on its own, series edits skip it
+	CreatedAt            time.Time    `json:"created_at"`
+	UpdatedAt            time.Time    `json:"updated_at"`
+}
+
+// Location returns the event's time zone, falling back to UTC when it is empty or unknown
+func (e *Event) Location() *time.Location {
+	if e.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(e.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// LateThreshold returns the instant after which a check-in is considered late
+func (e *Event) LateThreshold() time.Time {
+	grace := DefaultLateAfterMinutes
+	if e.LateAfterMinutes != nil {
+		grace = *e.LateAfterMinutes
+	}
+	return e.StartTime.In(e.Location()).Add(time.Duration(grace) * time.Minute)
+}
+
+// AbsentThreshold returns the instant after which a check-in is considered absent, if configured
+func (e *Event) AbsentThreshold() (time.Time, bool) {
+	if e.AbsentAfterMinutes == nil {
+		return time.Time{}, false
+	}
+	return e.StartTime.In(e.Location()).Add(time.Duration(*e.AbsentAfterMinutes) * time.Minute), true
+}
+
+// HasEnded reports whether the event has an end time that is already in the past
+func (e *Event) HasEnded(now time.Time) bool {
+	return !e.EndTime.IsZero() && !now.Before(e.EndTime)
+}
+
+// AttendanceStatusAt derives the attendance status of a check-in made at the given time
+func (e *Event) AttendanceStatusAt(checkIn time.Time) AttendanceStatus {
+	checkIn = checkIn.In(e.Location())
+
+	if absentAt, ok := e.AbsentThreshold(); ok && checkIn.After(absentAt) {
+		return StatusAbsent
+	}
+	if checkIn.After(e.LateThreshold()) {
+		return StatusLate
+	}
+	return StatusPresent
+}
+
+// IsDynamicQR reports whether the event uses rotating QR tokens
+func (e *Event) IsDynamicQR() bool {
+	return e.QRMode == QRModeDynamic
+}
+
+// QRRotationPeriod returns how long each dynamic QR token is displayed
+func (e *Event) QRRotationPeriod() time.Duration {
+	if e.QRRotationSeconds <= 0 {
+		return DefaultQRRotationSeconds * time.Second
+	}
+	return time.Duration(e.QRRotationSeconds) * time.Second
 }
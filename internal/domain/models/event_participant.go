@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+type ParticipantType string
+
+const (
+	ParticipantUser       ParticipantType = "user"
+	ParticipantDepartment ParticipantType = "department"
+	ParticipantRole       ParticipantType = "role"
+)
+
+// EventParticipant is a roster entry assigning a user, a whole department or every user
+// with a role to an event. Exactly one of UserID, DepartmentID or Role is set, matching Type.
+type EventParticipant struct {
+	ID           uint            `gorm:"primaryKey" json:"id"`
+	EventID      uint            `gorm:"not null;index" json:"event_id"`
+	Event        Event           `gorm:"foreignKey:EventID" json:"-"`
+	Type         ParticipantType `gorm:"type:varchar(20);not null" json:"type"`
+	UserID       *uint           `gorm:"index" json:"user_id,omitempty"`
+	User         *User           `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	DepartmentID *uint           `gorm:"index" json:"department_id,omitempty"`
+	Department   *Department     `gorm:"foreignKey:DepartmentID" json:"department,omitempty"`
+	Role         *Role           `gorm:"type:varchar(50)" json:"role,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// Matches reports whether the roster entry covers the given user
+func (p *EventParticipant) Matches(user *User) bool {
+	switch p.Type {
+	case ParticipantUser:
+		return p.UserID != nil && *p.UserID == user.ID
+	case ParticipantDepartment:
+		return p.DepartmentID != nil && user.DepartmentID != nil && *p.DepartmentID == *user.DepartmentID
+	case ParticipantRole:
+		return p.Role != nil && *p.Role == user.Role
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OccurrenceDateLayout is the format of exception dates and occurrence keys
+const OccurrenceDateLayout = "2006-01-02"
+
+// EventSeries generates one Event per occurrence of a recurrence rule. The template holds
+// the settings shared by every occurrence; its StartTime and EndTime are those of the first
+// occurrence and give the local time of day and the duration of the rest.
+type EventSeries struct {
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	Title          string         `gorm:"not null" json:"title"`
+	RRule          string         `gorm:"column:rrule;size:255;not null" json:"rrule"` // e.g. FREQ=WEEKLY;BYDAY=MO,WE;UNTIL=20261231
+	Template       Event          `gorm:"serializer:json;type:jsonb;not null" json:"template"`
+	ExceptionDates DateList       `gorm:"type:jsonb" json:"exception_dates"` // skipped occurrence dates
+	GeneratedUntil *time.Time     `json:"generated_until"`                   // occurrences exist up to this instant
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// NewOccurrence builds the event of the occurrence starting at start
+func (s *EventSeries) NewOccurrence(start time.Time) Event {
+	event := Event{}
+	s.ApplyTo(&event, start)
+	return event
+}
+
+// ApplyTo copies the template onto an occurrence starting at start, keeping the
+// fields that belong to the occurrence itself
+func (s *EventSeries) ApplyTo(event *Event, start time.Time) {
+	id, secret, closedAt, createdAt := event.ID, event.QRSecret, event.ClosedAt, event.CreatedAt
+
+	*event = s.Template
+	event.ID, event.QRSecret, event.ClosedAt, event.CreatedAt = id, secret, closedAt, createdAt
+
+	event.StartTime = start
+	if !s.Template.EndTime.IsZero() {
+		event.EndTime = start.Add(s.Template.EndTime.Sub(s.Template.StartTime))
+	}
+
+	local := start.In(s.Template.Location())
+	date := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	event.SeriesID = &s.ID
+	event.OccurrenceDate = &date
+	event.IsDetached = false
+}
+
+// DateList is a list of YYYY-MM-DD dates stored as JSON
+type DateList []string
+
+// Contains reports whether the list includes the calendar day of t
+func (d DateList) Contains(t time.Time) bool {
+	key := t.Format(OccurrenceDateLayout)
+	for _, date := range d {
+		if date == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Value implements driver.Valuer
+func (d DateList) Value() (driver.Value, error) {
+	if len(d) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner
+func (d *DateList) Scan(value interface{}) error {
+	if value == nil {
+		*d = nil
+		return nil
+	}
+
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported type for DateList")
+	}
+
+	return json.Unmarshal(data, d)
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+
+	"github.com/juank/attendance-backend/pkg/geo"
+)
+
+type GeofenceMode string
+
+const (
+	GeofenceOff GeofenceMode = "off"
+	// GeofenceFlag accepts check-ins outside the fence but flags them
+	GeofenceFlag GeofenceMode = "flag"
+	// GeofenceReject refuses check-ins outside the fence
+	GeofenceReject GeofenceMode = "reject"
+)
+
+// GeoPolygon is a list of vertices stored as JSON
+type GeoPolygon []geo.Point
+
+// Value implements driver.Valuer
+func (p GeoPolygon) Value() (driver.Value, error) {
+	if len(p) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner
+func (p *GeoPolygon) Scan(value interface{}) error {
+	if value == nil {
+		*p = nil
+		return nil
+	}
+
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported type for GeoPolygon")
+	}
+
+	return json.Unmarshal(data, p)
+}
+
+// HasGeofence reports whether check-ins must be checked against a geofence
+func (e *Event) HasGeofence() bool {
+	return e.GeofenceMode == GeofenceFlag || e.GeofenceMode == GeofenceReject
+}
+
+// GeofenceDistance returns how far a point is from the event geofence in meters.
+// For a circle it is the distance to the center; for a polygon it is 0 inside the
+// polygon and the distance to the closest edge outside it.
+func (e *Event) GeofenceDistance(p geo.Point) float64 {
+	if len(e.GeofencePolygon) >= 3 {
+		return geo.DistanceToPolygon(e.GeofencePolygon, p)
+	}
+	if e.GeofenceLatitude == nil || e.GeofenceLongitude == nil {
+		return 0
+	}
+	return geo.Distance(geo.Point{Lat: *e.GeofenceLatitude, Lng: *e.GeofenceLongitude}, p)
+}
+
+// IsInsideGeofence reports whether a point at the given distance is inside the geofence
+func (e *Event) IsInsideGeofence(distance float64) bool {
+	if len(e.GeofencePolygon) >= 3 {
+		return distance == 0
+	}
+	if e.GeofenceRadiusMeters == nil {
+		return true
+	}
+	return distance <= *e.GeofenceRadiusMeters
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LeaveType string
+
+const (
+	LeaveVacation LeaveType = "vacation"
+	LeaveSick     LeaveType = "sick"
+	LeavePersonal LeaveType = "personal"
+	LeaveOther    LeaveType = "other"
+)
+
+type LeaveStatus string
+
+const (
+	LeavePending   LeaveStatus = "pending"
+	LeaveApproved  LeaveStatus = "approved"
+	LeaveRejected  LeaveStatus = "rejected"
+	LeaveCancelled LeaveStatus = "cancelled"
+)
+
+type LeaveRequest struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	UserID     uint           `gorm:"not null;index" json:"user_id"`
+	User       User           `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Type       LeaveType      `gorm:"type:varchar(20);not null" json:"type"`
+	StartDate  time.Time      `gorm:"type:date;not null;index" json:"start_date"`
+	EndDate    time.Time      `gorm:"type:date;not null;index" json:"end_date"` // inclusive
+	Reason     string         `gorm:"type:text" json:"reason"`
+	Status     LeaveStatus    `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	ReviewerID *uint          `json:"reviewer_id"`
+	Reviewer   *User          `gorm:"foreignKey:ReviewerID" json:"reviewer,omitempty"`
+	ReviewedAt *time.Time     `json:"reviewed_at"`
+	ReviewNote string         `gorm:"type:text" json:"review_note"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// IsValidLeaveType reports whether t is one of the supported leave types
+func IsValidLeaveType(t LeaveType) bool {
+	switch t {
+	case LeaveVacation, LeaveSick, LeavePersonal, LeaveOther:
+		return true
+	}
+	return false
+}
+
+// Covers reports whether the leave includes the calendar day of t in the given location
+func (l *LeaveRequest) Covers(t time.Time, loc *time.Location) bool {
+	local := t.In(loc)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	start := time.Date(l.StartDate.Year(), l.StartDate.Month(), l.StartDate.Day(), 0, 0, 0, 0, time.UTC)
+	end := time.Date(l.EndDate.Year(), l.EndDate.Month(), l.EndDate.Day(), 0, 0, 0, 0, time.UTC)
+	return !day.Before(start) && !day.After(end)
+}
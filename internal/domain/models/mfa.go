@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// MFARecoveryCode is a single-use code that replaces the authenticator app when it is
+// lost. Only the SHA-256 of the normalized code is stored.
+type MFARecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	User      User       `gorm:"foreignKey:UserID" json:"-"`
+	CodeHash  string     `gorm:"size:64;not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// OIDCAuthRequest is a single sign-on login in progress, from the redirect to the identity
+// provider until its callback. Only the SHA-256 of the state is stored; the nonce and the
+// PKCE code verifier never leave the server.
+type OIDCAuthRequest struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	StateHash    string    `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	Nonce        string    `gorm:"size:64;not null" json:"-"`
+	CodeVerifier string    `gorm:"size:128;not null" json:"-"`
+	ExpiresAt    time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// PasswordResetToken lets a user who forgot their password set a new one through an
+// emailed link. Only the SHA-256 of the token is stored, and it can be used once.
+type PasswordResetToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	User      User       `gorm:"foreignKey:UserID" json:"-"`
+	TokenHash string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`           // also set when a newer token or a reset supersedes it
+	IP        string     `gorm:"size:45" json:"ip"` // client that requested the reset
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -6,6 +6,18 @@ import (
 	"gorm.io/gorm"
 )
 
+type QRMode string
+
+const (
+	// QRModeStatic issues a stored token valid for a fixed period
+	QRModeStatic QRMode = "static"
+	// QRModeDynamic derives a short-lived token from the event secret and the current time window
+	QRModeDynamic QRMode = "dynamic"
+)
+
+// DefaultQRRotationSeconds is the rotation period of dynamic QR codes when none is configured
+const DefaultQRRotationSeconds = 15
+
 type QRCode struct {
 	ID        uint           `gorm:"primarykey" json:"id"`
 	Token     string         `gorm:"uniqueIndex;not null" json:"qr_token"`
@@ -0,0 +1,89 @@
+package models
+
+import "time"
+
+// AttendanceCounts aggregates attendance records by status. Rates are computed over
+// the records where the user was expected to attend, so on_leave records are excluded.
+type AttendanceCounts struct {
+	Present         int64   `json:"present"`
+	Late            int64   `json:"late"`
+	Absent          int64   `json:"absent"`
+	OnLeave         int64   `json:"on_leave"`
+	Total           int64   `json:"total"`
+	AttendanceRate  float64 `json:"attendance_rate"`  // (present + late) / expected
+	PunctualityRate float64 `json:"punctuality_rate"` // present / (present + late)
+}
+
+// Add counts n records with the given status
+func (c *AttendanceCounts) Add(status string, n int64) {
+	switch AttendanceStatus(status) {
+	case StatusPresent:
+		c.Present += n
+	case StatusLate:
+		c.Late += n
+	case StatusAbsent:
+		c.Absent += n
+	case StatusOnLeave:
+		c.OnLeave += n
+	}
+	c.Total += n
+
+	attended := c.Present + c.Late
+	if expected := c.Total - c.OnLeave; expected > 0 {
+		c.AttendanceRate = float64(attended) / float64(expected)
+	}
+	if attended > 0 {
+		c.PunctualityRate = float64(c.Present) / float64(attended)
+	}
+}
+
+// StatusCount is a row of a per-status aggregation
+type StatusCount struct {
+	Status string
+	Count  int64
+}
+
+// DepartmentStatusCount is a row of the per-department status aggregation
+type DepartmentStatusCount struct {
+	DepartmentID uint
+	Status       string
+	Count        int64
+}
+
+type DepartmentSummary struct {
+	DepartmentID   uint   `json:"department_id"`
+	DepartmentName string `json:"department_name"`
+	AttendanceCounts
+}
+
+type UserAttendanceSummary struct {
+	UserID    uint   `json:"user_id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+	AttendanceCounts
+}
+
+type EventAttendanceSummary struct {
+	EventID   uint      `json:"event_id"`
+	Title     string    `json:"title"`
+	StartTime time.Time `json:"start_time"`
+	AttendanceCounts
+}
+
+type WeeklyAttendanceSummary struct {
+	WeekStart string `json:"week_start"` // Monday of the week, YYYY-MM-DD in the event timezone
+	AttendanceCounts
+}
+
+// DepartmentReport is the attendance of a department's members over a date range
+type DepartmentReport struct {
+	DepartmentID   uint                      `json:"department_id"`
+	DepartmentName string                    `json:"department_name"`
+	From           string                    `json:"from"`
+	To             string                    `json:"to"`
+	Totals         AttendanceCounts          `json:"totals"`
+	ByUser         []UserAttendanceSummary   `json:"by_user"`
+	ByEvent        []EventAttendanceSummary  `json:"by_event"`
+	ByWeek         []WeeklyAttendanceSummary `json:"by_week"`
+}
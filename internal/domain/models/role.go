@@ -0,0 +1,159 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// Permission is an action a role may perform. Routes and services check permissions,
+// never role names.
+type Permission string
+
+const (
+	PermUsersRead            Permission = "users:read"
+	PermUsersManage          Permission = "users:manage"
+	PermDepartmentsManage    Permission = "departments:manage"
+	PermEventsWrite          Permission = "events:write"
+	PermAttendanceRead       Permission = "attendance:read"
+	PermAttendanceManual     Permission = "attendance:manual"
+	PermAttendanceExport     Permission = "attendance:export"
+	PermQRManage             Permission = "qr:manage"
+	PermReportsRead          Permission = "reports:read"
+	PermReportsReadAll       Permission = "reports:read_all"
+	PermLeaveReview          Permission = "leave:review"
+	PermLeaveReviewAll       Permission = "leave:review_all"
+	PermCorrectionsReview    Permission = "corrections:review"
+	PermCorrectionsReviewAll Permission = "corrections:review_all"
+	PermWebhooksManage       Permission = "webhooks:manage"
+	PermRolesManage          Permission = "roles:manage"
+	PermAuditRead            Permission = "audit:read"
+)
+
+// PermissionInfo describes a permission in the catalog
+type PermissionInfo struct {
+	Name        Permission `json:"name"`
+	Description string     `json:"description"`
+}
+
+// PermissionCatalog lists every permission that can be granted
+var PermissionCatalog = []PermissionInfo{
+	{PermUsersRead, "List and view user accounts"},
+	{PermUsersManage, "Create, import, update and delete users, and assign roles"},
+	{PermDepartmentsManage, "Create, update and delete departments"},
+	{PermEventsWrite, "Create, update and delete events, series and rosters; see restricted events"},
+	{PermAttendanceRead, "View the attendance of any event"},
+	{PermAttendanceManual, "Record attendance and check-outs manually"},
+	{PermAttendanceExport, "Export attendance as CSV or XLSX"},
+	{PermQRManage, "Display, generate and deactivate event QR codes"},
+	{PermReportsRead, "View reports of the departments the user manages"},
+	{PermReportsReadAll, "View reports of every department"},
+	{PermLeaveReview, "Review leave requests of the departments the user manages"},
+	{PermLeaveReviewAll, "Review leave requests of every department"},
+	{PermCorrectionsReview, "Review attendance corrections of the departments the user manages"},
+	{PermCorrectionsReviewAll, "Review attendance corrections of every department"},
+	{PermWebhooksManage, "Manage webhooks and their deliveries"},
+	{PermRolesManage, "Create and edit custom roles"},
+	{PermAuditRead, "View the audit log"},
+}
+
+// Valid reports whether the permission is in the catalog
+func (p Permission) Valid() bool {
+	for _, info := range PermissionCatalog {
+		if info.Name == p {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleDefinition is a role stored in the database. Users reference it by name.
+type RoleDefinition struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Name        Role           `gorm:"size:50;uniqueIndex;not null" json:"name"`
+	Description string         `gorm:"size:255" json:"description"`
+	Permissions PermissionList `gorm:"type:jsonb;not null;default:'[]'" json:"permissions"`
+	IsSystem    bool           `gorm:"default:false" json:"is_system"`            // built-in, cannot be renamed or deleted
+	RequireMFA  bool           `gorm:"not null;default:false" json:"require_mfa"` // users must enroll in two-factor authentication
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// TableName specifies the table name for RoleDefinition
+func (RoleDefinition) TableName() string {
+	return "roles"
+}
+
+// Has reports whether the role grants a permission. Admins are granted everything,
+// including permissions added after the role was stored.
+func (r *RoleDefinition) Has(permission Permission) bool {
+	if r.Name == RoleAdmin {
+		return true
+	}
+	return r.Permissions.Contains(permission)
+}
+
+// Granted returns the effective permissions of the role
+func (r *RoleDefinition) Granted() PermissionList {
+	if r.Name != RoleAdmin {
+		return r.Permissions
+	}
+	all := make(PermissionList, 0, len(PermissionCatalog))
+	for _, info := range PermissionCatalog {
+		all = append(all, info.Name)
+	}
+	return all
+}
+
+// SystemRoles are created by the migrations. Their permissions, except admin's, can be
+// edited afterwards.
+func SystemRoles() []RoleDefinition {
+	return []RoleDefinition{
+		{Name: RoleAdmin, Description: "Full access", IsSystem: true},
+		{
+			Name:        RoleManager,
+			Description: "Reviews and reports for the departments they manage",
+			Permissions: PermissionList{PermReportsRead, PermLeaveReview, PermCorrectionsReview},
+			IsSystem:    true,
+		},
+		{Name: RoleEmployee, Description: "Marks their own attendance", Permissions: PermissionList{}, IsSystem: true},
+	}
+}
+
+// PermissionList is a set of permissions stored as a jsonb array
+type PermissionList []Permission
+
+// Contains reports whether the list includes the permission
+func (l PermissionList) Contains(permission Permission) bool {
+	for _, p := range l {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Value implements driver.Valuer
+func (l PermissionList) Value() (driver.Value, error) {
+	if l == nil {
+		l = PermissionList{}
+	}
+	b, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner
+func (l *PermissionList) Scan(value interface{}) error {
+	data, err := scanJSONBytes(value)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		*l = nil
+		return nil
+	}
+	return json.Unmarshal(data, l)
+}
@@ -20,11 +20,30 @@ type User struct {
 	Password     string         `gorm:"not null" json:"-"`
 	FirstName    string         `gorm:"not null;size:100" json:"first_name" validate:"required"`
 	LastName     string         `gorm:"not null;size:100" json:"last_name" validate:"required"`
-	Role         Role           `gorm:"type:varchar(20);not null;default:'employee'" json:"role"`
+	Role         Role           `gorm:"type:varchar(50);not null;default:'employee'" json:"role"`
 	DepartmentID *uint          `json:"department_id"`
 	Department   *Department    `gorm:"foreignKey:DepartmentID" json:"department,omitempty"`
 	IsActive     bool           `gorm:"default:true" json:"is_active"`
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Failed login tracking. Locked accounts reject every login until LockedUntil.
+	FailedLoginAttempts int        `gorm:"not null;default:0" json:"-"`
+	LastFailedLoginAt   *time.Time `json:"-"`
+	LockedUntil         *time.Time `json:"locked_until,omitempty"`
+
+	// Two-factor authentication. TOTPSecret is set, and MFAEnabled false, between setup and
+	// activation. TOTPLastCounter is the last time window used, so a code works only once.
+	TOTPSecret      string `gorm:"size:64" json:"-"`
+	MFAEnabled      bool   `gorm:"not null;default:false" json:"mfa_enabled"`
+	TOTPLastCounter int64  `gorm:"not null;default:0" json:"-"`
+
+	// TokenVersion is copied into access tokens; raising it revokes every access token
+	// issued before
+	TokenVersion int `gorm:"not null;default:0" json:"-"`
+
+	// OIDCSubject is the user's ID at the single sign-on identity provider, once the
+	// account has signed in through it
+	OIDCSubject *string `gorm:"size:255;uniqueIndex" json:"-"`
 }
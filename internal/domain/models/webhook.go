@@ -0,0 +1,174 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WebhookEventType string
+
+const (
+	WebhookAttendanceMarked    WebhookEventType = "attendance.marked"
+	WebhookAttendanceCorrected WebhookEventType = "attendance.corrected"
+	WebhookEventCreated        WebhookEventType = "event.created"
+	WebhookEventClosed         WebhookEventType = "event.closed"
+	WebhookUserDeactivated     WebhookEventType = "user.deactivated"
+)
+
+// WebhookEventTypes lists the event types endpoints can subscribe to
+var WebhookEventTypes = []WebhookEventType{
+	WebhookAttendanceMarked,
+	WebhookAttendanceCorrected,
+	WebhookEventCreated,
+	WebhookEventClosed,
+	WebhookUserDeactivated,
+}
+
+// Valid reports whether the event type is one of WebhookEventTypes
+func (t WebhookEventType) Valid() bool {
+	for _, known := range WebhookEventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook is an endpoint registered by an admin. Deliveries are signed with Secret,
+// which is kept in clear because it is needed to compute the HMAC.
+type Webhook struct {
+	ID          uint                 `gorm:"primaryKey" json:"id"`
+	URL         string               `gorm:"size:2048;not null" json:"url"`
+	Description string               `gorm:"size:255" json:"description"`
+	Secret      string               `gorm:"size:128;not null" json:"-"`
+	EventTypes  WebhookEventTypeList `gorm:"type:jsonb;not null" json:"event_types"`
+	IsActive    bool                 `gorm:"default:true" json:"is_active"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt       `gorm:"index" json:"-"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed" // gave up after the last attempt
+)
+
+// WebhookDelivery is an outbox entry: one event to send to one webhook. Pending deliveries
+// are picked up by the dispatcher once NextAttemptAt is reached.
+type WebhookDelivery struct {
+	ID             uint                     `gorm:"primaryKey" json:"id"`
+	WebhookID      uint                     `gorm:"not null;index" json:"webhook_id"`
+	Webhook        *Webhook                 `gorm:"foreignKey:WebhookID" json:"-"`
+	EventID        string                   `gorm:"size:36;not null;index" json:"event_id"` // shared by the deliveries of the same event
+	EventType      WebhookEventType         `gorm:"type:varchar(50);not null" json:"event_type"`
+	Payload        JSONPayload              `gorm:"type:jsonb;not null" json:"payload"`
+	Status         WebhookDeliveryStatus    `gorm:"type:varchar(20);not null;default:'pending';index:idx_webhook_delivery_due" json:"status"`
+	AttemptCount   int                      `gorm:"not null;default:0" json:"attempt_count"`
+	NextAttemptAt  time.Time                `gorm:"not null;index:idx_webhook_delivery_due" json:"next_attempt_at"`
+	LastAttemptAt  *time.Time               `json:"last_attempt_at"`
+	ResponseStatus *int                     `json:"response_status"`
+	LastError      string                   `gorm:"size:1024" json:"last_error,omitempty"`
+	DeliveredAt    *time.Time               `json:"delivered_at"`
+	Attempts       []WebhookDeliveryAttempt `gorm:"foreignKey:DeliveryID" json:"attempts,omitempty"`
+	CreatedAt      time.Time                `json:"created_at"`
+	UpdatedAt      time.Time                `json:"updated_at"`
+}
+
+// WebhookDeliveryAttempt logs a single HTTP request made for a delivery
+type WebhookDeliveryAttempt struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	DeliveryID     uint      `gorm:"not null;index" json:"delivery_id"`
+	Attempt        int       `gorm:"not null" json:"attempt"`
+	ResponseStatus *int      `json:"response_status"`
+	ResponseBody   string    `gorm:"type:text" json:"response_body,omitempty"` // truncated
+	Error          string    `gorm:"size:1024" json:"error,omitempty"`
+	DurationMs     int64     `json:"duration_ms"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// WebhookEventTypeList is stored as a JSON array of event types
+type WebhookEventTypeList []WebhookEventType
+
+// Contains reports whether the list includes the event type
+func (l WebhookEventTypeList) Contains(eventType WebhookEventType) bool {
+	for _, t := range l {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Value implements driver.Valuer
+func (l WebhookEventTypeList) Value() (driver.Value, error) {
+	if l == nil {
+		l = WebhookEventTypeList{}
+	}
+	b, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner
+func (l *WebhookEventTypeList) Scan(value interface{}) error {
+	data, err := scanJSONBytes(value)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		*l = nil
+		return nil
+	}
+	return json.Unmarshal(data, l)
+}
+
+// JSONPayload is a JSON document stored as jsonb and rendered as-is in API responses
+type JSONPayload []byte
+
+// MarshalJSON implements json.Marshaler
+func (p JSONPayload) MarshalJSON() ([]byte, error) {
+	if len(p) == 0 {
+		return []byte("null"), nil
+	}
+	return p, nil
+}
+
+// Value implements driver.Valuer
+func (p JSONPayload) Value() (driver.Value, error) {
+	if len(p) == 0 {
+		return nil, nil
+	}
+	return string(p), nil
+}
+
+// Scan implements sql.Scanner
+func (p *JSONPayload) Scan(value interface{}) error {
+	data, err := scanJSONBytes(value)
+	if err != nil {
+		return err
+	}
+	*p = append((*p)[:0], data...)
+	return nil
+}
+
+func scanJSONBytes(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	default:
+		return nil, errors.New("unsupported type for JSON column")
+	}
+}
@@ -0,0 +1,25 @@
+package repositories
+
+import "github.com/juank/attendance-backend/internal/domain/models"
+
+type AttendanceCorrectionRepository interface {
+	Create(correction *models.AttendanceCorrection) error
+	GetByID(id uint) (*models.AttendanceCorrection, error)
+
+	// Resolve stores the new status and review fields of a correction only if it is still
+	// pending, and reports whether it was
+	Resolve(correction *models.AttendanceCorrection) (bool, error)
+	GetByRequesterID(requesterID uint, page, limit int) ([]models.AttendanceCorrection, int64, error)
+
+	// GetPending returns pending corrections, limited to requesters of the given departments when any are passed
+	GetPending(departmentIDs []uint) ([]models.AttendanceCorrection, error)
+
+	// HasPending reports whether an attendance record already has a pending correction
+	HasPending(attendanceID uint) (bool, error)
+
+	// Approve resolves the pending correction and stores the revision and the corrected attendance
+	// in one transaction. It reports false without writing anything when the correction is no
+	// longer pending
+	Approve(correction *models.AttendanceCorrection, attendance *models.Attendance, revision *models.AttendanceRevision) (bool, error)
+	GetRevisions(attendanceID uint) ([]models.AttendanceRevision, error)
+}
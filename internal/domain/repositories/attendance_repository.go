@@ -15,4 +15,18 @@ type AttendanceRepository interface {
 	GetLastAttendance(userID uint) (*models.Attendance, error)
 	GetByEventAndUser(eventID, userID uint) (*models.Attendance, error)
 	GetByEventID(eventID uint) ([]models.Attendance, error)
+	GetUserIDsByEventID(eventID uint) ([]uint, error)
+	// CreateBatch inserts the given records, skipping users that already have one for the event
+	CreateBatch(attendances []models.Attendance) (int64, error)
+	// GetByUserStatusInRange returns a user's records with the given status whose check-in falls in the range
+	GetByUserStatusInRange(userID uint, status models.AttendanceStatus, startDate, endDate time.Time) ([]models.Attendance, error)
+	// GetByDepartmentInRange returns the records of a department's members whose check-in falls in the range
+	GetByDepartmentInRange(departmentID uint, startDate, endDate time.Time) ([]models.Attendance, error)
+	// CountByDepartments counts records per department and status whose check-in falls in the range
+	CountByDepartments(departmentIDs []uint, startDate, endDate time.Time) ([]models.DepartmentStatusCount, error)
+	// CountByEvent counts the records of an event per status
+	CountByEvent(eventID uint) ([]models.StatusCount, error)
+	// StreamForExport calls fn for every record matching the filter, ordered by check-in,
+	// without loading the whole result in memory
+	StreamForExport(filter models.AttendanceExportFilter, fn func(row *models.AttendanceExportRow) error) error
 }
@@ -0,0 +1,12 @@
+package repositories
+
+import "github.com/juank/attendance-backend/internal/domain/models"
+
+// AuditLogRepository only appends and reads; audit entries are never modified
+type AuditLogRepository interface {
+	Create(entry *models.AuditLog) error
+	// GetByID returns an entry with its actor
+	GetByID(id uint) (*models.AuditLog, error)
+	// GetAll returns a page of the entries matching the filter with their actors, newest first
+	GetAll(filter models.AuditLogFilter, page, limit int) ([]models.AuditLog, int64, error)
+}
@@ -1,10 +1,32 @@
 package repositories
 
-import "github.com/juank/attendance-backend/internal/domain/models"
+import (
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+)
 
 type RefreshTokenRepository interface {
 	Create(token *models.RefreshToken) error
 	GetByToken(token string) (*models.RefreshToken, error)
 	Revoke(id uint) error
 	RevokeByUserID(userID uint) error
+
+	// Rotate revokes a token being exchanged for a new one and reports false if it was
+	// already revoked, which means it is being reused
+	Rotate(id uint, at time.Time) (bool, error)
+	// RevokeFamily revokes every token of a session
+	RevokeFamily(familyID string) error
+	// RevokeUserFamily revokes a session of the user and reports whether it was active
+	RevokeUserFamily(userID uint, familyID string, now time.Time) (bool, error)
+	// GetActiveByUserID returns the unrevoked, unexpired tokens of the user, newest first
+	GetActiveByUserID(userID uint, now time.Time) ([]models.RefreshToken, error)
+}
+
+type RevokedSessionRepository interface {
+	// Create records the revocation; revoking a session twice keeps the first record
+	Create(session *models.RevokedSession) error
+	// GetIDsByUserID returns the user's revoked sessions whose access tokens may still be valid
+	GetIDsByUserID(userID uint, now time.Time) ([]string, error)
+	DeleteExpired(now time.Time) error
 }
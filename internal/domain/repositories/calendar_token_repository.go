@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+)
+
+type CalendarTokenRepository interface {
+	GetByUserID(userID uint) (*models.CalendarToken, error)
+	GetByHash(tokenHash string) (*models.CalendarToken, error)
+
+	// Replace stores a new token for the user, replacing the previous one if any
+	Replace(token *models.CalendarToken) error
+	DeleteByUserID(userID uint) error
+	Touch(id uint, usedAt time.Time) error
+}
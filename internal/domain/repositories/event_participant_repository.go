@@ -0,0 +1,16 @@
+package repositories
+
+import "github.com/juank/attendance-backend/internal/domain/models"
+
+type EventParticipantRepository interface {
+	Create(participant *models.EventParticipant) error
+	GetByID(id uint) (*models.EventParticipant, error)
+	GetByEventID(eventID uint) ([]models.EventParticipant, error)
+	Delete(id uint) error
+
+	// GetByEventIDs returns the roster entries of several events at once
+	GetByEventIDs(eventIDs []uint) ([]models.EventParticipant, error)
+
+	// GetUsers resolves the roster of an event into the active users it covers
+	GetUsers(eventID uint) ([]models.User, error)
+}
@@ -1,11 +1,37 @@
 package repositories
 
-import "github.com/juank/attendance-backend/internal/domain/models"
+import (
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+)
 
 type EventRepository interface {
 	Create(event *models.Event) error
 	GetByID(id uint) (*models.Event, error)
 	Update(event *models.Event) error
-	Delete(id uint) error
+	Delete(id uint) error // also removes the event's roster
 	GetAll() ([]models.Event, error)
+
+	// GetPendingClosure returns events that ended or were deactivated but are not closed yet
+	GetPendingClosure(now time.Time) ([]models.Event, error)
+
+	// MarkClosed stamps the closing time of an event
+	MarkClosed(id uint, closedAt time.Time) error
+
+	// UpdateQRSecret replaces the secret used to derive dynamic QR tokens
+	UpdateQRSecret(id uint, secret string) error
+
+	// GetBySeriesID returns the occurrences of a series starting at or after from, in order
+	GetBySeriesID(seriesID uint, from time.Time) ([]models.Event, error)
+
+	// GetLastInSeries returns the occurrence of a series with the latest start time
+	GetLastInSeries(seriesID uint) (*models.Event, error)
+
+	// GetStartingFrom returns the events starting at or after from, in order
+	GetStartingFrom(from time.Time) ([]models.Event, error)
+
+	// CreateOccurrence inserts an occurrence and its roster unless the series already has
+	// one for its date
+	CreateOccurrence(event *models.Event, roster []models.EventParticipant) (bool, error)
 }
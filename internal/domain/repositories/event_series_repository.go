@@ -0,0 +1,18 @@
+package repositories
+
+import "github.com/juank/attendance-backend/internal/domain/models"
+
+type EventSeriesRepository interface {
+	Create(series *models.EventSeries) error
+	GetByID(id uint) (*models.EventSeries, error)
+	GetAll() ([]models.EventSeries, error)
+	Update(series *models.EventSeries) error
+
+	// SkipOccurrences saves the series, with its new exception dates, and deletes the
+	// given occurrences in one transaction
+	SkipOccurrences(series *models.EventSeries, eventIDs []uint) error
+
+	// Delete removes the series in one transaction with the occurrences in removed, and
+	// detaches those in detached so they remain as standalone events
+	Delete(id uint, removed, detached []uint) error
+}
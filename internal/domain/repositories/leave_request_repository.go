@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+)
+
+type LeaveRequestRepository interface {
+	Create(leave *models.LeaveRequest) error
+	GetByID(id uint) (*models.LeaveRequest, error)
+	Update(leave *models.LeaveRequest) error
+
+	// Review stores the decision on a pending request together with the attendance records it
+	// changes and their revisions, in one transaction. It reports false without writing anything
+	// when the request is no longer pending
+	Review(leave *models.LeaveRequest, attendances []models.Attendance, revisions []models.AttendanceRevision) (bool, error)
+	GetByUserID(userID uint, page, limit int) ([]models.LeaveRequest, int64, error)
+
+	// GetPending returns pending requests, limited to users of the given departments when any are passed
+	GetPending(departmentIDs []uint) ([]models.LeaveRequest, error)
+
+	// GetApprovedOverlapping returns approved leave of the given users overlapping [from, to]
+	GetApprovedOverlapping(userIDs []uint, from, to time.Time) ([]models.LeaveRequest, error)
+
+	// HasOverlapping reports whether a user already has pending or approved leave overlapping [from, to]
+	HasOverlapping(userID uint, from, to time.Time) (bool, error)
+}
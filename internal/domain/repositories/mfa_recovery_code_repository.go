@@ -0,0 +1,12 @@
+package repositories
+
+import "time"
+
+type MFARecoveryCodeRepository interface {
+	// Replace deletes the user's codes and stores the given hashes in their place
+	Replace(userID uint, codeHashes []string) error
+	// Use consumes an unused code and reports whether there was one with that hash
+	Use(userID uint, codeHash string, at time.Time) (bool, error)
+	CountUnused(userID uint) (int64, error)
+	DeleteByUserID(userID uint) error
+}
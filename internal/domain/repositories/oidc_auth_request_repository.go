@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+)
+
+type OIDCAuthRequestRepository interface {
+	Create(request *models.OIDCAuthRequest) error
+	// Consume deletes and returns the unexpired request with the state hash, so that each
+	// state can complete one login only
+	Consume(stateHash string, now time.Time) (*models.OIDCAuthRequest, error)
+	DeleteExpired(now time.Time) error
+}
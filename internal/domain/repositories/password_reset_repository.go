@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+)
+
+type PasswordResetTokenRepository interface {
+	Create(token *models.PasswordResetToken) error
+	GetByHash(tokenHash string) (*models.PasswordResetToken, error)
+	// GetLatestByUserID returns the user's most recently issued token
+	GetLatestByUserID(userID uint) (*models.PasswordResetToken, error)
+
+	// Redeem consumes the token and, in the same transaction, sets the user's new password,
+	// clears their failed logins, bumps their token version and revokes their refresh tokens
+	// and other reset tokens. It reports whether the token was still unused, so that two
+	// concurrent resets cannot both succeed.
+	Redeem(token *models.PasswordResetToken, passwordHash string, at time.Time) (bool, error)
+	// InvalidateByUserID consumes every unused token of the user
+	InvalidateByUserID(userID uint, at time.Time) error
+}
@@ -0,0 +1,14 @@
+package repositories
+
+import "github.com/juank/attendance-backend/internal/domain/models"
+
+type RoleRepository interface {
+	Create(role *models.RoleDefinition) error
+	GetByID(id uint) (*models.RoleDefinition, error)
+	GetByName(name models.Role) (*models.RoleDefinition, error)
+	GetAll() ([]models.RoleDefinition, error)
+	Update(role *models.RoleDefinition) error
+	Delete(id uint) error
+	// CountUsers counts the users, deleted ones excluded, assigned to the role
+	CountUsers(name models.Role) (int64, error)
+}
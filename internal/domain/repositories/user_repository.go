@@ -1,12 +1,51 @@
 package repositories
 
-import "github.com/juank/attendance-backend/internal/domain/models"
+import (
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+)
 
 type UserRepository interface {
 	Create(user *models.User) error
 	GetByID(id uint) (*models.User, error)
-	GetByEmail(email string) (*models.User, error)
+	GetByEmail(email string) (*models.User, error) // case-insensitive
+	GetByOIDCSubject(subject string) (*models.User, error)
 	Update(user *models.User) error
 	Delete(id uint) error
 	GetAll(page, limit int) ([]models.User, int64, error)
+	GetActive() ([]models.User, error)
+
+	// RecordLoginFailure increments the failed login counter and returns its new value.
+	// Failures older than since are forgotten first.
+	RecordLoginFailure(id uint, at, since time.Time) (int, error)
+	// Lock rejects logins for the user until the given time
+	Lock(id uint, until time.Time) error
+	// ResetLoginFailures clears the failed login counter and any lock
+	ResetLoginFailures(id uint) error
+
+	// SetMFA stores the user's TOTP secret and whether it is active; an empty secret
+	// removes two-factor authentication
+	SetMFA(id uint, secret string, enabled bool) error
+	// UseTOTPCounter records the time window of an accepted code and reports false if that
+	// window, or a later one, was already used
+	UseTOTPCounter(id uint, counter int64) (bool, error)
+
+	// IncrementTokenVersion revokes the user's access tokens
+	IncrementTokenVersion(id uint) error
+	// GetTokenVersion returns the user's token version and whether the user may use the
+	// API; deleted users are reported as inactive
+	GetTokenVersion(id uint) (version int, active bool, err error)
+
+	// SetOIDCSubject links the user to their single sign-on account
+	SetOIDCSubject(id uint, subject string) error
+
+	// GetExistingEmails returns which of the given emails are already taken, deleted users included.
+	// The comparison is case-insensitive and the result is lowercased.
+	GetExistingEmails(emails []string) ([]string, error)
+
+	// Import creates the departments and then the users in a single transaction. Users whose
+	// Department points to one of the new departments get its ID. With dryRun the transaction
+	// is rolled back after all inserts succeed.
+	Import(departments []*models.Department, users []*models.User, dryRun bool) error
 }
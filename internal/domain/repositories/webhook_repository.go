@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+)
+
+type WebhookRepository interface {
+	Create(webhook *models.Webhook) error
+	GetByID(id uint) (*models.Webhook, error)
+	GetAll() ([]models.Webhook, error)
+	Update(webhook *models.Webhook) error
+	Delete(id uint) error
+
+	// GetActiveByEventType returns the active webhooks subscribed to an event type
+	GetActiveByEventType(eventType models.WebhookEventType) ([]models.Webhook, error)
+}
+
+type WebhookDeliveryRepository interface {
+	CreateBatch(deliveries []models.WebhookDelivery) error
+	// GetByID returns a delivery with its attempts
+	GetByID(id uint) (*models.WebhookDelivery, error)
+	// GetByWebhookID returns a page of the deliveries of a webhook, newest first. An empty status returns all.
+	GetByWebhookID(webhookID uint, status models.WebhookDeliveryStatus, page, limit int) ([]models.WebhookDelivery, int64, error)
+	Update(delivery *models.WebhookDelivery) error
+
+	// ClaimDue locks up to limit pending deliveries due at now and pushes their next attempt
+	// lease into the future, so concurrent dispatchers don't send them twice. The deliveries
+	// are returned with their webhook, deleted webhooks included.
+	ClaimDue(now time.Time, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+
+	// RecordAttempt saves the outcome of an attempt together with the updated delivery
+	RecordAttempt(delivery *models.WebhookDelivery, attempt *models.WebhookDeliveryAttempt) error
+}
@@ -7,10 +7,17 @@ import (
 )
 
 type MarkAttendanceRequest struct {
-	UserID   uint   `json:"user_id" validate:"required"`
-	QRToken  string `json:"qr_token" validate:"required"`
-	Location string `json:"location"`
-	Notes    string `json:"notes"`
+	UserID    uint     `json:"user_id" validate:"required"`
+	QRToken   string   `json:"qr_token" validate:"required"`
+	Location  string   `json:"location"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	Notes     string   `json:"notes"`
+}
+
+type CheckOutRequest struct {
+	UserID  uint   `json:"user_id" validate:"required"`
+	QRToken string `json:"qr_token" validate:"required"`
 }
 
 type AttendanceService interface {
@@ -20,5 +27,12 @@ type AttendanceService interface {
 	GetTodayAttendance(userID uint) (*models.Attendance, error)
 	GetByDateRange(userID uint, startDate, endDate time.Time) ([]models.Attendance, error)
 	GetEventAttendance(eventID uint) ([]models.Attendance, error)
-	MarkManualAttendance(eventID, userID uint, notes string) (*models.Attendance, error)
+	MarkManualAttendance(actor *Actor, eventID, userID uint, notes string) (*models.Attendance, error)
+	CheckOut(req *CheckOutRequest) (*models.Attendance, error)
+	MarkManualCheckOut(actor *Actor, eventID, userID uint) (*models.Attendance, error)
+
+	// MaterializeAbsences records an absence (or on_leave, for approved leave) for every expected
+	// participant without attendance and marks the event as closed. It returns the number of
+	// records created.
+	MaterializeAbsences(eventID uint) (int64, error)
 }
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+)
+
+// ErrInvalidAuditQuery is returned when the filters of an audit log query are malformed
+var ErrInvalidAuditQuery = errors.New("invalid audit log query")
+
+// Actor identifies who performs an operation and from where. Services use the role for
+// authorization and record the whole actor in the audit log. UserID is zero for anonymous
+// operations such as self-registration.
+type Actor struct {
+	UserID    uint
+	Role      models.Role
+	IP        string
+	UserAgent string
+}
+
+// AuditLogQuery holds the filters of the audit log endpoint. From and To accept a date
+// (YYYY-MM-DD, both days included) or an RFC 3339 timestamp.
+type AuditLogQuery struct {
+	ActorID    uint               `form:"actor_id"`
+	Action     models.AuditAction `form:"action"`
+	TargetType models.AuditTarget `form:"target_type"`
+	TargetID   uint               `form:"target_id"`
+	From       string             `form:"from"`
+	To         string             `form:"to"`
+	Page       int                `form:"page"`
+	Limit      int                `form:"limit"`
+}
+
+// AuditRecorder appends entries to the audit log
+type AuditRecorder interface {
+	// Record stores that actor performed action on a target, with the fields that differ
+	// between before and after. before is nil for creations and after for deletions.
+	// Failures are logged and not returned, so auditing never breaks the audited operation.
+	Record(actor *Actor, action models.AuditAction, target models.AuditTarget, targetID uint, before, after interface{})
+}
+
+type AuditService interface {
+	AuditRecorder
+
+	GetByID(id uint) (*models.AuditLog, error)
+	// GetAll returns a page of the entries matching the query, newest first. Out of range
+	// page and limit values are corrected in query.
+	GetAll(query *AuditLogQuery) ([]models.AuditLog, int64, error)
+}
@@ -1,6 +1,12 @@
 package services
 
-import "github.com/juank/attendance-backend/internal/domain/models"
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+)
 
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
@@ -14,14 +20,164 @@ type RegisterRequest struct {
 	LastName  string `json:"last_name" validate:"required"`
 }
 
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// TokenResponse carries the session tokens. When a second factor is needed, Login returns
+// a challenge token instead, with MFARequired or MFAEnrollmentRequired set.
 type TokenResponse struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+
+	MFARequired           bool   `json:"mfa_required,omitempty"`
+	MFAEnrollmentRequired bool   `json:"mfa_enrollment_required,omitempty"`
+	ChallengeToken        string `json:"challenge_token,omitempty"`
+
+	// RecoveryCodes is set once, when enrollment completes during login
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+// Session is a signed-in device. It lasts from login until logout, revocation or the
+// expiry of its refresh token, across refreshes.
+type Session struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"` // e.g. "Chrome on Windows", from the user agent
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"` // of the last login or refresh
+	SignedInAt time.Time `json:"signed_in_at"`
+	LastUsedAt time.Time `json:"last_used_at"` // last login or refresh
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"` // the session of the access token making the request
+}
+
+// ErrMFA is wrapped by errors caused by a wrong, reused or missing second factor
+var ErrMFA = errors.New("two-factor authentication failed")
+
+type MFAChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+}
+
+type MFAVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"` // TOTP code or recovery code
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type DisableMFARequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"` // TOTP code or recovery code
+}
+
+// MFASetup is what an authenticator app needs to generate codes for the account
+type MFASetup struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type MFAStatus struct {
+	Enabled             bool  `json:"enabled"`
+	Required            bool  `json:"required"` // by the user's role
+	RecoveryCodesUnused int64 `json:"recovery_codes_unused"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// Single sign-on errors. ErrOIDC wraps rejected logins: an unknown or expired state, a
+// code or ID token the identity provider did not validate, or an identity that cannot be
+// matched to a user.
+var (
+	ErrOIDCDisabled = errors.New("single sign-on is not configured")
+	ErrOIDCProvider = errors.New("identity provider unavailable")
+	ErrOIDC         = errors.New("single sign-on failed")
+)
+
+// OIDCAuthorization starts a single sign-on login. The client keeps the state, sends the
+// user to AuthorizationURL and, when the provider redirects back, checks that the state
+// it receives is the same before calling the callback endpoint.
+type OIDCAuthorization struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+}
+
+// OIDCCallbackRequest carries the query parameters of the identity provider's redirect
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+// LoginThrottledError is returned by Login while the account or the client's IP must wait
+// after failed attempts, or is locked out
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return "too many failed login attempts"
 }
 
 type AuthService interface {
-	Register(req *RegisterRequest) (*models.User, error)
-	Login(req *LoginRequest) (*TokenResponse, error)
-	RefreshToken(token string) (*TokenResponse, error)
+	// Register creates an employee account; client carries the IP and user agent of the request
+	Register(client *Actor, req *RegisterRequest) (*models.User, error)
+	// Login tracks failures per account and per client IP. Repeated failures delay the next
+	// attempt and eventually lock the account, returning a *LoginThrottledError.
+	Login(client *Actor, req *LoginRequest) (*TokenResponse, error)
+	// RefreshToken rotates the refresh token. Presenting a token that was already rotated
+	// revokes its whole session, since only a copy of it can be presented twice.
+	RefreshToken(client *Actor, token string) (*TokenResponse, error)
 	Logout(token string) error
+
+	// BeginOIDCLogin prepares a single sign-on login with the authorization code flow and PKCE
+	BeginOIDCLogin(ctx context.Context) (*OIDCAuthorization, error)
+	// CompleteOIDCLogin signs in the owner of the identity provider account. The account is
+	// linked on first use to the user with the same email, if the provider verified it, or
+	// a user is created. The user's role and department follow their provider groups when
+	// the mappings are configured. Users with MFA still get a challenge, as in Login.
+	CompleteOIDCLogin(ctx context.Context, client *Actor, req *OIDCCallbackRequest) (*TokenResponse, error)
+
+	// ListSessions returns the user's active sessions; currentSessionID marks the caller's
+	ListSessions(userID uint, currentSessionID string) ([]Session, error)
+	// RevokeSession signs the actor out of one of their own sessions
+	RevokeSession(actor *Actor, sessionID string) error
+	// RevokeAllSessions signs a user out of every session
+	RevokeAllSessions(actor *Actor, userID uint) error
+
+	// ForgotPassword emails a single-use reset link to the account, if it exists and is
+	// active. It works in the background and reports nothing, so callers cannot tell
+	// whether the email is registered.
+	ForgotPassword(client *Actor, req *ForgotPasswordRequest)
+	// ResetPassword sets a new password with a token from ForgotPassword, lifts any login
+	// lockout and revokes every refresh token of the user
+	ResetPassword(client *Actor, req *ResetPasswordRequest) error
+
+	// VerifyMFA completes a login that returned MFARequired, with a TOTP or recovery code
+	VerifyMFA(client *Actor, req *MFAVerifyRequest) (*TokenResponse, error)
+	// BeginMFAEnrollment and CompleteMFAEnrollment enroll, during login, a user whose role
+	// requires two-factor authentication; the challenge comes from a login that returned
+	// MFAEnrollmentRequired. Completing it signs the user in and returns the recovery codes.
+	BeginMFAEnrollment(client *Actor, req *MFAChallengeRequest) (*MFASetup, error)
+	CompleteMFAEnrollment(client *Actor, req *MFAVerifyRequest) (*TokenResponse, error)
+
+	GetMFAStatus(actor *Actor) (*MFAStatus, error)
+	// SetupMFA generates a new secret for the signed-in user, which ActivateMFA enables once
+	// the user proves their app produces its codes
+	SetupMFA(actor *Actor) (*MFASetup, error)
+	ActivateMFA(actor *Actor, req *MFACodeRequest) (*RecoveryCodesResponse, error)
+	// DisableMFA is refused when the user's role requires two-factor authentication
+	DisableMFA(actor *Actor, req *DisableMFARequest) error
+	// RegenerateRecoveryCodes replaces every recovery code of the signed-in user
+	RegenerateRecoveryCodes(actor *Actor, req *MFACodeRequest) (*RecoveryCodesResponse, error)
+	// ResetMFA removes two-factor authentication from a user who lost their device; they
+	// must enroll again at their next login if their role requires it
+	ResetMFA(actor *Actor, userID uint) error
 }
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/pkg/ical"
+)
+
+type CalendarService interface {
+	// GetToken returns the metadata of the user's feed token
+	GetToken(userID uint) (*models.CalendarToken, error)
+	// RotateToken issues a new feed token and invalidates the previous one. The plain
+	// token is only returned here; afterwards just its hash is kept.
+	RotateToken(actor *Actor) (string, *models.CalendarToken, error)
+	RevokeToken(actor *Actor) error
+
+	// Authenticate resolves a feed token to its user, who must still be active
+	Authenticate(token string) (*models.User, error)
+
+	// GetUserFeed builds the iCalendar feed with the recent and upcoming events visible to the user
+	GetUserFeed(user *models.User) (*ical.Calendar, error)
+	// GetEventCalendar builds the .ics of a single event, or of its whole series with wholeSeries
+	GetEventCalendar(user *models.User, eventID uint, wholeSeries bool) (*ical.Calendar, error)
+}
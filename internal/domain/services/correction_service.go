@@ -0,0 +1,37 @@
+package services
+
+import (
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+)
+
+type CreateCorrectionRequest struct {
+	Status   *models.AttendanceStatus `json:"status"`
+	CheckIn  *time.Time               `json:"check_in"`
+	CheckOut *time.Time               `json:"check_out"`
+	Reason   string                   `json:"reason" binding:"required"`
+}
+
+type ReviewCorrectionRequest struct {
+	Note string `json:"note"`
+}
+
+type CorrectionService interface {
+	// Create files a correction request against one of the user's own attendance records
+	Create(actor *Actor, attendanceID uint, req *CreateCorrectionRequest) (*models.AttendanceCorrection, error)
+	GetUserCorrections(userID uint, page, limit int) ([]models.AttendanceCorrection, int64, error)
+	Cancel(actor *Actor, id uint) (*models.AttendanceCorrection, error)
+
+	// GetPendingForReviewer returns all pending corrections for admins, and those of the
+	// departments they manage for managers
+	GetPendingForReviewer(reviewerID uint, role models.Role) ([]models.AttendanceCorrection, error)
+
+	// Approve applies the correction to the attendance record, keeping its previous values as a revision
+	Approve(actor *Actor, id uint, req *ReviewCorrectionRequest) (*models.AttendanceCorrection, error)
+	Reject(actor *Actor, id uint, req *ReviewCorrectionRequest) (*models.AttendanceCorrection, error)
+
+	// GetHistory returns the revisions of an attendance record, visible to its owner,
+	// the owner's department manager and admins
+	GetHistory(attendanceID, viewerID uint, role models.Role) ([]models.AttendanceRevision, error)
+}
@@ -15,9 +15,9 @@ type UpdateDepartmentRequest struct {
 }
 
 type DepartmentService interface {
-	Create(req *CreateDepartmentRequest) (*models.Department, error)
+	Create(actor *Actor, req *CreateDepartmentRequest) (*models.Department, error)
 	GetByID(id uint) (*models.Department, error)
 	GetAll() ([]models.Department, error)
-	Update(id uint, req *UpdateDepartmentRequest) (*models.Department, error)
-	Delete(id uint) error
+	Update(actor *Actor, id uint, req *UpdateDepartmentRequest) (*models.Department, error)
+	Delete(actor *Actor, id uint) error
 }
@@ -0,0 +1,22 @@
+package services
+
+import "github.com/juank/attendance-backend/internal/domain/models"
+
+type AddParticipantsRequest struct {
+	UserIDs       []uint        `json:"user_ids"`
+	DepartmentIDs []uint        `json:"department_ids"`
+	Roles         []models.Role `json:"roles"`
+}
+
+type EventParticipantService interface {
+	// AddParticipants adds users, departments and roles to an event roster, skipping existing entries
+	AddParticipants(actor *Actor, eventID uint, req *AddParticipantsRequest) ([]models.EventParticipant, error)
+	GetParticipants(eventID uint) ([]models.EventParticipant, error)
+	RemoveParticipant(actor *Actor, eventID, participantID uint) error
+
+	// GetExpectedUsers resolves the roster into the users expected to attend the event
+	GetExpectedUsers(eventID uint) ([]models.User, error)
+
+	// IsExpected reports whether a user is covered by the roster of an event
+	IsExpected(eventID, userID uint) (bool, error)
+}
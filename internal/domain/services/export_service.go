@@ -0,0 +1,29 @@
+package services
+
+import (
+	"io"
+	"time"
+)
+
+type ExportFormat string
+
+const (
+	ExportCSV  ExportFormat = "csv"
+	ExportXLSX ExportFormat = "xlsx"
+)
+
+// AttendanceExport is a validated export ready to be streamed
+type AttendanceExport struct {
+	FileName    string
+	ContentType string
+	// Write streams the file to w, reading the records from the database as it goes
+	Write func(w io.Writer) error
+}
+
+type ExportService interface {
+	ExportEventAttendance(eventID uint, format ExportFormat) (*AttendanceExport, error)
+	// ExportAttendanceRange exports the records of all events whose check-in falls in the inclusive date range
+	ExportAttendanceRange(startDate, endDate time.Time, format ExportFormat) (*AttendanceExport, error)
+	// ExportUserAttendance exports a user's records, optionally limited to an inclusive date range
+	ExportUserAttendance(userID uint, startDate, endDate *time.Time, format ExportFormat) (*AttendanceExport, error)
+}
@@ -0,0 +1,27 @@
+package services
+
+import "github.com/juank/attendance-backend/internal/domain/models"
+
+type CreateLeaveRequest struct {
+	Type      models.LeaveType `json:"type" binding:"required"`
+	StartDate string           `json:"start_date" binding:"required"` // YYYY-MM-DD
+	EndDate   string           `json:"end_date" binding:"required"`   // YYYY-MM-DD, inclusive
+	Reason    string           `json:"reason"`
+}
+
+type ReviewLeaveRequest struct {
+	Note string `json:"note"`
+}
+
+type LeaveService interface {
+	Create(actor *Actor, req *CreateLeaveRequest) (*models.LeaveRequest, error)
+	GetByID(id uint) (*models.LeaveRequest, error)
+	GetUserLeaves(userID uint, page, limit int) ([]models.LeaveRequest, int64, error)
+	Cancel(actor *Actor, id uint) (*models.LeaveRequest, error)
+
+	// GetPendingForReviewer returns all pending requests for admins, and those of the
+	// departments they manage for managers
+	GetPendingForReviewer(reviewerID uint, role models.Role) ([]models.LeaveRequest, error)
+	Approve(actor *Actor, id uint, req *ReviewLeaveRequest) (*models.LeaveRequest, error)
+	Reject(actor *Actor, id uint, req *ReviewLeaveRequest) (*models.LeaveRequest, error)
+}
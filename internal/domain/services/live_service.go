@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+)
+
+type LiveUpdateType string
+
+const (
+	// LiveSnapshot is the first update of a stream: current counts and QR code
+	LiveSnapshot LiveUpdateType = "snapshot"
+	// LiveAttendance is sent for every attendance marked by scan or manually
+	LiveAttendance LiveUpdateType = "attendance"
+	// LiveQRRotated is sent when the code to display changes
+	LiveQRRotated LiveUpdateType = "qr_rotated"
+	// LiveHeartbeat keeps idle connections open through proxies; it carries no data
+	LiveHeartbeat LiveUpdateType = "heartbeat"
+)
+
+// LiveUpdate is a message of the live stream of an event
+type LiveUpdate struct {
+	Type LiveUpdateType `json:"type"`
+	Data interface{}    `json:"data,omitempty"`
+}
+
+// LiveSnapshotData is the payload of snapshot
+type LiveSnapshotData struct {
+	EventID uint                    `json:"event_id"`
+	Title   string                  `json:"title"`
+	Counts  models.AttendanceCounts `json:"counts"`
+	QR      *models.QRCode          `json:"qr"`
+}
+
+// LiveAttendanceData is the payload of attendance; counts already include the new record
+type LiveAttendanceData struct {
+	Attendance *models.Attendance      `json:"attendance"`
+	Counts     models.AttendanceCounts `json:"counts"`
+}
+
+// LivePublisher pushes changes to the clients watching an event. Publishing never fails
+// the operation that triggered it, and does nothing when nobody is watching.
+type LivePublisher interface {
+	PublishAttendance(attendance *models.Attendance)
+	PublishQRRotated(qr *models.QRCode)
+}
+
+type LiveService interface {
+	// Watch streams the updates of an event to send, starting with a snapshot, until ctx
+	// is done or send fails. It never creates QR codes: it forwards the ones issued elsewhere
+	// and, for dynamic codes, moves to the next window when the current one expires.
+	// Errors before the snapshot is sent mean the stream could not start.
+	Watch(ctx context.Context, eventID uint, send func(update *LiveUpdate) error) error
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+)
+
+// ErrInvalidQRImage is returned when the rendering options are not valid
+var ErrInvalidQRImage = errors.New("invalid QR image options")
+
+type QRImageFormat string
+
+const (
+	QRImagePNG QRImageFormat = "png"
+	QRImageSVG QRImageFormat = "svg"
+)
+
+type QRImageOptions struct {
+	Size    int    `form:"size"`    // width in pixels, 64 to 2048 (default 256)
+	Level   string `form:"level"`   // error correction: L, M (default), Q or H
+	Caption bool   `form:"caption"` // print the event title under the code
+}
+
+// QRImage is a rendered QR code along with the code it encodes
+type QRImage struct {
+	Content     []byte
+	ContentType string
+	QR          *models.QRCode
+}
+
+type QRImageService interface {
+	// RenderActive renders the active QR code of an event, creating one if needed
+	RenderActive(eventID uint, format QRImageFormat, opts *QRImageOptions) (*QRImage, error)
+	// RenderPoster renders a printable A4 PDF for a static-QR event, with a code that
+	// stays valid until the event ends
+	RenderPoster(eventID uint) (*QRImage, error)
+}
@@ -6,12 +6,20 @@ type QRService interface {
 	// GetOrCreateActive returns the active QR code for an event or creates a new one
 	GetOrCreateActive(eventID uint) (*models.QRCode, error)
 
+	// GetActive returns the QR code currently valid for an event without creating one; it is
+	// nil when a static event has no active code
+	GetActive(eventID uint) (*models.QRCode, error)
+
 	// GenerateNew generates a new QR code for an event and deactivates all previous ones
-	GenerateNew(eventID uint) (*models.QRCode, error)
+	GenerateNew(actor *Actor, eventID uint) (*models.QRCode, error)
 
 	// ValidateToken validates a QR token and returns true if valid
 	ValidateToken(token string) (*models.QRCode, error)
 
+	// GetOrCreatePrintable returns a static QR code valid until the event ends, replacing
+	// the active one if it expires earlier. Dynamic events cannot be printed.
+	GetOrCreatePrintable(eventID uint) (*models.QRCode, error)
+
 	// DeactivateActiveForEvent deactivates the current active QR code for an event
-	DeactivateActiveForEvent(eventID uint) error
+	DeactivateActiveForEvent(actor *Actor, eventID uint) error
 }
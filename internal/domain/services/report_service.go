@@ -0,0 +1,17 @@
+package services
+
+import (
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+)
+
+type ReportService interface {
+	// GetDepartmentSummaries returns the status totals of every department the viewer can
+	// report on: all departments for admins, the ones they manage for managers.
+	// from and to are calendar days and both are inclusive.
+	GetDepartmentSummaries(viewerID uint, role models.Role, from, to time.Time) ([]models.DepartmentSummary, error)
+
+	// GetDepartmentReport breaks down a department's attendance per user, per event and per week
+	GetDepartmentReport(departmentID, viewerID uint, role models.Role, from, to time.Time) (*models.DepartmentReport, error)
+}
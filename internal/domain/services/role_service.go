@@ -0,0 +1,50 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+)
+
+// ErrInvalidRole is returned when a role name or its permissions fail validation
+var ErrInvalidRole = errors.New("invalid role")
+
+type CreateRoleRequest struct {
+	Name        models.Role         `json:"name" binding:"required"` // lowercase letters, digits and underscores
+	Description string              `json:"description"`
+	Permissions []models.Permission `json:"permissions"`
+	RequireMFA  bool                `json:"require_mfa"`
+}
+
+type UpdateRoleRequest struct {
+	Description *string             `json:"description"`
+	Permissions []models.Permission `json:"permissions"` // replaces the current list when present
+	RequireMFA  *bool               `json:"require_mfa"`
+}
+
+// Authorizer answers permission checks from the roles stored in the database
+type Authorizer interface {
+	// HasPermission reports whether the role grants the permission. Unknown roles grant nothing.
+	HasPermission(role models.Role, permission models.Permission) bool
+	// CanAssign reports whether a user with actorRole may give role to someone, which
+	// requires holding every permission the role grants
+	CanAssign(actorRole, role models.Role) bool
+	// Exists reports whether the role is defined
+	Exists(role models.Role) bool
+	// RequiresMFA reports whether users with the role must use two-factor authentication
+	RequiresMFA(role models.Role) bool
+}
+
+type RoleService interface {
+	Authorizer
+
+	GetAll() ([]models.RoleDefinition, error)
+	GetByID(id uint) (*models.RoleDefinition, error)
+	// GetPermissions returns the effective permissions of a role
+	GetPermissions(role models.Role) (models.PermissionList, error)
+	// Create defines a custom role; actors cannot grant permissions they do not hold
+	Create(actor *Actor, req *CreateRoleRequest) (*models.RoleDefinition, error)
+	Update(actor *Actor, id uint, req *UpdateRoleRequest) (*models.RoleDefinition, error)
+	// Delete removes a custom role that no user is assigned to
+	Delete(actor *Actor, id uint) error
+}
@@ -0,0 +1,22 @@
+package services
+
+import "errors"
+
+// ErrTokenRevoked is returned for access tokens that are valid but no longer honored
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// TokenRevocation ends access tokens before they expire. A user's tokens are revoked
+// together by raising their token version, and a session's by recording it as revoked.
+type TokenRevocation interface {
+	// Check returns ErrTokenRevoked when the user is inactive or gone, the token's version
+	// is behind the user's, or its session was revoked
+	Check(userID uint, tokenVersion int, sessionID string) error
+	// RevokeUser revokes every access token of the user. Tokens issued afterwards, by login
+	// or refresh, carry the new version and the user's current role.
+	RevokeUser(userID uint) error
+	// RevokeSession revokes the access tokens of one session
+	RevokeSession(userID uint, sessionID string) error
+	// Forget drops what is cached about the user, after changes Check depends on, such as
+	// reactivation
+	Forget(userID uint)
+}
@@ -1,20 +1,25 @@
 package services
 
-import "github.com/juank/attendance-backend/internal/domain/models"
+import (
+	"errors"
+	"io"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+)
 
 type CreateUserRequest struct {
 	Email        string      `json:"email" validate:"required,email"`
 	Password     string      `json:"password" validate:"required,min=6"`
 	FirstName    string      `json:"first_name" validate:"required"`
 	LastName     string      `json:"last_name" validate:"required"`
-	Role         models.Role `json:"role" validate:"required,oneof=admin manager employee"`
+	Role         models.Role `json:"role"` // defaults to employee
 	DepartmentID *uint       `json:"department_id"`
 }
 
 type UpdateUserRequest struct {
 	FirstName    string       `json:"first_name"`
 	LastName     string       `json:"last_name"`
-	Role         *models.Role `json:"role" validate:"omitempty,oneof=admin manager employee"`
+	Role         *models.Role `json:"role"`
 	DepartmentID *uint        `json:"department_id"`
 	IsActive     *bool        `json:"is_active"`
 }
@@ -24,12 +29,68 @@ type ChangePasswordRequest struct {
 	NewPassword string `json:"new_password" validate:"required,min=6"`
 }
 
+var (
+	// ErrRoleNotAssignable is returned when the actor's role does not hold every permission
+	// of the role being assigned
+	ErrRoleNotAssignable = errors.New("cannot assign a role with permissions you do not have")
+	// ErrUserNotManageable is returned when the target user's role holds permissions the
+	// actor's role does not
+	ErrUserNotManageable = errors.New("cannot manage users with permissions you do not have")
+)
+
+// ErrInvalidImport is returned when the import file itself cannot be processed
+var ErrInvalidImport = errors.New("invalid import file")
+
+type ImportUsersOptions struct {
+	DryRun            bool   // validate and run the inserts, then roll back
+	CreateDepartments bool   // create departments missing from the database instead of rejecting the row
+	Actor             *Actor // user running the import; rows may only assign roles their role covers
+}
+
+type ImportRowStatus string
+
+const (
+	ImportRowCreated     ImportRowStatus = "created"
+	ImportRowWouldCreate ImportRowStatus = "would_create"
+	ImportRowInvalid     ImportRowStatus = "invalid"
+	ImportRowSkipped     ImportRowStatus = "skipped" // valid, but not imported because other rows failed
+)
+
+type ImportRowResult struct {
+	Line              int             `json:"line"` // line in the CSV file, the header is line 1
+	Email             string          `json:"email"`
+	Status            ImportRowStatus `json:"status"`
+	Errors            []string        `json:"errors,omitempty"`
+	UserID            *uint           `json:"user_id,omitempty"`
+	Department        string          `json:"department,omitempty"`
+	TemporaryPassword string          `json:"temporary_password,omitempty"` // only when the file had no password
+}
+
+type ImportUsersResult struct {
+	DryRun             bool              `json:"dry_run"`
+	Imported           bool              `json:"imported"`
+	Total              int               `json:"total"`
+	Valid              int               `json:"valid"`
+	Invalid            int               `json:"invalid"`
+	CreatedDepartments []string          `json:"created_departments"`
+	Rows               []ImportRowResult `json:"rows"`
+}
+
 type UserService interface {
-	Create(req *CreateUserRequest) (*models.User, error)
+	// Create, Update and Delete take the user performing the change, whose role must
+	// cover the roles involved
+	Create(actor *Actor, req *CreateUserRequest) (*models.User, error)
 	GetByID(id uint) (*models.User, error)
 	GetByEmail(email string) (*models.User, error)
-	Update(id uint, req *UpdateUserRequest) (*models.User, error)
-	Delete(id uint) error
+	Update(actor *Actor, id uint, req *UpdateUserRequest) (*models.User, error)
+	Delete(actor *Actor, id uint) error
+	// Unlock lifts a login lockout and clears the failed attempts
+	Unlock(actor *Actor, id uint) (*models.User, error)
 	GetAll(page, limit int) ([]models.User, int64, error)
-	ChangePassword(userID uint, req *ChangePasswordRequest) error
+	ChangePassword(actor *Actor, req *ChangePasswordRequest) error
+
+	// ImportUsers creates users from a CSV with the columns email, first_name, last_name and
+	// optionally role, department and password. Every row is validated first and nothing is
+	// written unless all of them are valid.
+	ImportUsers(r io.Reader, opts ImportUsersOptions) (*ImportUsersResult, error)
 }
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+)
+
+// ErrInvalidWebhook is returned when a webhook registration fails validation
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+type CreateWebhookRequest struct {
+	URL         string                    `json:"url" binding:"required"`
+	Description string                    `json:"description"`
+	EventTypes  []models.WebhookEventType `json:"event_types" binding:"required"`
+	Secret      string                    `json:"secret"` // generated when empty
+}
+
+type UpdateWebhookRequest struct {
+	URL         *string                   `json:"url"`
+	Description *string                   `json:"description"`
+	EventTypes  []models.WebhookEventType `json:"event_types"`
+	Secret      *string                   `json:"secret"`
+	IsActive    *bool                     `json:"is_active"`
+}
+
+// WebhookEnvelope is the JSON body of every delivery
+type WebhookEnvelope struct {
+	ID        string                  `json:"id"` // same for every webhook receiving the event
+	Type      models.WebhookEventType `json:"type"`
+	CreatedAt time.Time               `json:"created_at"`
+	Data      interface{}             `json:"data"`
+}
+
+// AttendanceCorrectedData is the payload of attendance.corrected
+type AttendanceCorrectedData struct {
+	CorrectionID uint                       `json:"correction_id"`
+	Attendance   *models.Attendance         `json:"attendance"`
+	Revision     *models.AttendanceRevision `json:"revision"`
+}
+
+// EventClosedData is the payload of event.closed
+type EventClosedData struct {
+	Event            *models.Event `json:"event"`
+	AbsencesRecorded int64         `json:"absences_recorded"`
+}
+
+// WebhookPublisher queues events for the webhooks subscribed to them
+type WebhookPublisher interface {
+	// Publish stores one delivery per subscribed webhook in the outbox. Failures are
+	// logged and not returned, so webhooks never break the operation that raised the event.
+	Publish(eventType models.WebhookEventType, data interface{})
+}
+
+type WebhookService interface {
+	WebhookPublisher
+
+	Create(actor *Actor, req *CreateWebhookRequest) (*models.Webhook, error)
+	GetByID(id uint) (*models.Webhook, error)
+	GetAll() ([]models.Webhook, error)
+	Update(actor *Actor, id uint, req *UpdateWebhookRequest) (*models.Webhook, error)
+	Delete(actor *Actor, id uint) error
+
+	// GetDeliveries returns a page of the delivery log of a webhook, optionally filtered by status
+	GetDeliveries(webhookID uint, status models.WebhookDeliveryStatus, page, limit int) ([]models.WebhookDelivery, int64, error)
+	// GetDelivery returns a delivery with every attempt made
+	GetDelivery(webhookID, deliveryID uint) (*models.WebhookDelivery, error)
+	// RetryDelivery queues a failed delivery for one more attempt
+	RetryDelivery(actor *Actor, webhookID, deliveryID uint) (*models.WebhookDelivery, error)
+
+	// DispatchDue sends the deliveries that are due and returns how many were attempted
+	DispatchDue(now time.Time) (int, error)
+}
@@ -0,0 +1,126 @@
+package persistence
+
+import (
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/repositories"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AttendanceCorrectionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewAttendanceCorrectionRepository(db *gorm.DB) repositories.AttendanceCorrectionRepository {
+	return &AttendanceCorrectionRepositoryImpl{db: db}
+}
+
+func (r *AttendanceCorrectionRepositoryImpl) Create(correction *models.AttendanceCorrection) error {
+	return r.db.Omit(clause.Associations).Create(correction).Error
+}
+
+func (r *AttendanceCorrectionRepositoryImpl) GetByID(id uint) (*models.AttendanceCorrection, error) {
+	var correction models.AttendanceCorrection
+	err := r.db.Preload("Attendance").Preload("Attendance.Event").Preload("Requester").Preload("Reviewer").
+		First(&correction, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &correction, nil
+}
+
+func (r *AttendanceCorrectionRepositoryImpl) Resolve(correction *models.AttendanceCorrection) (bool, error) {
+	return resolvePendingCorrection(r.db, correction)
+}
+
+func (r *AttendanceCorrectionRepositoryImpl) GetByRequesterID(requesterID uint, page, limit int) ([]models.AttendanceCorrection, int64, error) {
+	var corrections []models.AttendanceCorrection
+	var total int64
+
+	offset := (page - 1) * limit
+
+	if err := r.db.Model(&models.AttendanceCorrection{}).Where("requester_id = ?", requesterID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := r.db.Preload("Attendance").
+		Where("requester_id = ?", requesterID).
+		Order("created_at desc").
+		Offset(offset).Limit(limit).
+		Find(&corrections).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return corrections, total, nil
+}
+
+func (r *AttendanceCorrectionRepositoryImpl) GetPending(departmentIDs []uint) ([]models.AttendanceCorrection, error) {
+	var corrections []models.AttendanceCorrection
+	query := r.db.Preload("Attendance").Preload("Requester").Where("status = ?", models.CorrectionPending)
+	if len(departmentIDs) > 0 {
+		query = query.Where("requester_id IN (?)", r.db.Model(&models.User{}).Select("id").Where("department_id IN ?", departmentIDs))
+	}
+	if err := query.Order("created_at asc").Find(&corrections).Error; err != nil {
+		return nil, err
+	}
+	return corrections, nil
+}
+
+func (r *AttendanceCorrectionRepositoryImpl) HasPending(attendanceID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.AttendanceCorrection{}).
+		Where("attendance_id = ? AND status = ?", attendanceID, models.CorrectionPending).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *AttendanceCorrectionRepositoryImpl) Approve(correction *models.AttendanceCorrection, attendance *models.Attendance, revision *models.AttendanceRevision) (bool, error) {
+	approved := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		resolved, err := resolvePendingCorrection(tx, correction)
+		if err != nil || !resolved {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Create(revision).Error; err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Save(attendance).Error; err != nil {
+			return err
+		}
+		approved = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return approved, nil
+}
+
+func (r *AttendanceCorrectionRepositoryImpl) GetRevisions(attendanceID uint) ([]models.AttendanceRevision, error) {
+	var revisions []models.AttendanceRevision
+	if err := r.db.Preload("ChangedBy").Where("attendance_id = ?", attendanceID).Order("created_at asc").Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// resolvePendingCorrection stores the outcome of a correction only while it is still pending,
+// so two concurrent reviews or a review racing a cancellation cannot both win
+func resolvePendingCorrection(db *gorm.DB, correction *models.AttendanceCorrection) (bool, error) {
+	result := db.Model(&models.AttendanceCorrection{}).
+		Where("id = ? AND status = ?", correction.ID, models.CorrectionPending).
+		Updates(map[string]interface{}{
+			"status":      correction.Status,
+			"reviewer_id": correction.ReviewerID,
+			"reviewed_at": correction.ReviewedAt,
+			"review_note": correction.ReviewNote,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
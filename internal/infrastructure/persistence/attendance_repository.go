@@ -6,6 +6,7 @@ import (
 	"github.com/juank/attendance-backend/internal/domain/models"
 	"github.com/juank/attendance-backend/internal/domain/repositories"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AttendanceRepositoryImpl struct {
@@ -54,7 +55,7 @@ func (r *AttendanceRepositoryImpl) GetByDateRange(userID uint, startDate, endDat
 }
 
 func (r *AttendanceRepositoryImpl) Update(attendance *models.Attendance) error {
-	return r.db.Save(attendance).Error
+	return r.db.Omit(clause.Associations).Save(attendance).Error
 }
 
 func (r *AttendanceRepositoryImpl) GetLastAttendance(userID uint) (*models.Attendance, error) {
@@ -80,3 +81,115 @@ func (r *AttendanceRepositoryImpl) GetByEventID(eventID uint) ([]models.Attendan
 	}
 	return attendances, nil
 }
+
+func (r *AttendanceRepositoryImpl) GetUserIDsByEventID(eventID uint) ([]uint, error) {
+	var userIDs []uint
+	if err := r.db.Model(&models.Attendance{}).Where("event_id = ?", eventID).Pluck("user_id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+func (r *AttendanceRepositoryImpl) CreateBatch(attendances []models.Attendance) (int64, error) {
+	if len(attendances) == 0 {
+		return 0, nil
+	}
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "event_id"}, {Name: "user_id"}},
+		DoNothing: true,
+	}).CreateInBatches(attendances, 100)
+	return result.RowsAffected, result.Error
+}
+
+func (r *AttendanceRepositoryImpl) GetByUserStatusInRange(userID uint, status models.AttendanceStatus, startDate, endDate time.Time) ([]models.Attendance, error) {
+	var attendances []models.Attendance
+	err := r.db.Preload("Event").
+		Where("user_id = ? AND status = ? AND check_in BETWEEN ? AND ?", userID, status, startDate, endDate).
+		Find(&attendances).Error
+	if err != nil {
+		return nil, err
+	}
+	return attendances, nil
+}
+
+func (r *AttendanceRepositoryImpl) GetByDepartmentInRange(departmentID uint, startDate, endDate time.Time) ([]models.Attendance, error) {
+	var attendances []models.Attendance
+	err := r.db.Preload("User").Preload("Event").
+		Joins("JOIN users ON users.id = attendances.user_id AND users.deleted_at IS NULL").
+		Where("users.department_id = ? AND attendances.check_in BETWEEN ? AND ?", departmentID, startDate, endDate).
+		Order("attendances.check_in asc").
+		Find(&attendances).Error
+	if err != nil {
+		return nil, err
+	}
+	return attendances, nil
+}
+
+func (r *AttendanceRepositoryImpl) CountByDepartments(departmentIDs []uint, startDate, endDate time.Time) ([]models.DepartmentStatusCount, error) {
+	var counts []models.DepartmentStatusCount
+	err := r.db.Model(&models.Attendance{}).
+		Select("users.department_id AS department_id, attendances.status AS status, COUNT(*) AS count").
+		Joins("JOIN users ON users.id = attendances.user_id AND users.deleted_at IS NULL").
+		Where("users.department_id IN ? AND attendances.check_in BETWEEN ? AND ?", departmentIDs, startDate, endDate).
+		Group("users.department_id, attendances.status").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func (r *AttendanceRepositoryImpl) CountByEvent(eventID uint) ([]models.StatusCount, error) {
+	var counts []models.StatusCount
+	err := r.db.Model(&models.Attendance{}).
+		Select("status, COUNT(*) AS count").
+		Where("event_id = ?", eventID).
+		Group("status").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func (r *AttendanceRepositoryImpl) StreamForExport(filter models.AttendanceExportFilter, fn func(row *models.AttendanceExportRow) error) error {
+	query := r.db.Model(&models.Attendance{}).
+		Select(`attendances.id AS attendance_id, users.first_name, users.last_name, users.email,
+			COALESCE(departments.name, '') AS department_name, events.title AS event_title, events.timezone AS event_timezone,
+			attendances.status, attendances.check_in, attendances.check_out, COALESCE(attendances.notes, '') AS notes,
+			COALESCE(attendances.location, '') AS location, attendances.latitude, attendances.longitude`).
+		Joins("JOIN users ON users.id = attendances.user_id").
+		Joins("LEFT JOIN departments ON departments.id = users.department_id").
+		Joins("JOIN events ON events.id = attendances.event_id")
+
+	if filter.EventID != 0 {
+		query = query.Where("attendances.event_id = ?", filter.EventID)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("attendances.user_id = ?", filter.UserID)
+	}
+	if filter.StartDate != nil {
+		query = query.Where("attendances.check_in >= ?", *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		query = query.Where("attendances.check_in <= ?", *filter.EndDate)
+	}
+
+	rows, err := query.Order("attendances.check_in asc, attendances.id asc").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row models.AttendanceExportRow
+		if err := r.db.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		if err := fn(&row); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
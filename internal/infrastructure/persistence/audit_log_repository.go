@@ -0,0 +1,71 @@
+package persistence
+
+import (
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+type AuditLogRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewAuditLogRepository(db *gorm.DB) repositories.AuditLogRepository {
+	return &AuditLogRepositoryImpl{db: db}
+}
+
+func (r *AuditLogRepositoryImpl) Create(entry *models.AuditLog) error {
+	return r.db.Omit("Actor").Create(entry).Error
+}
+
+func (r *AuditLogRepositoryImpl) GetByID(id uint) (*models.AuditLog, error) {
+	var entry models.AuditLog
+	if err := r.db.Preload("Actor", unscopedUsers).First(&entry, id).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *AuditLogRepositoryImpl) GetAll(filter models.AuditLogFilter, page, limit int) ([]models.AuditLog, int64, error) {
+	var entries []models.AuditLog
+	var total int64
+
+	query := r.db.Model(&models.AuditLog{})
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != 0 {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at <= ?", *filter.To)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Preload("Actor", unscopedUsers).
+		Order("id desc").Offset(offset).Limit(limit).
+		Find(&entries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+// unscopedUsers keeps deleted users as actors of the entries they wrote
+func unscopedUsers(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
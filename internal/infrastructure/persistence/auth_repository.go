@@ -1,9 +1,12 @@
 package persistence
 
 import (
+	"time"
+
 	"github.com/juank/attendance-backend/internal/domain/models"
 	"github.com/juank/attendance-backend/internal/domain/repositories"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type RefreshTokenRepositoryImpl struct {
@@ -33,3 +36,53 @@ func (r *RefreshTokenRepositoryImpl) Revoke(id uint) error {
 func (r *RefreshTokenRepositoryImpl) RevokeByUserID(userID uint) error {
 	return r.db.Model(&models.RefreshToken{}).Where("user_id = ?", userID).Update("revoked", true).Error
 }
+
+func (r *RefreshTokenRepositoryImpl) Rotate(id uint, at time.Time) (bool, error) {
+	result := r.db.Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked = ?", id, false).
+		Updates(map[string]interface{}{"revoked": true, "last_used_at": at})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *RefreshTokenRepositoryImpl) RevokeFamily(familyID string) error {
+	return r.db.Model(&models.RefreshToken{}).Where("family_id = ?", familyID).Update("revoked", true).Error
+}
+
+func (r *RefreshTokenRepositoryImpl) RevokeUserFamily(userID uint, familyID string, now time.Time) (bool, error) {
+	result := r.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND family_id = ? AND revoked = ? AND expires_at > ?", userID, familyID, false, now).
+		Update("revoked", true)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *RefreshTokenRepositoryImpl) GetActiveByUserID(userID uint, now time.Time) ([]models.RefreshToken, error) {
+	var tokens []models.RefreshToken
+	err := r.db.Where("user_id = ? AND revoked = ? AND expires_at > ?", userID, false, now).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+type RevokedSessionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewRevokedSessionRepository(db *gorm.DB) repositories.RevokedSessionRepository {
+	return &RevokedSessionRepositoryImpl{db: db}
+}
+
+func (r *RevokedSessionRepositoryImpl) Create(session *models.RevokedSession) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(session).Error
+}
+
+func (r *RevokedSessionRepositoryImpl) GetIDsByUserID(userID uint, now time.Time) ([]string, error) {
+	var ids []string
+	err := r.db.Model(&models.RevokedSession{}).
+		Where("user_id = ? AND expires_at > ?", userID, now).
+		Pluck("session_id", &ids).Error
+	return ids, err
+}
+
+func (r *RevokedSessionRepositoryImpl) DeleteExpired(now time.Time) error {
+	return r.db.Where("expires_at <= ?", now).Delete(&models.RevokedSession{}).Error
+}
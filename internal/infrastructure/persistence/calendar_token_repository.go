@@ -0,0 +1,50 @@
+package persistence
+
+import (
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+type CalendarTokenRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewCalendarTokenRepository(db *gorm.DB) repositories.CalendarTokenRepository {
+	return &CalendarTokenRepositoryImpl{db: db}
+}
+
+func (r *CalendarTokenRepositoryImpl) GetByUserID(userID uint) (*models.CalendarToken, error) {
+	var token models.CalendarToken
+	if err := r.db.Where("user_id = ?", userID).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *CalendarTokenRepositoryImpl) GetByHash(tokenHash string) (*models.CalendarToken, error) {
+	var token models.CalendarToken
+	if err := r.db.Preload("User").Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *CalendarTokenRepositoryImpl) Replace(token *models.CalendarToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", token.UserID).Delete(&models.CalendarToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+func (r *CalendarTokenRepositoryImpl) DeleteByUserID(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.CalendarToken{}).Error
+}
+
+func (r *CalendarTokenRepositoryImpl) Touch(id uint, usedAt time.Time) error {
+	return r.db.Model(&models.CalendarToken{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
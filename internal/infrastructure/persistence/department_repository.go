@@ -26,6 +26,14 @@ func (r *DepartmentRepositoryImpl) GetByID(id uint) (*models.Department, error)
 	return &department, nil
 }
 
+func (r *DepartmentRepositoryImpl) GetByName(name string) (*models.Department, error) {
+	var department models.Department
+	if err := r.db.Where("name = ?", name).First(&department).Error; err != nil {
+		return nil, err
+	}
+	return &department, nil
+}
+
 func (r *DepartmentRepositoryImpl) GetAll() ([]models.Department, error) {
 	var departments []models.Department
 	if err := r.db.Preload("Manager").Find(&departments).Error; err != nil {
@@ -41,3 +49,11 @@ func (r *DepartmentRepositoryImpl) Update(department *models.Department) error {
 func (r *DepartmentRepositoryImpl) Delete(id uint) error {
 	return r.db.Delete(&models.Department{}, id).Error
 }
+
+func (r *DepartmentRepositoryImpl) GetByManagerID(managerID uint) ([]models.Department, error) {
+	var departments []models.Department
+	if err := r.db.Where("manager_id = ?", managerID).Find(&departments).Error; err != nil {
+		return nil, err
+	}
+	return departments, nil
+}
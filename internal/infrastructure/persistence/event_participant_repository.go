@@ -0,0 +1,73 @@
+package persistence
+
+import (
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+type EventParticipantRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewEventParticipantRepository(db *gorm.DB) repositories.EventParticipantRepository {
+	return &EventParticipantRepositoryImpl{db: db}
+}
+
+func (r *EventParticipantRepositoryImpl) Create(participant *models.EventParticipant) error {
+	return r.db.Create(participant).Error
+}
+
+func (r *EventParticipantRepositoryImpl) GetByID(id uint) (*models.EventParticipant, error) {
+	var participant models.EventParticipant
+	if err := r.db.First(&participant, id).Error; err != nil {
+		return nil, err
+	}
+	return &participant, nil
+}
+
+func (r *EventParticipantRepositoryImpl) GetByEventID(eventID uint) ([]models.EventParticipant, error) {
+	var participants []models.EventParticipant
+	err := r.db.Preload("User").Preload("Department").
+		Where("event_id = ?", eventID).
+		Order("id asc").
+		Find(&participants).Error
+	if err != nil {
+		return nil, err
+	}
+	return participants, nil
+}
+
+func (r *EventParticipantRepositoryImpl) Delete(id uint) error {
+	return r.db.Delete(&models.EventParticipant{}, id).Error
+}
+
+func (r *EventParticipantRepositoryImpl) GetByEventIDs(eventIDs []uint) ([]models.EventParticipant, error) {
+	var participants []models.EventParticipant
+	if len(eventIDs) == 0 {
+		return participants, nil
+	}
+	if err := r.db.Where("event_id IN ?", eventIDs).Find(&participants).Error; err != nil {
+		return nil, err
+	}
+	return participants, nil
+}
+
+func (r *EventParticipantRepositoryImpl) GetUsers(eventID uint) ([]models.User, error) {
+	roster := r.db.Model(&models.EventParticipant{}).Where("event_id = ?", eventID)
+
+	var users []models.User
+	err := r.db.Preload("Department").
+		Where("is_active = ?", true).
+		Where(
+			r.db.Where("id IN (?)", roster.Session(&gorm.Session{}).Select("user_id").Where("type = ?", models.ParticipantUser)).
+				Or("department_id IN (?)", roster.Session(&gorm.Session{}).Select("department_id").Where("type = ?", models.ParticipantDepartment)).
+				Or("role IN (?)", roster.Session(&gorm.Session{}).Select("role").Where("type = ?", models.ParticipantRole)),
+		).
+		Order("last_name asc, first_name asc").
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
@@ -1,9 +1,12 @@
 package persistence
 
 import (
+	"time"
+
 	"github.com/juank/attendance-backend/internal/domain/models"
 	"github.com/juank/attendance-backend/internal/domain/repositories"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type eventRepository struct {
@@ -30,8 +33,22 @@ func (r *eventRepository) Update(event *models.Event) error {
 	return r.db.Save(event).Error
 }
 
+// Delete removes the event together with its roster, which would otherwise block it
 func (r *eventRepository) Delete(id uint) error {
-	return r.db.Delete(&models.Event{}, id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return deleteEvents(tx, []uint{id})
+	})
+}
+
+// deleteEvents deletes the given events and their roster entries within tx
+func deleteEvents(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := tx.Where("event_id IN ?", ids).Delete(&models.EventParticipant{}).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&models.Event{}, ids).Error
 }
 
 func (r *eventRepository) GetAll() ([]models.Event, error) {
@@ -41,3 +58,79 @@ func (r *eventRepository) GetAll() ([]models.Event, error) {
 	}
 	return events, nil
 }
+
+func (r *eventRepository) GetPendingClosure(now time.Time) ([]models.Event, error) {
+	var events []models.Event
+	err := r.db.Where("closed_at IS NULL").
+		Where("is_active = ? OR (end_time > ? AND end_time <= ?)", false, time.Time{}, now).
+		Order("end_time asc").
+		Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *eventRepository) MarkClosed(id uint, closedAt time.Time) error {
+	return r.db.Model(&models.Event{}).Where("id = ?", id).Update("closed_at", closedAt).Error
+}
+
+func (r *eventRepository) UpdateQRSecret(id uint, secret string) error {
+	return r.db.Model(&models.Event{}).Where("id = ?", id).Update("qr_secret", secret).Error
+}
+
+func (r *eventRepository) GetBySeriesID(seriesID uint, from time.Time) ([]models.Event, error) {
+	var events []models.Event
+	err := r.db.Where("series_id = ? AND start_time >= ?", seriesID, from).
+		Order("start_time asc").
+		Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *eventRepository) GetLastInSeries(seriesID uint) (*models.Event, error) {
+	var event models.Event
+	if err := r.db.Where("series_id = ?", seriesID).Order("start_time desc").First(&event).Error; err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (r *eventRepository) CreateOccurrence(event *models.Event, roster []models.EventParticipant) (bool, error) {
+	created := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(event)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		created = true
+
+		for _, p := range roster {
+			participant := models.EventParticipant{
+				EventID:      event.ID,
+				Type:         p.Type,
+				UserID:       p.UserID,
+				DepartmentID: p.DepartmentID,
+				Role:         p.Role,
+			}
+			if err := tx.Omit(clause.Associations).Create(&participant).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return created, nil
+}
+
+func (r *eventRepository) GetStartingFrom(from time.Time) ([]models.Event, error) {
+	var events []models.Event
+	if err := r.db.Where("start_time >= ?", from).Order("start_time asc").Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
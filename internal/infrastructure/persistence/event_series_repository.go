@@ -0,0 +1,62 @@
+package persistence
+
+import (
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+type EventSeriesRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewEventSeriesRepository(db *gorm.DB) repositories.EventSeriesRepository {
+	return &EventSeriesRepositoryImpl{db: db}
+}
+
+func (r *EventSeriesRepositoryImpl) Create(series *models.EventSeries) error {
+	return r.db.Create(series).Error
+}
+
+func (r *EventSeriesRepositoryImpl) GetByID(id uint) (*models.EventSeries, error) {
+	var series models.EventSeries
+	if err := r.db.First(&series, id).Error; err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+func (r *EventSeriesRepositoryImpl) GetAll() ([]models.EventSeries, error) {
+	var series []models.EventSeries
+	if err := r.db.Order("id asc").Find(&series).Error; err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+func (r *EventSeriesRepositoryImpl) Update(series *models.EventSeries) error {
+	return r.db.Save(series).Error
+}
+
+func (r *EventSeriesRepositoryImpl) SkipOccurrences(series *models.EventSeries, eventIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := deleteEvents(tx, eventIDs); err != nil {
+			return err
+		}
+		return tx.Save(series).Error
+	})
+}
+
+func (r *EventSeriesRepositoryImpl) Delete(id uint, removed, detached []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := deleteEvents(tx, removed); err != nil {
+			return err
+		}
+		if len(detached) > 0 {
+			if err := tx.Model(&models.Event{}).Where("id IN ?", detached).Update("is_detached", true).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&models.EventSeries{}, id).Error
+	})
+}
@@ -0,0 +1,126 @@
+package persistence
+
+import (
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/repositories"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LeaveRequestRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewLeaveRequestRepository(db *gorm.DB) repositories.LeaveRequestRepository {
+	return &LeaveRequestRepositoryImpl{db: db}
+}
+
+func (r *LeaveRequestRepositoryImpl) Create(leave *models.LeaveRequest) error {
+	return r.db.Create(leave).Error
+}
+
+func (r *LeaveRequestRepositoryImpl) GetByID(id uint) (*models.LeaveRequest, error) {
+	var leave models.LeaveRequest
+	if err := r.db.Preload("User").Preload("Reviewer").First(&leave, id).Error; err != nil {
+		return nil, err
+	}
+	return &leave, nil
+}
+
+func (r *LeaveRequestRepositoryImpl) Update(leave *models.LeaveRequest) error {
+	return r.db.Omit("User", "Reviewer").Save(leave).Error
+}
+
+func (r *LeaveRequestRepositoryImpl) Review(leave *models.LeaveRequest, attendances []models.Attendance, revisions []models.AttendanceRevision) (bool, error) {
+	reviewed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.LeaveRequest{}).
+			Where("id = ? AND status = ?", leave.ID, models.LeavePending).
+			Updates(map[string]interface{}{
+				"status":      leave.Status,
+				"reviewer_id": leave.ReviewerID,
+				"reviewed_at": leave.ReviewedAt,
+				"review_note": leave.ReviewNote,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		for i := range attendances {
+			if err := tx.Omit(clause.Associations).Save(&attendances[i]).Error; err != nil {
+				return err
+			}
+		}
+		if len(revisions) > 0 {
+			if err := tx.Omit(clause.Associations).Create(&revisions).Error; err != nil {
+				return err
+			}
+		}
+
+		reviewed = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return reviewed, nil
+}
+
+func (r *LeaveRequestRepositoryImpl) GetByUserID(userID uint, page, limit int) ([]models.LeaveRequest, int64, error) {
+	var leaves []models.LeaveRequest
+	var total int64
+
+	offset := (page - 1) * limit
+
+	if err := r.db.Model(&models.LeaveRequest{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := r.db.Where("user_id = ?", userID).Order("start_date desc").Offset(offset).Limit(limit).Find(&leaves).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return leaves, total, nil
+}
+
+func (r *LeaveRequestRepositoryImpl) GetPending(departmentIDs []uint) ([]models.LeaveRequest, error) {
+	var leaves []models.LeaveRequest
+	query := r.db.Preload("User").Where("status = ?", models.LeavePending)
+	if len(departmentIDs) > 0 {
+		query = query.Where("user_id IN (?)", r.db.Model(&models.User{}).Select("id").Where("department_id IN ?", departmentIDs))
+	}
+	if err := query.Order("start_date asc").Find(&leaves).Error; err != nil {
+		return nil, err
+	}
+	return leaves, nil
+}
+
+func (r *LeaveRequestRepositoryImpl) GetApprovedOverlapping(userIDs []uint, from, to time.Time) ([]models.LeaveRequest, error) {
+	var leaves []models.LeaveRequest
+	if len(userIDs) == 0 {
+		return leaves, nil
+	}
+	err := r.db.Where("status = ? AND user_id IN ? AND start_date <= ? AND end_date >= ?", models.LeaveApproved, userIDs, to, from).
+		Find(&leaves).Error
+	if err != nil {
+		return nil, err
+	}
+	return leaves, nil
+}
+
+func (r *LeaveRequestRepositoryImpl) HasOverlapping(userID uint, from, to time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.LeaveRequest{}).
+		Where("user_id = ? AND status IN ? AND start_date <= ? AND end_date >= ?",
+			userID, []models.LeaveStatus{models.LeavePending, models.LeaveApproved}, to, from).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
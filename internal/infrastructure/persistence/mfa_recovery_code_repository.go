@@ -0,0 +1,47 @@
+package persistence
+
+import (
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+type MFARecoveryCodeRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewMFARecoveryCodeRepository(db *gorm.DB) repositories.MFARecoveryCodeRepository {
+	return &MFARecoveryCodeRepositoryImpl{db: db}
+}
+
+func (r *MFARecoveryCodeRepositoryImpl) Replace(userID uint, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]models.MFARecoveryCode, len(codeHashes))
+		for i, hash := range codeHashes {
+			codes[i] = models.MFARecoveryCode{UserID: userID, CodeHash: hash}
+		}
+		return tx.Omit("User").Create(&codes).Error
+	})
+}
+
+func (r *MFARecoveryCodeRepositoryImpl) Use(userID uint, codeHash string, at time.Time) (bool, error) {
+	result := r.db.Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", at)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *MFARecoveryCodeRepositoryImpl) CountUnused(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.MFARecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *MFARecoveryCodeRepositoryImpl) DeleteByUserID(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error
+}
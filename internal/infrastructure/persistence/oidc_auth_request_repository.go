@@ -0,0 +1,40 @@
+package persistence
+
+import (
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/repositories"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OIDCAuthRequestRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewOIDCAuthRequestRepository(db *gorm.DB) repositories.OIDCAuthRequestRepository {
+	return &OIDCAuthRequestRepositoryImpl{db: db}
+}
+
+func (r *OIDCAuthRequestRepositoryImpl) Create(request *models.OIDCAuthRequest) error {
+	return r.db.Create(request).Error
+}
+
+func (r *OIDCAuthRequestRepositoryImpl) Consume(stateHash string, now time.Time) (*models.OIDCAuthRequest, error) {
+	var requests []models.OIDCAuthRequest
+	result := r.db.Clauses(clause.Returning{}).
+		Where("state_hash = ? AND expires_at > ?", stateHash, now).
+		Delete(&requests)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if len(requests) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &requests[0], nil
+}
+
+func (r *OIDCAuthRequestRepositoryImpl) DeleteExpired(now time.Time) error {
+	return r.db.Where("expires_at <= ?", now).Delete(&models.OIDCAuthRequest{}).Error
+}
@@ -0,0 +1,77 @@
+package persistence
+
+import (
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+type PasswordResetTokenRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetTokenRepository(db *gorm.DB) repositories.PasswordResetTokenRepository {
+	return &PasswordResetTokenRepositoryImpl{db: db}
+}
+
+func (r *PasswordResetTokenRepositoryImpl) Create(token *models.PasswordResetToken) error {
+	return r.db.Omit("User").Create(token).Error
+}
+
+func (r *PasswordResetTokenRepositoryImpl) GetByHash(tokenHash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	if err := r.db.Preload("User").Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *PasswordResetTokenRepositoryImpl) GetLatestByUserID(userID uint) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	if err := r.db.Where("user_id = ?", userID).Order("created_at DESC").First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *PasswordResetTokenRepositoryImpl) Redeem(token *models.PasswordResetToken, passwordHash string, at time.Time) (bool, error) {
+	used := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", token.ID).
+			Update("used_at", at)
+		if result.Error != nil || result.RowsAffected != 1 {
+			return result.Error
+		}
+		used = true
+
+		err := tx.Model(&models.User{}).Where("id = ?", token.UserID).Updates(map[string]interface{}{
+			"password":              passwordHash,
+			"failed_login_attempts": 0,
+			"last_failed_login_at":  nil,
+			"locked_until":          nil,
+			"token_version":         gorm.Expr("token_version + 1"),
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.RefreshToken{}).Where("user_id = ?", token.UserID).Update("revoked", true).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", token.UserID).
+			Update("used_at", at).Error
+	})
+	if err != nil {
+		return false, err
+	}
+	return used, nil
+}
+
+func (r *PasswordResetTokenRepositoryImpl) InvalidateByUserID(userID uint, at time.Time) error {
+	return r.db.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", at).Error
+}
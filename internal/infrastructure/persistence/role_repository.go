@@ -0,0 +1,55 @@
+package persistence
+
+import (
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+type RoleRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) repositories.RoleRepository {
+	return &RoleRepositoryImpl{db: db}
+}
+
+func (r *RoleRepositoryImpl) Create(role *models.RoleDefinition) error {
+	return r.db.Create(role).Error
+}
+
+func (r *RoleRepositoryImpl) GetByID(id uint) (*models.RoleDefinition, error) {
+	var role models.RoleDefinition
+	if err := r.db.First(&role, id).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *RoleRepositoryImpl) GetByName(name models.Role) (*models.RoleDefinition, error) {
+	var role models.RoleDefinition
+	if err := r.db.Where("name = ?", name).First(&role).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *RoleRepositoryImpl) GetAll() ([]models.RoleDefinition, error) {
+	var roles []models.RoleDefinition
+	err := r.db.Order("is_system DESC, name ASC").Find(&roles).Error
+	return roles, err
+}
+
+func (r *RoleRepositoryImpl) Update(role *models.RoleDefinition) error {
+	return r.db.Save(role).Error
+}
+
+func (r *RoleRepositoryImpl) Delete(id uint) error {
+	return r.db.Delete(&models.RoleDefinition{}, id).Error
+}
+
+func (r *RoleRepositoryImpl) CountUsers(name models.Role) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.User{}).Where("role = ?", name).Count(&count).Error
+	return count, err
+}
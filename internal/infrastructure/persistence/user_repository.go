@@ -1,11 +1,18 @@
 package persistence
 
 import (
+	"errors"
+	"time"
+
 	"github.com/juank/attendance-backend/internal/domain/models"
 	"github.com/juank/attendance-backend/internal/domain/repositories"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// errDryRun rolls back an import transaction once every insert has succeeded
+var errDryRun = errors.New("dry run")
+
 type UserRepositoryImpl struct {
 	db *gorm.DB
 }
@@ -26,9 +33,17 @@ func (r *UserRepositoryImpl) GetByID(id uint) (*models.User, error) {
 	return &user, nil
 }
 
+func (r *UserRepositoryImpl) GetByOIDCSubject(subject string) (*models.User, error) {
+	var user models.User
+	if err := r.db.Preload("Department").Where("oidc_subject = ?", subject).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (r *UserRepositoryImpl) GetByEmail(email string) (*models.User, error) {
 	var user models.User
-	if err := r.db.Preload("Department").Where("email = ?", email).First(&user).Error; err != nil {
+	if err := r.db.Preload("Department").Where("LOWER(email) = LOWER(?)", email).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
@@ -58,3 +73,113 @@ func (r *UserRepositoryImpl) GetAll(page, limit int) ([]models.User, int64, erro
 
 	return users, total, nil
 }
+
+func (r *UserRepositoryImpl) GetActive() ([]models.User, error) {
+	var users []models.User
+	if err := r.db.Where("is_active = ?", true).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *UserRepositoryImpl) RecordLoginFailure(id uint, at, since time.Time) (int, error) {
+	var attempts int
+	err := r.db.Raw(`
+		UPDATE users SET
+			failed_login_attempts = CASE
+				WHEN last_failed_login_at IS NULL OR last_failed_login_at < ? THEN 1
+				ELSE failed_login_attempts + 1
+			END,
+			last_failed_login_at = ?
+		WHERE id = ? AND deleted_at IS NULL
+		RETURNING failed_login_attempts`, since, at, id).Scan(&attempts).Error
+	return attempts, err
+}
+
+func (r *UserRepositoryImpl) Lock(id uint, until time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("locked_until", until).Error
+}
+
+func (r *UserRepositoryImpl) ResetLoginFailures(id uint) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"failed_login_attempts": 0,
+		"last_failed_login_at":  nil,
+		"locked_until":          nil,
+	}).Error
+}
+
+func (r *UserRepositoryImpl) SetMFA(id uint, secret string, enabled bool) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"totp_secret": secret,
+		"mfa_enabled": enabled,
+	}).Error
+}
+
+func (r *UserRepositoryImpl) UseTOTPCounter(id uint, counter int64) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND totp_last_counter < ?", id, counter).
+		Update("totp_last_counter", counter)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *UserRepositoryImpl) IncrementTokenVersion(id uint) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).
+		Update("token_version", gorm.Expr("token_version + 1")).Error
+}
+
+func (r *UserRepositoryImpl) GetTokenVersion(id uint) (int, bool, error) {
+	var user models.User
+	result := r.db.Select("token_version", "is_active").Where("id = ?", id).Limit(1).Find(&user)
+	if result.Error != nil {
+		return 0, false, result.Error
+	}
+	return user.TokenVersion, result.RowsAffected == 1 && user.IsActive, nil
+}
+
+func (r *UserRepositoryImpl) SetOIDCSubject(id uint, subject string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("oidc_subject", subject).Error
+}
+
+func (r *UserRepositoryImpl) GetExistingEmails(emails []string) ([]string, error) {
+	var existing []string
+	if len(emails) == 0 {
+		return existing, nil
+	}
+
+	err := r.db.Unscoped().Model(&models.User{}).
+		Where("LOWER(email) IN ?", emails).
+		Pluck("LOWER(email)", &existing).Error
+	if err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+func (r *UserRepositoryImpl) Import(departments []*models.Department, users []*models.User, dryRun bool) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, dept := range departments {
+			if err := tx.Create(dept).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, user := range users {
+			if user.DepartmentID == nil && user.Department != nil {
+				user.DepartmentID = &user.Department.ID
+			}
+			if err := tx.Omit(clause.Associations).Create(user).Error; err != nil {
+				return err
+			}
+		}
+
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+
+	if errors.Is(err, errDryRun) {
+		return nil
+	}
+	return err
+}
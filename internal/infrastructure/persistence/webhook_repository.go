@@ -0,0 +1,154 @@
+package persistence
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/repositories"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) repositories.WebhookRepository {
+	return &WebhookRepositoryImpl{db: db}
+}
+
+func (r *WebhookRepositoryImpl) Create(webhook *models.Webhook) error {
+	return r.db.Create(webhook).Error
+}
+
+func (r *WebhookRepositoryImpl) GetByID(id uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := r.db.First(&webhook, id).Error; err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *WebhookRepositoryImpl) GetAll() ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	if err := r.db.Order("id asc").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *WebhookRepositoryImpl) Update(webhook *models.Webhook) error {
+	return r.db.Save(webhook).Error
+}
+
+func (r *WebhookRepositoryImpl) Delete(id uint) error {
+	return r.db.Delete(&models.Webhook{}, id).Error
+}
+
+func (r *WebhookRepositoryImpl) GetActiveByEventType(eventType models.WebhookEventType) ([]models.Webhook, error) {
+	filter, err := json.Marshal([]models.WebhookEventType{eventType})
+	if err != nil {
+		return nil, err
+	}
+
+	var webhooks []models.Webhook
+	err = r.db.Where("is_active = ?", true).
+		Where("event_types @> ?::jsonb", string(filter)).
+		Find(&webhooks).Error
+	if err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+type WebhookDeliveryRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewWebhookDeliveryRepository(db *gorm.DB) repositories.WebhookDeliveryRepository {
+	return &WebhookDeliveryRepositoryImpl{db: db}
+}
+
+func (r *WebhookDeliveryRepositoryImpl) CreateBatch(deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.Create(&deliveries).Error
+}
+
+func (r *WebhookDeliveryRepositoryImpl) GetByID(id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.db.Preload("Attempts", func(db *gorm.DB) *gorm.DB {
+		return db.Order("attempt asc")
+	}).First(&delivery, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *WebhookDeliveryRepositoryImpl) GetByWebhookID(webhookID uint, status models.WebhookDeliveryStatus, page, limit int) ([]models.WebhookDelivery, int64, error) {
+	var deliveries []models.WebhookDelivery
+	var total int64
+
+	query := r.db.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("id desc").Offset(offset).Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, total, nil
+}
+
+func (r *WebhookDeliveryRepositoryImpl) Update(delivery *models.WebhookDelivery) error {
+	return r.db.Omit("Attempts").Save(delivery).Error
+}
+
+func (r *WebhookDeliveryRepositoryImpl) ClaimDue(now time.Time, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	var ids []uint
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.WebhookDelivery{}).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+			Order("next_attempt_at asc").
+			Limit(limit).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		return tx.Model(&models.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	var deliveries []models.WebhookDelivery
+	err = r.db.Preload("Webhook", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).Where("id IN ?", ids).Order("id asc").Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *WebhookDeliveryRepositoryImpl) RecordAttempt(delivery *models.WebhookDelivery, attempt *models.WebhookDeliveryAttempt) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(attempt).Error; err != nil {
+			return err
+		}
+		return tx.Omit("Webhook", "Attempts").Save(delivery).Error
+	})
+}
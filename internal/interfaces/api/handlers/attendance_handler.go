@@ -3,7 +3,6 @@ package handlers
 import (
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/juank/attendance-backend/internal/domain/services"
@@ -40,9 +39,11 @@ func (h *AttendanceHandler) MarkAttendance(c *gin.Context) {
 	}
 
 	var req struct {
-		QRToken  string `json:"qr_token" binding:"required"`
-		Location string `json:"location"`
-		Notes    string `json:"notes"`
+		QRToken   string   `json:"qr_token" binding:"required"`
+		Location  string   `json:"location"`
+		Latitude  *float64 `json:"latitude"`
+		Longitude *float64 `json:"longitude"`
+		Notes     string   `json:"notes"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -59,14 +60,20 @@ func (h *AttendanceHandler) MarkAttendance(c *gin.Context) {
 
 	// Mark attendance
 	markReq := &services.MarkAttendanceRequest{
-		UserID:   userID.(uint),
-		QRToken:  req.QRToken,
-		Location: req.Location,
-		Notes:    req.Notes,
+		UserID:    userID.(uint),
+		QRToken:   req.QRToken,
+		Location:  req.Location,
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+		Notes:     req.Notes,
 	}
 
 	attendance, err := h.attendanceService.MarkAttendance(markReq)
 	if err != nil {
+		if err.Error() == "user is not a participant of this event" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -74,6 +81,50 @@ func (h *AttendanceHandler) MarkAttendance(c *gin.Context) {
 	c.JSON(http.StatusCreated, attendance)
 }
 
+// CheckOut records the check-out of the current user using the event QR token
+// @Summary Check out with QR code
+// @Tags Attendance
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.CheckOutRequest true "Check Out Request"
+// @Success 200 {object} models.Attendance
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /attendance/checkout [post]
+func (h *AttendanceHandler) CheckOut(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var req struct {
+		QRToken string `json:"qr_token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	attendance, err := h.attendanceService.CheckOut(&services.CheckOutRequest{
+		UserID:  userID.(uint),
+		QRToken: req.QRToken,
+	})
+	if err != nil {
+		if err.Error() == "user already checked out of this event" {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attendance)
+}
+
 // GetToday gets today's attendance for current user
 // @Summary Get today's attendance
 // @Tags Attendance
@@ -148,23 +199,8 @@ func (h *AttendanceHandler) GetByDateRange(c *gin.Context) {
 		return
 	}
 
-	startDateStr := c.Query("start_date")
-	endDateStr := c.Query("end_date")
-
-	if startDateStr == "" || endDateStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date and end_date are required"})
-		return
-	}
-
-	startDate, err := time.Parse("2006-01-02", startDateStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid start_date format, use YYYY-MM-DD"})
-		return
-	}
-
-	endDate, err := time.Parse("2006-01-02", endDateStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid end_date format, use YYYY-MM-DD"})
+	startDate, endDate, ok := parseDateRange(c)
+	if !ok {
 		return
 	}
 
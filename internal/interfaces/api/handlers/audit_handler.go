@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juank/attendance-backend/internal/domain/services"
+)
+
+type AuditHandler struct {
+	auditService services.AuditService
+}
+
+func NewAuditHandler(auditService services.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// GetAll lists audit log entries, newest first
+// @Summary List audit log
+// @Tags Audit
+// @Security BearerAuth
+// @Produce json
+// @Param actor_id query int false "User who performed the action"
+// @Param action query string false "Action, e.g. user.update"
+// @Param target_type query string false "Target type, e.g. user"
+// @Param target_id query int false "Target ID"
+// @Param from query string false "From date (YYYY-MM-DD) or RFC 3339 timestamp"
+// @Param to query string false "To date (YYYY-MM-DD, inclusive) or RFC 3339 timestamp"
+// @Param page query int false "Page"
+// @Param limit query int false "Page size (max 100)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Router /audit-logs [get]
+func (h *AuditHandler) GetAll(c *gin.Context) {
+	var query services.AuditLogQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entries, total, err := h.auditService.GetAll(&query)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAuditQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  entries,
+		"total": total,
+		"page":  query.Page,
+		"limit": query.Limit,
+	})
+}
+
+// GetByID returns an audit log entry
+// @Summary Get audit log entry
+// @Tags Audit
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Entry ID"
+// @Success 200 {object} models.AuditLog
+// @Failure 404 {object} map[string]string
+// @Router /audit-logs/{id} [get]
+func (h *AuditHandler) GetByID(c *gin.Context) {
+	id, ok := parseUintParam(c, "id", "invalid audit log id")
+	if !ok {
+		return
+	}
+
+	entry, err := h.auditService.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juank/attendance-backend/internal/domain/services"
+	"github.com/juank/attendance-backend/pkg/ical"
+)
+
+type CalendarHandler struct {
+	calendarService services.CalendarService
+}
+
+func NewCalendarHandler(calendarService services.CalendarService) *CalendarHandler {
+	return &CalendarHandler{
+		calendarService: calendarService,
+	}
+}
+
+type calendarTokenResponse struct {
+	Token     string    `json:"token"`
+	FeedURL   string    `json:"feed_url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GetToken returns whether the current user has a feed token and when it was last used
+// @Summary Get calendar feed token status
+// @Tags Calendar
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.CalendarToken
+// @Failure 404 {object} map[string]string
+// @Router /calendar/token [get]
+func (h *CalendarHandler) GetToken(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	token, err := h.calendarService.GetToken(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, token)
+}
+
+// RotateToken issues a new feed token, invalidating the previous one
+// @Summary Create or rotate calendar feed token
+// @Tags Calendar
+// @Security BearerAuth
+// @Produce json
+// @Success 201 {object} calendarTokenResponse
+// @Router /calendar/token [post]
+func (h *CalendarHandler) RotateToken(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	plain, token, err := h.calendarService.RotateToken(actor)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, calendarTokenResponse{
+		Token:     plain,
+		FeedURL:   feedURL(c, plain),
+		CreatedAt: token.CreatedAt,
+	})
+}
+
+// RevokeToken deletes the feed token; subscribed calendars stop updating
+// @Summary Revoke calendar feed token
+// @Tags Calendar
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /calendar/token [delete]
+func (h *CalendarHandler) RevokeToken(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	if err := h.calendarService.RevokeToken(actor); err != nil {
+		if err.Error() == "calendar token not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "calendar token revoked"})
+}
+
+// GetFeed serves the subscribable iCalendar feed of the token owner
+// @Summary Calendar feed
+// @Tags Calendar
+// @Produce text/calendar
+// @Param token query string true "Calendar feed token"
+// @Success 200 {file} file
+// @Failure 401 {object} map[string]string
+// @Router /calendar/feed.ics [get]
+func (h *CalendarHandler) GetFeed(c *gin.Context) {
+	user, err := h.calendarService.Authenticate(c.Query("token"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	calendar, err := h.calendarService.GetUserFeed(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.write(c, calendar, "")
+}
+
+// GetEvent downloads a single event, or its whole series with scope=series
+// @Summary Event .ics download
+// @Tags Calendar
+// @Produce text/calendar
+// @Param id path int true "Event ID"
+// @Param token query string true "Calendar feed token"
+// @Param scope query string false "series to export every occurrence of the event's series"
+// @Success 200 {file} file
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /calendar/events/{id} [get]
+func (h *CalendarHandler) GetEvent(c *gin.Context) {
+	user, err := h.calendarService.Authenticate(c.Query("token"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event id"})
+		return
+	}
+
+	calendar, err := h.calendarService.GetEventCalendar(user, uint(id), c.Query("scope") == "series")
+	if err != nil {
+		if err.Error() == "event not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.write(c, calendar, fmt.Sprintf("event-%d.ics", id))
+}
+
+// write renders the calendar, as an attachment when a file name is given
+func (h *CalendarHandler) write(c *gin.Context, calendar *ical.Calendar, fileName string) {
+	var buf bytes.Buffer
+	if err := calendar.Write(&buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if fileName != "" {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	}
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, ical.ContentType, buf.Bytes())
+}
+
+// feedURL builds the subscription URL of the feed next to the token route
+func feedURL(c *gin.Context, token string) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	path := strings.TrimSuffix(c.FullPath(), "/token") + "/feed.ics"
+	return fmt.Sprintf("%s://%s%s?token=%s", scheme, c.Request.Host, path, url.QueryEscape(token))
+}
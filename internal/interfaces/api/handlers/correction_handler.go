@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/services"
+)
+
+type CorrectionHandler struct {
+	correctionService services.CorrectionService
+}
+
+func NewCorrectionHandler(correctionService services.CorrectionService) *CorrectionHandler {
+	return &CorrectionHandler{
+		correctionService: correctionService,
+	}
+}
+
+// Create requests a correction of one of the current user's attendance records
+// @Summary Request attendance correction
+// @Tags Corrections
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Attendance ID"
+// @Param request body services.CreateCorrectionRequest true "Correction Request"
+// @Success 201 {object} models.AttendanceCorrection
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /attendance/{id}/corrections [post]
+func (h *CorrectionHandler) Create(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	attendanceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attendance id"})
+		return
+	}
+
+	var req services.CreateCorrectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	correction, err := h.correctionService.Create(actor, uint(attendanceID), &req)
+	if err != nil {
+		switch err.Error() {
+		case "attendance not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "attendance already has a pending correction":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, correction)
+}
+
+// GetMine lists the correction requests of the current user
+// @Summary Get my correction requests
+// @Tags Corrections
+// @Security BearerAuth
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} map[string]interface{}
+// @Router /attendance-corrections/me [get]
+func (h *CorrectionHandler) GetMine(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	corrections, total, err := h.correctionService.GetUserCorrections(userID, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  corrections,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// Cancel withdraws a pending correction request of the current user
+// @Summary Cancel correction request
+// @Tags Corrections
+// @Security BearerAuth
+// @Param id path int true "Correction ID"
+// @Success 200 {object} models.AttendanceCorrection
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /attendance-corrections/{id}/cancel [post]
+func (h *CorrectionHandler) Cancel(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid correction id"})
+		return
+	}
+
+	correction, err := h.correctionService.Cancel(actor, uint(id))
+	if err != nil {
+		if err.Error() == "correction not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, correction)
+}
+
+// GetPending lists the pending corrections the current manager or admin can review
+// @Summary Get pending correction requests
+// @Tags Corrections
+// @Security BearerAuth
+// @Success 200 {array} models.AttendanceCorrection
+// @Router /attendance-corrections/pending [get]
+func (h *CorrectionHandler) GetPending(c *gin.Context) {
+	reviewerID, role, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	corrections, err := h.correctionService.GetPendingForReviewer(reviewerID, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, corrections)
+}
+
+// Approve approves a pending correction and applies it to the attendance record
+// @Summary Approve correction request
+// @Tags Corrections
+// @Security BearerAuth
+// @Param id path int true "Correction ID"
+// @Param request body services.ReviewCorrectionRequest false "Review"
+// @Success 200 {object} models.AttendanceCorrection
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /attendance-corrections/{id}/approve [post]
+func (h *CorrectionHandler) Approve(c *gin.Context) {
+	h.review(c, h.correctionService.Approve)
+}
+
+// Reject rejects a pending correction
+// @Summary Reject correction request
+// @Tags Corrections
+// @Security BearerAuth
+// @Param id path int true "Correction ID"
+// @Param request body services.ReviewCorrectionRequest false "Review"
+// @Success 200 {object} models.AttendanceCorrection
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /attendance-corrections/{id}/reject [post]
+func (h *CorrectionHandler) Reject(c *gin.Context) {
+	h.review(c, h.correctionService.Reject)
+}
+
+// GetHistory returns the revisions of an attendance record
+// @Summary Get attendance revision history
+// @Tags Corrections
+// @Security BearerAuth
+// @Param id path int true "Attendance ID"
+// @Success 200 {array} models.AttendanceRevision
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /attendance/{id}/revisions [get]
+func (h *CorrectionHandler) GetHistory(c *gin.Context) {
+	viewerID, role, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	attendanceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attendance id"})
+		return
+	}
+
+	revisions, err := h.correctionService.GetHistory(uint(attendanceID), viewerID, role)
+	if err != nil {
+		switch err.Error() {
+		case "attendance not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "only the department manager can review this request", "you cannot review your own request":
+			c.JSON(http.StatusForbidden, gin.H{"error": "you cannot view this attendance history"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+func (h *CorrectionHandler) review(c *gin.Context, decide func(actor *services.Actor, id uint, req *services.ReviewCorrectionRequest) (*models.AttendanceCorrection, error)) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid correction id"})
+		return
+	}
+
+	var req services.ReviewCorrectionRequest
+	// The review note is optional, so an empty body is accepted
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	correction, err := decide(actor, uint(id), &req)
+	if err != nil {
+		switch err.Error() {
+		case "correction not found", "attendance not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "only the department manager can review this request", "you cannot review your own request":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case "correction has already been reviewed":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, correction)
+}
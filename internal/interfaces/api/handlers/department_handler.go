@@ -19,13 +19,18 @@ func NewDepartmentHandler(deptService services.DepartmentService) *DepartmentHan
 }
 
 func (h *DepartmentHandler) Create(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
 	var req services.CreateDepartmentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	dept, err := h.deptService.Create(&req)
+	dept, err := h.deptService.Create(actor, &req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -62,6 +67,11 @@ func (h *DepartmentHandler) GetAll(c *gin.Context) {
 }
 
 func (h *DepartmentHandler) Update(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -75,7 +85,7 @@ func (h *DepartmentHandler) Update(c *gin.Context) {
 		return
 	}
 
-	dept, err := h.deptService.Update(uint(id), &req)
+	dept, err := h.deptService.Update(actor, uint(id), &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -85,6 +95,11 @@ func (h *DepartmentHandler) Update(c *gin.Context) {
 }
 
 func (h *DepartmentHandler) Delete(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -92,7 +107,7 @@ func (h *DepartmentHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.deptService.Delete(uint(id)); err != nil {
+	if err := h.deptService.Delete(actor, uint(id)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -12,24 +13,35 @@ import (
 
 type EventHandler struct {
 	eventService      *services.EventService
+	seriesService     *services.EventSeriesService
 	attendanceService domainServices.AttendanceService
 }
 
-func NewEventHandler(eventService *services.EventService, attendanceService domainServices.AttendanceService) *EventHandler {
+func NewEventHandler(eventService *services.EventService, seriesService *services.EventSeriesService, attendanceService domainServices.AttendanceService) *EventHandler {
 	return &EventHandler{
 		eventService:      eventService,
+		seriesService:     seriesService,
 		attendanceService: attendanceService,
 	}
 }
 
 func (h *EventHandler) Create(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
 	var event models.Event
 	if err := c.ShouldBindJSON(&event); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.eventService.Create(&event); err != nil {
+	if err := h.eventService.Create(actor, &event); err != nil {
+		if errors.Is(err, services.ErrInvalidEvent) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -63,6 +75,11 @@ func (h *EventHandler) GetByID(c *gin.Context) {
 }
 
 func (h *EventHandler) Update(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
@@ -75,8 +92,35 @@ func (h *EventHandler) Update(c *gin.Context) {
 		return
 	}
 
+	// scope=series applies the changes to every upcoming occurrence of the event's series
+	if c.Query("scope") == "series" {
+		series, err := h.seriesService.UpdateFromOccurrence(actor, uint(id), &event)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidEvent) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if err.Error() == "event not found" || err.Error() == "event series not found" {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, series)
+		return
+	}
+
 	event.ID = uint(id)
-	if err := h.eventService.Update(&event); err != nil {
+	if err := h.eventService.Update(actor, &event); err != nil {
+		if errors.Is(err, services.ErrInvalidEvent) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "event not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -85,13 +129,18 @@ func (h *EventHandler) Update(c *gin.Context) {
 }
 
 func (h *EventHandler) Delete(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		return
 	}
 
-	if err := h.eventService.Delete(uint(id)); err != nil {
+	if err := h.eventService.Delete(actor, uint(id)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -116,6 +165,11 @@ func (h *EventHandler) GetAttendance(c *gin.Context) {
 }
 
 func (h *EventHandler) MarkManualAttendance(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event id"})
@@ -132,15 +186,70 @@ func (h *EventHandler) MarkManualAttendance(c *gin.Context) {
 		return
 	}
 
-	attendance, err := h.attendanceService.MarkManualAttendance(uint(id), req.UserID, req.Notes)
+	attendance, err := h.attendanceService.MarkManualAttendance(actor, uint(id), req.UserID, req.Notes)
 	if err != nil {
 		if err.Error() == "user already marked attendance for this event" {
 			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 			return
 		}
+		if err.Error() == "event not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusCreated, attendance)
 }
+
+// MarkManualCheckOut records the check-out of a participant on their behalf
+// @Summary Check out a participant manually
+// @Tags Events
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Event ID"
+// @Param request body object true "Participant to check out: {\"user_id\": 42}"
+// @Success 200 {object} models.Attendance
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /events/{id}/attendance/checkout/manual [post]
+func (h *EventHandler) MarkManualCheckOut(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event id"})
+		return
+	}
+
+	var req struct {
+		UserID uint `json:"user_id" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	attendance, err := h.attendanceService.MarkManualCheckOut(actor, uint(id), req.UserID)
+	if err != nil {
+		switch err.Error() {
+		case "user already checked out of this event":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case "event not found", "no check-in found for this event":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, attendance)
+}
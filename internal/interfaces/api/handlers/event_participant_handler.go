@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juank/attendance-backend/internal/domain/services"
+)
+
+type EventParticipantHandler struct {
+	participantService services.EventParticipantService
+}
+
+func NewEventParticipantHandler(participantService services.EventParticipantService) *EventParticipantHandler {
+	return &EventParticipantHandler{
+		participantService: participantService,
+	}
+}
+
+// GetAll lists the roster entries of an event
+// @Summary List event participants
+// @Tags Events
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Success 200 {array} models.EventParticipant
+// @Failure 400 {object} map[string]string
+// @Router /events/{id}/participants [get]
+func (h *EventParticipantHandler) GetAll(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event id"})
+		return
+	}
+
+	participants, err := h.participantService.GetParticipants(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, participants)
+}
+
+// GetExpectedUsers resolves the roster into the users expected to attend
+// @Summary List expected attendees of an event
+// @Tags Events
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Success 200 {array} models.User
+// @Failure 400 {object} map[string]string
+// @Router /events/{id}/participants/users [get]
+func (h *EventParticipantHandler) GetExpectedUsers(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event id"})
+		return
+	}
+
+	users, err := h.participantService.GetExpectedUsers(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+// Add assigns users, departments or roles to an event
+// @Summary Add event participants
+// @Tags Events
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Event ID"
+// @Param request body services.AddParticipantsRequest true "Participants"
+// @Success 201 {array} models.EventParticipant
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /events/{id}/participants [post]
+func (h *EventParticipantHandler) Add(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event id"})
+		return
+	}
+
+	var req services.AddParticipantsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	participants, err := h.participantService.AddParticipants(actor, uint(id), &req)
+	if err != nil {
+		if err.Error() == "event not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, participants)
+}
+
+// Remove deletes a roster entry from an event
+// @Summary Remove event participant
+// @Tags Events
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Param participantId path int true "Participant ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /events/{id}/participants/{participantId} [delete]
+func (h *EventParticipantHandler) Remove(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event id"})
+		return
+	}
+
+	participantID, err := strconv.ParseUint(c.Param("participantId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid participant id"})
+		return
+	}
+
+	if err := h.participantService.RemoveParticipant(actor, uint(id), uint(participantID)); err != nil {
+		if err.Error() == "participant not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "participant removed"})
+}
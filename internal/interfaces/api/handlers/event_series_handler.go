@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juank/attendance-backend/internal/application/services"
+	"github.com/juank/attendance-backend/internal/domain/models"
+)
+
+type EventSeriesHandler struct {
+	seriesService *services.EventSeriesService
+}
+
+func NewEventSeriesHandler(seriesService *services.EventSeriesService) *EventSeriesHandler {
+	return &EventSeriesHandler{
+		seriesService: seriesService,
+	}
+}
+
+type eventSeriesRequest struct {
+	Event          models.Event `json:"event" binding:"required"`
+	RRule          string       `json:"rrule" binding:"required"`
+	ExceptionDates []string     `json:"exception_dates"`
+}
+
+func (r *eventSeriesRequest) toModel() *models.EventSeries {
+	return &models.EventSeries{
+		Template:       r.Event,
+		RRule:          r.RRule,
+		ExceptionDates: models.DateList(r.ExceptionDates),
+	}
+}
+
+// Create creates a recurring event and generates its upcoming occurrences
+// @Summary Create event series
+// @Tags Event Series
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body eventSeriesRequest true "Series"
+// @Success 201 {object} models.EventSeries
+// @Failure 400 {object} map[string]string
+// @Router /event-series [post]
+func (h *EventSeriesHandler) Create(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	var req eventSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	series := req.toModel()
+	if err := h.seriesService.Create(actor, series); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, series)
+}
+
+// GetAll lists the event series
+// @Summary List event series
+// @Tags Event Series
+// @Security BearerAuth
+// @Success 200 {array} models.EventSeries
+// @Router /event-series [get]
+func (h *EventSeriesHandler) GetAll(c *gin.Context) {
+	series, err := h.seriesService.GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, series)
+}
+
+// GetByID returns an event series
+// @Summary Get event series
+// @Tags Event Series
+// @Security BearerAuth
+// @Param id path int true "Series ID"
+// @Success 200 {object} models.EventSeries
+// @Failure 404 {object} map[string]string
+// @Router /event-series/{id} [get]
+func (h *EventSeriesHandler) GetByID(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	series, err := h.seriesService.GetByID(id)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+// GetOccurrences lists the events generated by a series
+// @Summary List series occurrences
+// @Tags Event Series
+// @Security BearerAuth
+// @Param id path int true "Series ID"
+// @Success 200 {array} models.Event
+// @Failure 404 {object} map[string]string
+// @Router /event-series/{id}/occurrences [get]
+func (h *EventSeriesHandler) GetOccurrences(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	events, err := h.seriesService.GetOccurrences(id)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// Update edits the whole series; upcoming occurrences not edited on their own follow it
+// @Summary Update event series
+// @Tags Event Series
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Series ID"
+// @Param request body eventSeriesRequest true "Series"
+// @Success 200 {object} models.EventSeries
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /event-series/{id} [put]
+func (h *EventSeriesHandler) Update(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req eventSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	series := req.toModel()
+	series.ID = id
+	if err := h.seriesService.Update(actor, series); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+// Delete removes a series and its upcoming occurrences
+// @Summary Delete event series
+// @Tags Event Series
+// @Security BearerAuth
+// @Param id path int true "Series ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /event-series/{id} [delete]
+func (h *EventSeriesHandler) Delete(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.seriesService.Delete(actor, id); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "event series deleted"})
+}
+
+// AddException skips one date of the series
+// @Summary Skip a series occurrence
+// @Tags Event Series
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Series ID"
+// @Param request body object true "{\"date\": \"2026-04-03\"}"
+// @Success 200 {object} models.EventSeries
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /event-series/{id}/exceptions [post]
+func (h *EventSeriesHandler) AddException(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Date string `json:"date" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	series, err := h.seriesService.AddException(actor, id, req.Date)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+func (h *EventSeriesHandler) parseID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid series id"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func (h *EventSeriesHandler) writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidEvent):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err.Error() == "event series not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err.Error() == "occurrence already has attendance":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juank/attendance-backend/internal/domain/services"
+)
+
+type ExportHandler struct {
+	exportService services.ExportService
+}
+
+func NewExportHandler(exportService services.ExportService) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+	}
+}
+
+// ExportEvent exports the attendance of an event
+// @Summary Export event attendance
+// @Tags Export
+// @Security BearerAuth
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param id path int true "Event ID"
+// @Param format query string false "csv (default) or xlsx"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /events/{id}/attendance/export [get]
+func (h *ExportHandler) ExportEvent(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event id"})
+		return
+	}
+
+	export, err := h.exportService.ExportEventAttendance(uint(id), exportFormat(c))
+	h.stream(c, export, err)
+}
+
+// ExportRange exports the attendance of all events in a date range
+// @Summary Export attendance by date range
+// @Tags Export
+// @Security BearerAuth
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param start_date query string true "Start date (YYYY-MM-DD)"
+// @Param end_date query string true "End date (YYYY-MM-DD)"
+// @Param format query string false "csv (default) or xlsx"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Router /attendance/export [get]
+func (h *ExportHandler) ExportRange(c *gin.Context) {
+	startDate, endDate, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	export, err := h.exportService.ExportAttendanceRange(startDate, endDate, exportFormat(c))
+	h.stream(c, export, err)
+}
+
+// ExportUser exports the attendance of a user, optionally limited to a date range
+// @Summary Export user attendance
+// @Tags Export
+// @Security BearerAuth
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param id path int true "User ID"
+// @Param start_date query string false "Start date (YYYY-MM-DD)"
+// @Param end_date query string false "End date (YYYY-MM-DD)"
+// @Param format query string false "csv (default) or xlsx"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/attendance/export [get]
+func (h *ExportHandler) ExportUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var startDate, endDate *time.Time
+	if c.Query("start_date") != "" || c.Query("end_date") != "" {
+		start, end, ok := parseDateRange(c)
+		if !ok {
+			return
+		}
+		startDate, endDate = &start, &end
+	}
+
+	export, err := h.exportService.ExportUserAttendance(uint(id), startDate, endDate, exportFormat(c))
+	h.stream(c, export, err)
+}
+
+// stream writes the export as an attachment. Once the body has started an error can no
+// longer change the status code, so it is only recorded for the request logger.
+func (h *ExportHandler) stream(c *gin.Context, export *services.AttendanceExport, err error) {
+	if err != nil {
+		switch err.Error() {
+		case "event not found", "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.Header("Content-Type", export.ContentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.FileName))
+	c.Status(http.StatusOK)
+
+	if err := export.Write(c.Writer); err != nil {
+		_ = c.Error(err)
+		c.Abort()
+	}
+}
+
+func exportFormat(c *gin.Context) services.ExportFormat {
+	return services.ExportFormat(c.DefaultQuery("format", string(services.ExportCSV)))
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/services"
+)
+
+// currentUser returns the authenticated user ID and role set by AuthMiddleware,
+// writing a 401 response when they are missing
+func currentUser(c *gin.Context) (uint, models.Role, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return 0, "", false
+	}
+
+	role, _ := c.Get("role")
+	roleStr, _ := role.(string)
+
+	return userID.(uint), models.Role(roleStr), true
+}
+
+// currentActor returns the authenticated user together with the IP and user agent of the
+// request, for services that authorize and audit what they do. It writes a 401 response
+// when the user is missing.
+func currentActor(c *gin.Context) (*services.Actor, bool) {
+	userID, role, ok := currentUser(c)
+	if !ok {
+		return nil, false
+	}
+
+	actor := clientActor(c)
+	actor.UserID, actor.Role = userID, role
+	return actor, true
+}
+
+// clientActor describes the client of an unauthenticated request
+func clientActor(c *gin.Context) *services.Actor {
+	return &services.Actor{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// setRetryAfter sets the Retry-After header, in whole seconds rounded up
+func setRetryAfter(c *gin.Context, wait time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int((wait+time.Second-1)/time.Second)))
+}
+
+// parseUintParam reads a numeric path parameter, writing a 400 response with the given
+// message when it is not a valid ID
+func parseUintParam(c *gin.Context, name, message string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// parseDateRange reads the required start_date and end_date query parameters (YYYY-MM-DD),
+// writing a 400 response when they are missing or malformed
+func parseDateRange(c *gin.Context) (time.Time, time.Time, bool) {
+	startDateStr := c.Query("start_date")
+	endDateStr := c.Query("end_date")
+
+	if startDateStr == "" || endDateStr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date and end_date are required"})
+		return time.Time{}, time.Time{}, false
+	}
+
+	startDate, err := time.Parse("2006-01-02", startDateStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid start_date format, use YYYY-MM-DD"})
+		return time.Time{}, time.Time{}, false
+	}
+
+	endDate, err := time.Parse("2006-01-02", endDateStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid end_date format, use YYYY-MM-DD"})
+		return time.Time{}, time.Time{}, false
+	}
+
+	return startDate, endDate, true
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/services"
+)
+
+type LeaveHandler struct {
+	leaveService services.LeaveService
+}
+
+func NewLeaveHandler(leaveService services.LeaveService) *LeaveHandler {
+	return &LeaveHandler{
+		leaveService: leaveService,
+	}
+}
+
+// Create submits a leave request for the current user
+// @Summary Submit leave request
+// @Tags Leave
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.CreateLeaveRequest true "Leave Request"
+// @Success 201 {object} models.LeaveRequest
+// @Failure 400 {object} map[string]string
+// @Router /leave-requests [post]
+func (h *LeaveHandler) Create(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	var req services.CreateLeaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	leave, err := h.leaveService.Create(actor, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, leave)
+}
+
+// GetMine lists the leave requests of the current user
+// @Summary Get my leave requests
+// @Tags Leave
+// @Security BearerAuth
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} map[string]interface{}
+// @Router /leave-requests/me [get]
+func (h *LeaveHandler) GetMine(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	leaves, total, err := h.leaveService.GetUserLeaves(userID.(uint), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  leaves,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// Cancel withdraws a pending leave request of the current user
+// @Summary Cancel leave request
+// @Tags Leave
+// @Security BearerAuth
+// @Param id path int true "Leave Request ID"
+// @Success 200 {object} models.LeaveRequest
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /leave-requests/{id}/cancel [post]
+func (h *LeaveHandler) Cancel(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid leave request id"})
+		return
+	}
+
+	leave, err := h.leaveService.Cancel(actor, uint(id))
+	if err != nil {
+		if err.Error() == "leave request not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, leave)
+}
+
+// GetPending lists the pending requests the current manager or admin can review
+// @Summary Get pending leave requests
+// @Tags Leave
+// @Security BearerAuth
+// @Success 200 {array} models.LeaveRequest
+// @Router /leave-requests/pending [get]
+func (h *LeaveHandler) GetPending(c *gin.Context) {
+	reviewerID, role, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	leaves, err := h.leaveService.GetPendingForReviewer(reviewerID, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, leaves)
+}
+
+// Approve approves a pending leave request
+// @Summary Approve leave request
+// @Tags Leave
+// @Security BearerAuth
+// @Param id path int true "Leave Request ID"
+// @Param request body services.ReviewLeaveRequest false "Review"
+// @Success 200 {object} models.LeaveRequest
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /leave-requests/{id}/approve [post]
+func (h *LeaveHandler) Approve(c *gin.Context) {
+	h.review(c, h.leaveService.Approve)
+}
+
+// Reject rejects a pending leave request
+// @Summary Reject leave request
+// @Tags Leave
+// @Security BearerAuth
+// @Param id path int true "Leave Request ID"
+// @Param request body services.ReviewLeaveRequest false "Review"
+// @Success 200 {object} models.LeaveRequest
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /leave-requests/{id}/reject [post]
+func (h *LeaveHandler) Reject(c *gin.Context) {
+	h.review(c, h.leaveService.Reject)
+}
+
+func (h *LeaveHandler) review(c *gin.Context, decide func(actor *services.Actor, id uint, req *services.ReviewLeaveRequest) (*models.LeaveRequest, error)) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid leave request id"})
+		return
+	}
+
+	var req services.ReviewLeaveRequest
+	// The review note is optional, so an empty body is accepted
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	leave, err := decide(actor, uint(id), &req)
+	if err != nil {
+		switch err.Error() {
+		case "leave request not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "only the department manager can review this request", "you cannot review your own request":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case "leave request has already been reviewed":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, leave)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juank/attendance-backend/internal/domain/services"
+	"github.com/juank/attendance-backend/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// liveRetryMillis is the reconnection delay suggested to EventSource clients
+const liveRetryMillis = 3000
+
+type LiveHandler struct {
+	liveService services.LiveService
+}
+
+func NewLiveHandler(liveService services.LiveService) *LiveHandler {
+	return &LiveHandler{
+		liveService: liveService,
+	}
+}
+
+// Stream pushes the live updates of an event as Server-Sent Events
+// @Summary Live event stream
+// @Description Sends a snapshot event, then attendance and qr_rotated events as they happen.
+// @Description The access token may be passed as access_token for EventSource clients.
+// @Tags Events
+// @Security BearerAuth
+// @Produce text/event-stream
+// @Param id path int true "Event ID"
+// @Param access_token query string false "Access token, when the Authorization header cannot be sent"
+// @Success 200 {string} string "event stream"
+// @Failure 404 {object} map[string]string
+// @Router /events/{id}/live [get]
+func (h *LiveHandler) Stream(c *gin.Context) {
+	id, ok := parseUintParam(c, "id", "invalid event id")
+	if !ok {
+		return
+	}
+
+	started := false
+	send := func(update *services.LiveUpdate) error {
+		if !started {
+			c.Header("Content-Type", "text/event-stream")
+			c.Header("Cache-Control", "no-cache")
+			c.Header("Connection", "keep-alive")
+			c.Header("X-Accel-Buffering", "no") // disable proxy buffering (nginx)
+			c.Status(http.StatusOK)
+			if _, err := fmt.Fprintf(c.Writer, "retry: %d\n\n", liveRetryMillis); err != nil {
+				return err
+			}
+			started = true
+		}
+
+		if err := writeLiveUpdate(c.Writer, update); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	}
+
+	err := h.liveService.Watch(c.Request.Context(), id, send)
+	if err == nil {
+		return
+	}
+	if started {
+		// The client reconnects on its own and receives a fresh snapshot
+		logger.Warn("Live stream closed", zap.Uint("event_id", id), zap.Error(err))
+		return
+	}
+
+	if err.Error() == "event not found" {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// writeLiveUpdate writes an update in SSE framing; heartbeats are comments, which
+// EventSource ignores
+func writeLiveUpdate(w gin.ResponseWriter, update *services.LiveUpdate) error {
+	if update.Type == services.LiveHeartbeat {
+		_, err := fmt.Fprint(w, ": heartbeat\n\n")
+		return err
+	}
+
+	data, err := json.Marshal(update.Data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", update.Type, data)
+	return err
+}
@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juank/attendance-backend/internal/domain/services"
+)
+
+// VerifyMFA godoc
+// @Summary Complete a two-factor login
+// @Description Exchange the challenge token of a login that returned mfa_required, plus a TOTP or recovery code, for the session tokens
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body services.MFAVerifyRequest true "MFA Verify Request"
+// @Success 200 {object} services.TokenResponse
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/mfa/verify [post]
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req services.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.authService.VerifyMFA(clientActor(c), &req)
+	if err != nil {
+		mfaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// BeginMFAEnrollment godoc
+// @Summary Start two-factor enrollment during login
+// @Description For logins that returned mfa_enrollment_required: get the secret to add to an authenticator app
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body services.MFAChallengeRequest true "MFA Challenge Request"
+// @Success 200 {object} services.MFASetup
+// @Failure 401 {object} map[string]string
+// @Router /auth/mfa/setup [post]
+func (h *AuthHandler) BeginMFAEnrollment(c *gin.Context) {
+	var req services.MFAChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	setup, err := h.authService.BeginMFAEnrollment(clientActor(c), &req)
+	if err != nil {
+		mfaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, setup)
+}
+
+// CompleteMFAEnrollment godoc
+// @Summary Finish two-factor enrollment during login
+// @Description Activate the secret from /auth/mfa/setup with a code from the app. Returns the session tokens and the recovery codes.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body services.MFAVerifyRequest true "MFA Verify Request"
+// @Success 200 {object} services.TokenResponse
+// @Failure 401 {object} map[string]string
+// @Router /auth/mfa/activate [post]
+func (h *AuthHandler) CompleteMFAEnrollment(c *gin.Context) {
+	var req services.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.authService.CompleteMFAEnrollment(clientActor(c), &req)
+	if err != nil {
+		mfaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// GetMFAStatus godoc
+// @Summary Get my two-factor status
+// @Tags auth
+// @Produce json
+// @Success 200 {object} services.MFAStatus
+// @Security BearerAuth
+// @Router /users/me/mfa [get]
+func (h *AuthHandler) GetMFAStatus(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.authService.GetMFAStatus(actor)
+	if err != nil {
+		mfaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// SetupMFA godoc
+// @Summary Start two-factor enrollment
+// @Description Generate a new secret for an authenticator app. It takes effect after /users/me/mfa/activate.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} services.MFASetup
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /users/me/mfa/setup [post]
+func (h *AuthHandler) SetupMFA(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	setup, err := h.authService.SetupMFA(actor)
+	if err != nil {
+		mfaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, setup)
+}
+
+// ActivateMFA godoc
+// @Summary Activate two-factor authentication
+// @Description Confirm the secret from /users/me/mfa/setup with a code from the app. Returns the recovery codes, which are shown only once.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body services.MFACodeRequest true "MFA Code Request"
+// @Success 200 {object} services.RecoveryCodesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /users/me/mfa/activate [post]
+func (h *AuthHandler) ActivateMFA(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	var req services.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.authService.ActivateMFA(actor, &req)
+	if err != nil {
+		mfaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+// DisableMFA godoc
+// @Summary Disable two-factor authentication
+// @Description Requires the password and a TOTP or recovery code. Not allowed when the user's role requires MFA.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body services.DisableMFARequest true "Disable MFA Request"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Security BearerAuth
+// @Router /users/me/mfa [delete]
+func (h *AuthHandler) DisableMFA(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	var req services.DisableMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.authService.DisableMFA(actor, &req); err != nil {
+		mfaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Replace my recovery codes
+// @Description Invalidate every recovery code and issue new ones. Requires a TOTP or recovery code.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body services.MFACodeRequest true "MFA Code Request"
+// @Success 200 {object} services.RecoveryCodesResponse
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /users/me/mfa/recovery-codes [post]
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	var req services.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.authService.RegenerateRecoveryCodes(actor, &req)
+	if err != nil {
+		mfaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+// ResetMFA godoc
+// @Summary Reset a user's two-factor authentication
+// @Description Remove MFA from a user who lost their device. Requires users:manage.
+// @Tags auth
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /users/{id}/mfa [delete]
+func (h *AuthHandler) ResetMFA(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseUintParam(c, "id", "invalid user id")
+	if !ok {
+		return
+	}
+
+	if err := h.authService.ResetMFA(actor, id); err != nil {
+		mfaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication reset"})
+}
+
+// mfaError writes the response for an error of the two-factor endpoints
+func mfaError(c *gin.Context, err error) {
+	var throttled *services.LoginThrottledError
+	switch {
+	case errors.As(err, &throttled):
+		setRetryAfter(c, throttled.RetryAfter)
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrMFA):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrUserNotManageable):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch err.Error() {
+	case "invalid or expired challenge token", "invalid password":
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case "two-factor authentication is already enabled":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case "two-factor authentication is not enabled", "two-factor authentication setup has not been started":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case "two-factor authentication is required for your role":
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case "user not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juank/attendance-backend/internal/domain/services"
+)
+
+// BeginOIDCLogin godoc
+// @Summary Start a single sign-on login
+// @Description Get the identity provider URL to send the user to. The provider redirects back to OIDC_REDIRECT_URL with code and state.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} services.OIDCAuthorization
+// @Failure 404 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /auth/oidc/authorize [get]
+func (h *AuthHandler) BeginOIDCLogin(c *gin.Context) {
+	authorization, err := h.authService.BeginOIDCLogin(c.Request.Context())
+	if err != nil {
+		oidcError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, authorization)
+}
+
+// CompleteOIDCLogin godoc
+// @Summary Complete a single sign-on login
+// @Description Exchange the code and state from the identity provider's redirect for the session tokens, or an MFA challenge as in /auth/login
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body services.OIDCCallbackRequest true "OIDC Callback Request"
+// @Success 200 {object} services.TokenResponse
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /auth/oidc/callback [post]
+func (h *AuthHandler) CompleteOIDCLogin(c *gin.Context) {
+	var req services.OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.authService.CompleteOIDCLogin(c.Request.Context(), clientActor(c), &req)
+	if err != nil {
+		oidcError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// oidcError writes the response for an error of a single sign-on login
+func oidcError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrOIDCDisabled):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrOIDCProvider):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrOIDC), err.Error() == "user is inactive":
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -1,20 +1,25 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/juank/attendance-backend/internal/domain/services"
 )
 
 type QRHandler struct {
-	qrService services.QRService
+	qrService      services.QRService
+	qrImageService services.QRImageService
 }
 
-func NewQRHandler(qrService services.QRService) *QRHandler {
+func NewQRHandler(qrService services.QRService, qrImageService services.QRImageService) *QRHandler {
 	return &QRHandler{
-		qrService: qrService,
+		qrService:      qrService,
+		qrImageService: qrImageService,
 	}
 }
 
@@ -27,25 +32,82 @@ func NewQRHandler(qrService services.QRService) *QRHandler {
 // @Failure 500 {object} map[string]string
 // @Router /qr/active [get]
 func (h *QRHandler) GetActive(c *gin.Context) {
-	eventIDStr := c.Query("event_id")
-	if eventIDStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "event_id is required"})
+	eventID, ok := h.eventIDQuery(c)
+	if !ok {
 		return
 	}
 
-	eventID, err := strconv.ParseUint(eventIDStr, 10, 32)
+	qr, err := h.qrService.GetOrCreateActive(eventID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event_id"})
+		if err.Error() == "event not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, qr)
+}
+
+// GetActivePNG renders the active QR code as a PNG image
+// @Summary Get active QR code as PNG
+// @Tags QR
+// @Security BearerAuth
+// @Produce png
+// @Param event_id query int true "Event ID"
+// @Param size query int false "Width in pixels, 64 to 2048 (default 256)"
+// @Param level query string false "Error correction level: L, M, Q or H (default M)"
+// @Param caption query bool false "Print the event title under the code"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /qr/active.png [get]
+func (h *QRHandler) GetActivePNG(c *gin.Context) {
+	h.renderActive(c, services.QRImagePNG)
+}
+
+// GetActiveSVG renders the active QR code as an SVG image
+// @Summary Get active QR code as SVG
+// @Tags QR
+// @Security BearerAuth
+// @Produce image/svg+xml
+// @Param event_id query int true "Event ID"
+// @Param size query int false "Width in pixels, 64 to 2048 (default 256)"
+// @Param level query string false "Error correction level: L, M, Q or H (default M)"
+// @Param caption query bool false "Print the event title under the code"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /qr/active.svg [get]
+func (h *QRHandler) GetActiveSVG(c *gin.Context) {
+	h.renderActive(c, services.QRImageSVG)
+}
+
+// GetPoster downloads a printable PDF poster for a static-QR event
+// @Summary Get printable QR poster
+// @Tags QR
+// @Security BearerAuth
+// @Produce application/pdf
+// @Param event_id query int true "Event ID"
+// @Success 200 {file} file
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /qr/poster.pdf [get]
+func (h *QRHandler) GetPoster(c *gin.Context) {
+	eventID, ok := h.eventIDQuery(c)
+	if !ok {
 		return
 	}
 
-	qr, err := h.qrService.GetOrCreateActive(uint(eventID))
+	poster, err := h.qrImageService.RenderPoster(eventID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.writeImageError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, qr)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("qr-poster-event-%d.pdf", eventID)))
+	h.writeImage(c, poster)
 }
 
 // Generate creates a new QR code (invalidates previous)
@@ -57,6 +119,11 @@ func (h *QRHandler) GetActive(c *gin.Context) {
 // @Failure 500 {object} map[string]string
 // @Router /qr/generate [post]
 func (h *QRHandler) Generate(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
 	var req struct {
 		EventID uint `json:"event_id" binding:"required"`
 	}
@@ -66,8 +133,12 @@ func (h *QRHandler) Generate(c *gin.Context) {
 		return
 	}
 
-	qr, err := h.qrService.GenerateNew(req.EventID)
+	qr, err := h.qrService.GenerateNew(actor, req.EventID)
 	if err != nil {
+		if err.Error() == "event not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -84,6 +155,11 @@ func (h *QRHandler) Generate(c *gin.Context) {
 // @Failure 500 {object} map[string]string
 // @Router /qr/deactivate [post]
 func (h *QRHandler) Deactivate(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
 	var req struct {
 		EventID uint `json:"event_id" binding:"required"`
 	}
@@ -93,7 +169,7 @@ func (h *QRHandler) Deactivate(c *gin.Context) {
 		return
 	}
 
-	if err := h.qrService.DeactivateActiveForEvent(req.EventID); err != nil {
+	if err := h.qrService.DeactivateActiveForEvent(actor, req.EventID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -103,3 +179,60 @@ func (h *QRHandler) Deactivate(c *gin.Context) {
 		"event_id": req.EventID,
 	})
 }
+
+func (h *QRHandler) renderActive(c *gin.Context, format services.QRImageFormat) {
+	eventID, ok := h.eventIDQuery(c)
+	if !ok {
+		return
+	}
+
+	var opts services.QRImageOptions
+	if err := c.ShouldBindQuery(&opts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	image, err := h.qrImageService.RenderActive(eventID, format, &opts)
+	if err != nil {
+		h.writeImageError(c, err)
+		return
+	}
+
+	h.writeImage(c, image)
+}
+
+// writeImage sends a rendered code. Codes rotate, so images must not be cached past
+// the expiry advertised in X-QR-Expires-At.
+func (h *QRHandler) writeImage(c *gin.Context, image *services.QRImage) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-QR-Expires-At", image.QR.ExpiresAt.UTC().Format(time.RFC3339))
+	c.Data(http.StatusOK, image.ContentType, image.Content)
+}
+
+func (h *QRHandler) writeImageError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidQRImage):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err.Error() == "event not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err.Error() == "posters require a static QR code", err.Error() == "event has already ended":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *QRHandler) eventIDQuery(c *gin.Context) (uint, bool) {
+	eventIDStr := c.Query("event_id")
+	if eventIDStr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "event_id is required"})
+		return 0, false
+	}
+
+	eventID, err := strconv.ParseUint(eventIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event_id"})
+		return 0, false
+	}
+	return uint(eventID), true
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juank/attendance-backend/internal/domain/services"
+)
+
+type ReportHandler struct {
+	reportService services.ReportService
+}
+
+func NewReportHandler(reportService services.ReportService) *ReportHandler {
+	return &ReportHandler{
+		reportService: reportService,
+	}
+}
+
+// GetDepartments returns attendance totals for the departments the caller can report on
+// @Summary Get department attendance summaries
+// @Tags Reports
+// @Security BearerAuth
+// @Param start_date query string true "Start date (YYYY-MM-DD)"
+// @Param end_date query string true "End date (YYYY-MM-DD)"
+// @Success 200 {array} models.DepartmentSummary
+// @Failure 400 {object} map[string]string
+// @Router /reports/departments [get]
+func (h *ReportHandler) GetDepartments(c *gin.Context) {
+	viewerID, role, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	startDate, endDate, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	summaries, err := h.reportService.GetDepartmentSummaries(viewerID, role, startDate, endDate)
+	if err != nil {
+		if err.Error() == "end_date must not be before start_date" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, summaries)
+}
+
+// GetDepartment returns a department's attendance broken down per user, event and week
+// @Summary Get department attendance report
+// @Tags Reports
+// @Security BearerAuth
+// @Param id path int true "Department ID"
+// @Param start_date query string true "Start date (YYYY-MM-DD)"
+// @Param end_date query string true "End date (YYYY-MM-DD)"
+// @Success 200 {object} models.DepartmentReport
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /reports/departments/{id} [get]
+func (h *ReportHandler) GetDepartment(c *gin.Context) {
+	viewerID, role, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid department id"})
+		return
+	}
+
+	startDate, endDate, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.reportService.GetDepartmentReport(uint(id), viewerID, role, startDate, endDate)
+	if err != nil {
+		switch err.Error() {
+		case "department not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "you do not manage this department":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case "end_date must not be before start_date":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/services"
+)
+
+type RoleHandler struct {
+	roleService services.RoleService
+}
+
+func NewRoleHandler(roleService services.RoleService) *RoleHandler {
+	return &RoleHandler{
+		roleService: roleService,
+	}
+}
+
+// GetAll lists the system and custom roles
+// @Summary List roles
+// @Tags Roles
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.RoleDefinition
+// @Router /roles [get]
+func (h *RoleHandler) GetAll(c *gin.Context) {
+	roles, err := h.roleService.GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+// GetCatalog lists every permission that can be granted to a role
+// @Summary List permissions
+// @Tags Roles
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.PermissionInfo
+// @Router /roles/permissions [get]
+func (h *RoleHandler) GetCatalog(c *gin.Context) {
+	c.JSON(http.StatusOK, models.PermissionCatalog)
+}
+
+// GetByID returns a role
+// @Summary Get role
+// @Tags Roles
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Role ID"
+// @Success 200 {object} models.RoleDefinition
+// @Failure 404 {object} map[string]string
+// @Router /roles/{id} [get]
+func (h *RoleHandler) GetByID(c *gin.Context) {
+	id, ok := parseUintParam(c, "id", "invalid role id")
+	if !ok {
+		return
+	}
+
+	role, err := h.roleService.GetByID(id)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// GetMine returns the role and effective permissions of the current user, so clients
+// can hide what the user cannot do
+// @Summary Get my permissions
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /users/me/permissions [get]
+func (h *RoleHandler) GetMine(c *gin.Context) {
+	_, role, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	permissions, err := h.roleService.GetPermissions(role)
+	if err != nil {
+		// A role deleted or renamed under a live token grants nothing
+		permissions = models.PermissionList{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"role":        role,
+		"permissions": permissions,
+	})
+}
+
+// Create defines a custom role
+// @Summary Create role
+// @Tags Roles
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.CreateRoleRequest true "Role"
+// @Success 201 {object} models.RoleDefinition
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /roles [post]
+func (h *RoleHandler) Create(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	var req services.CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.roleService.Create(actor, &req)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, role)
+}
+
+// Update changes the description or permissions of a role
+// @Summary Update role
+// @Tags Roles
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Role ID"
+// @Param request body services.UpdateRoleRequest true "Changes"
+// @Success 200 {object} models.RoleDefinition
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /roles/{id} [put]
+func (h *RoleHandler) Update(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+	id, ok := parseUintParam(c, "id", "invalid role id")
+	if !ok {
+		return
+	}
+
+	var req services.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.roleService.Update(actor, id, &req)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// Delete removes a custom role that is not assigned to any user
+// @Summary Delete role
+// @Tags Roles
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /roles/{id} [delete]
+func (h *RoleHandler) Delete(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseUintParam(c, "id", "invalid role id")
+	if !ok {
+		return
+	}
+
+	if err := h.roleService.Delete(actor, id); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "role deleted"})
+}
+
+func (h *RoleHandler) writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err.Error() == "role not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrRoleNotAssignable),
+		err.Error() == "cannot grant permissions you do not have":
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err.Error() == "role already exists",
+		err.Error() == "system roles cannot be deleted",
+		err.Error() == "admin permissions cannot be changed",
+		err.Error() == "role is assigned to users":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
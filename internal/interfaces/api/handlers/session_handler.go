@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juank/attendance-backend/internal/domain/services"
+)
+
+// GetMySessions godoc
+// @Summary List my sessions
+// @Description Devices signed in to the account. The session of the calling token has current set.
+// @Tags auth
+// @Produce json
+// @Success 200 {array} services.Session
+// @Security BearerAuth
+// @Router /users/me/sessions [get]
+func (h *AuthHandler) GetMySessions(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.authService.ListSessions(actor.UserID, c.GetString("sessionID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeMySession godoc
+// @Summary Sign out a session
+// @Description Revoke one of my sessions, such as a lost phone, with its refresh and access tokens
+// @Tags auth
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /users/me/sessions/{id} [delete]
+func (h *AuthHandler) RevokeMySession(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	if err := h.authService.RevokeSession(actor, c.Param("id")); err != nil {
+		if err.Error() == "session not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "session revoked"})
+}
+
+// GetUserSessions godoc
+// @Summary List a user's sessions
+// @Tags auth
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {array} services.Session
+// @Security BearerAuth
+// @Router /users/{id}/sessions [get]
+func (h *AuthHandler) GetUserSessions(c *gin.Context) {
+	id, ok := parseUintParam(c, "id", "invalid user id")
+	if !ok {
+		return
+	}
+
+	sessions, err := h.authService.ListSessions(id, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeUserSessions godoc
+// @Summary Force logout
+// @Description Revoke every session of a user
+// @Tags auth
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /users/{id}/sessions [delete]
+func (h *AuthHandler) RevokeUserSessions(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseUintParam(c, "id", "invalid user id")
+	if !ok {
+		return
+	}
+
+	if err := h.authService.RevokeAllSessions(actor, id); err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserNotManageable):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case err.Error() == "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "all sessions revoked"})
+}
@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/juank/attendance-backend/internal/domain/services"
@@ -19,14 +22,23 @@ func NewUserHandler(userService services.UserService) *UserHandler {
 }
 
 func (h *UserHandler) Create(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
 	var req services.CreateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	user, err := h.userService.Create(&req)
+	user, err := h.userService.Create(actor, &req)
 	if err != nil {
+		if isRoleForbidden(err) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -89,6 +101,11 @@ func (h *UserHandler) GetAll(c *gin.Context) {
 }
 
 func (h *UserHandler) Update(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -102,9 +119,16 @@ func (h *UserHandler) Update(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.Update(uint(id), &req)
+	user, err := h.userService.Update(actor, uint(id), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch {
+		case isRoleForbidden(err):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case err.Error() == "role does not exist":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
@@ -112,6 +136,11 @@ func (h *UserHandler) Update(c *gin.Context) {
 }
 
 func (h *UserHandler) Delete(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -119,7 +148,15 @@ func (h *UserHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.userService.Delete(uint(id)); err != nil {
+	if err := h.userService.Delete(actor, uint(id)); err != nil {
+		if isRoleForbidden(err) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -127,10 +164,37 @@ func (h *UserHandler) Delete(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "user deleted"})
 }
 
+func (h *UserHandler) Unlock(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseUintParam(c, "id", "invalid user id")
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.Unlock(actor, id)
+	if err != nil {
+		if isRoleForbidden(err) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
 func (h *UserHandler) ChangePassword(c *gin.Context) {
-	userID, exists := c.Get("userID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+	actor, ok := currentActor(c)
+	if !ok {
 		return
 	}
 
@@ -140,10 +204,93 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	if err := h.userService.ChangePassword(userID.(uint), &req); err != nil {
+	if err := h.userService.ChangePassword(actor, &req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "password changed successfully"})
 }
+
+// maxImportFileSize caps the size of a user import CSV
+const maxImportFileSize = 10 << 20
+
+// Import creates users from a CSV sent as a multipart file or as the raw request body.
+// Nothing is created unless every row is valid.
+// @Summary Import users from CSV
+// @Tags Users
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Accept text/csv
+// @Produce json
+// @Param file formData file false "CSV with the columns email, first_name, last_name and optionally role, department and password"
+// @Param dry_run query bool false "Validate the file without creating anything"
+// @Param create_departments query bool false "Create the departments that do not exist"
+// @Success 200 {object} services.ImportUsersResult "Dry run"
+// @Success 201 {object} services.ImportUsersResult
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 422 {object} services.ImportUsersResult "Some rows are invalid; nothing was created"
+// @Router /users/import [post]
+func (h *UserHandler) Import(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	var file io.Reader
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+			return
+		}
+		if header.Size > maxImportFileSize {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file is too large"})
+			return
+		}
+		f, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer f.Close()
+		file = f
+	} else {
+		// Also accept the CSV as the raw request body (Content-Type: text/csv)
+		file = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportFileSize)
+	}
+
+	opts := services.ImportUsersOptions{
+		DryRun:            c.Query("dry_run") == "true",
+		CreateDepartments: c.Query("create_departments") == "true",
+		Actor:             actor,
+	}
+
+	result, err := h.userService.ImportUsers(file, opts)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidImport) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if result.Invalid > 0 {
+		c.JSON(http.StatusUnprocessableEntity, result)
+		return
+	}
+
+	status := http.StatusCreated
+	if opts.DryRun {
+		status = http.StatusOK
+	}
+	c.JSON(status, result)
+}
+
+// isRoleForbidden reports whether the error comes from a role the actor cannot assign or manage
+func isRoleForbidden(err error) bool {
+	return errors.Is(err, services.ErrRoleNotAssignable) || errors.Is(err, services.ErrUserNotManageable)
+}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/services"
+)
+
+type WebhookHandler struct {
+	webhookService services.WebhookService
+}
+
+func NewWebhookHandler(webhookService services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// webhookWithSecret is returned when the secret is set, the only time it is shown
+type webhookWithSecret struct {
+	*models.Webhook
+	Secret string `json:"secret"`
+}
+
+// Create registers a webhook endpoint
+// @Summary Register webhook
+// @Tags Webhooks
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.CreateWebhookRequest true "Webhook"
+// @Success 201 {object} webhookWithSecret
+// @Failure 400 {object} map[string]string
+// @Router /webhooks [post]
+func (h *WebhookHandler) Create(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	var req services.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook, err := h.webhookService.Create(actor, &req)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, webhookWithSecret{Webhook: webhook, Secret: webhook.Secret})
+}
+
+// GetAll lists the registered webhooks
+// @Summary List webhooks
+// @Tags Webhooks
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.Webhook
+// @Router /webhooks [get]
+func (h *WebhookHandler) GetAll(c *gin.Context) {
+	webhooks, err := h.webhookService.GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// GetByID returns a webhook
+// @Summary Get webhook
+// @Tags Webhooks
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} models.Webhook
+// @Failure 404 {object} map[string]string
+// @Router /webhooks/{id} [get]
+func (h *WebhookHandler) GetByID(c *gin.Context) {
+	id, ok := parseUintParam(c, "id", "invalid webhook id")
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.GetByID(id)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// Update changes the URL, event types, secret or status of a webhook
+// @Summary Update webhook
+// @Tags Webhooks
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param request body services.UpdateWebhookRequest true "Changes"
+// @Success 200 {object} models.Webhook
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /webhooks/{id} [put]
+func (h *WebhookHandler) Update(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseUintParam(c, "id", "invalid webhook id")
+	if !ok {
+		return
+	}
+
+	var req services.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook, err := h.webhookService.Update(actor, id, &req)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	if req.Secret != nil {
+		c.JSON(http.StatusOK, webhookWithSecret{Webhook: webhook, Secret: webhook.Secret})
+		return
+	}
+	c.JSON(http.StatusOK, webhook)
+}
+
+// Delete removes a webhook; its pending deliveries are not sent
+// @Summary Delete webhook
+// @Tags Webhooks
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseUintParam(c, "id", "invalid webhook id")
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.Delete(actor, id); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "webhook deleted"})
+}
+
+// GetDeliveries returns the delivery log of a webhook
+// @Summary List webhook deliveries
+// @Tags Webhooks
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param status query string false "pending, succeeded or failed"
+// @Param page query int false "Page"
+// @Param limit query int false "Page size"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	id, ok := parseUintParam(c, "id", "invalid webhook id")
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	status := models.WebhookDeliveryStatus(c.Query("status"))
+
+	deliveries, total, err := h.webhookService.GetDeliveries(id, status, page, limit)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  deliveries,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetDelivery returns a delivery with all its attempts
+// @Summary Get webhook delivery
+// @Tags Webhooks
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param deliveryId path int true "Delivery ID"
+// @Success 200 {object} models.WebhookDelivery
+// @Failure 404 {object} map[string]string
+// @Router /webhooks/{id}/deliveries/{deliveryId} [get]
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	id, deliveryID, ok := h.parseDeliveryParams(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.GetDelivery(id, deliveryID)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// RetryDelivery queues a failed delivery again
+// @Summary Retry webhook delivery
+// @Tags Webhooks
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param deliveryId path int true "Delivery ID"
+// @Success 200 {object} models.WebhookDelivery
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /webhooks/{id}/deliveries/{deliveryId}/retry [post]
+func (h *WebhookHandler) RetryDelivery(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+
+	id, deliveryID, ok := h.parseDeliveryParams(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.RetryDelivery(actor, id, deliveryID)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+func (h *WebhookHandler) parseDeliveryParams(c *gin.Context) (uint, uint, bool) {
+	id, ok := parseUintParam(c, "id", "invalid webhook id")
+	if !ok {
+		return 0, 0, false
+	}
+	deliveryID, ok := parseUintParam(c, "deliveryId", "invalid delivery id")
+	if !ok {
+		return 0, 0, false
+	}
+	return id, deliveryID, true
+}
+
+func (h *WebhookHandler) writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidWebhook):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err.Error() == "webhook not found", err.Error() == "delivery not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err.Error() == "only failed deliveries can be retried":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
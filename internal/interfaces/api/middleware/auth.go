@@ -1,14 +1,18 @@
 package middleware
 
 import (
+	"errors"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/juank/attendance-backend/config"
+	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/services"
+	"github.com/juank/attendance-backend/pkg/jwtkeys"
 	"github.com/juank/attendance-backend/pkg/utils"
 )
 
-func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+// AuthMiddleware accepts access tokens that are valid and have not been revoked
+func AuthMiddleware(keys *jwtkeys.KeySet, tokens services.TokenRevocation) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -25,23 +29,54 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 		}
 
 		tokenString := parts[1]
-		claims, err := utils.ValidateToken(tokenString, cfg.JWT.Secret)
+		claims, err := utils.ValidateToken(tokenString, keys)
 		if err != nil {
 			c.JSON(401, gin.H{"error": "Invalid or expired token"})
 			c.Abort()
 			return
 		}
 
+		if err := tokens.Check(claims.UserID, claims.TokenVersion, claims.SessionID); err != nil {
+			if errors.Is(err, services.ErrTokenRevoked) {
+				c.JSON(401, gin.H{"error": "Token has been revoked"})
+			} else {
+				c.JSON(500, gin.H{"error": "Failed to verify token"})
+			}
+			c.Abort()
+			return
+		}
+
 		// Set claims in context
 		c.Set("userID", claims.UserID)
 		c.Set("email", claims.Email)
 		c.Set("role", claims.Role)
+		c.Set("sessionID", claims.SessionID)
+
+		c.Next()
+	}
+}
 
+// QueryTokenMiddleware lets AuthMiddleware accept the access token from the access_token
+// query parameter when no Authorization header is sent. Use it only on routes consumed by
+// clients that cannot set headers, such as EventSource. The token is removed from the
+// request URL so that nothing further down the chain can log it.
+func QueryTokenMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := c.Request.URL.Query()
+		if token := query.Get("access_token"); token != "" {
+			if c.GetHeader("Authorization") == "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+			query.Del("access_token")
+			c.Request.URL.RawQuery = query.Encode()
+		}
 		c.Next()
 	}
 }
 
-func RoleMiddleware(roles ...string) gin.HandlerFunc {
+// PermissionMiddleware lets the request through when the user's role grants any of the
+// given permissions
+func PermissionMiddleware(authz services.Authorizer, permissions ...models.Permission) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userRole, exists := c.Get("role")
 		if !exists {
@@ -50,9 +85,9 @@ func RoleMiddleware(roles ...string) gin.HandlerFunc {
 			return
 		}
 
-		roleStr := userRole.(string)
-		for _, role := range roles {
-			if role == roleStr {
+		role := models.Role(userRole.(string))
+		for _, permission := range permissions {
+			if authz.HasPermission(role, permission) {
 				c.Next()
 				return
 			}
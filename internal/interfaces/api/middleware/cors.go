@@ -19,6 +19,7 @@ func CORSMiddleware(cfg *config.Config) gin.HandlerFunc {
 
 	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
 	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
+	config.ExposeHeaders = []string{"Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining"}
 	config.AllowCredentials = true
 	config.MaxAge = 12 * time.Hour
 
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"net/url"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -13,7 +14,7 @@ func LoggerMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
-		query := c.Request.URL.RawQuery
+		query := redactQuery(c.Request.URL.RawQuery)
 
 		// Process request
 		c.Next()
@@ -49,3 +50,28 @@ func LoggerMiddleware() gin.HandlerFunc {
 		}
 	}
 }
+
+// redactedQueryParams are credentials that may travel in the query string, such as the
+// calendar feed token or the access token of the live stream, and must not reach the logs
+var redactedQueryParams = []string{"token", "access_token"}
+
+func redactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return rawQuery
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "[unparseable query]"
+	}
+	redacted := false
+	for _, name := range redactedQueryParams {
+		if values.Has(name) {
+			values.Set(name, "REDACTED")
+			redacted = true
+		}
+	}
+	if !redacted {
+		return rawQuery
+	}
+	return values.Encode()
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juank/attendance-backend/config"
+	"github.com/juank/attendance-backend/pkg/ratelimit"
+)
+
+// RateLimitMiddleware limits each client IP to RATE_LIMIT_REQUESTS requests per
+// RATE_LIMIT_DURATION with a token bucket, so short bursts are allowed. Rejected requests
+// get a 429 with Retry-After. A non-positive limit disables it.
+func RateLimitMiddleware(cfg *config.Config) gin.HandlerFunc {
+	limiter := ratelimit.NewLimiter(cfg.RateLimit.Requests, cfg.RateLimit.Duration)
+	if limiter == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	limit := strconv.Itoa(limiter.Capacity())
+
+	return func(c *gin.Context) {
+		allowed, remaining, wait := limiter.Allow(c.ClientIP())
+		c.Header("X-RateLimit-Limit", limit)
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int((wait+time.Second-1)/time.Second)))
+			c.JSON(429, gin.H{"error": "rate limit exceeded"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -4,40 +4,92 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/juank/attendance-backend/config"
 	"github.com/juank/attendance-backend/internal/domain/models"
+	"github.com/juank/attendance-backend/internal/domain/services"
 	"github.com/juank/attendance-backend/internal/interfaces/api/handlers"
 	"github.com/juank/attendance-backend/internal/interfaces/api/middleware"
+	"github.com/juank/attendance-backend/pkg/jwtkeys"
 )
 
 type Router struct {
-	cfg               *config.Config
-	authHandler       *handlers.AuthHandler
-	userHandler       *handlers.UserHandler
-	deptHandler       *handlers.DepartmentHandler
-	attendanceHandler *handlers.AttendanceHandler
-	qrHandler         *handlers.QRHandler
-	eventHandler      *handlers.EventHandler
+	cfg                *config.Config
+	authz              services.Authorizer
+	tokens             services.TokenRevocation
+	keys               *jwtkeys.KeySet
+	authHandler        *handlers.AuthHandler
+	userHandler        *handlers.UserHandler
+	deptHandler        *handlers.DepartmentHandler
+	attendanceHandler  *handlers.AttendanceHandler
+	qrHandler          *handlers.QRHandler
+	eventHandler       *handlers.EventHandler
+	participantHandler *handlers.EventParticipantHandler
+	leaveHandler       *handlers.LeaveHandler
+	correctionHandler  *handlers.CorrectionHandler
+	reportHandler      *handlers.ReportHandler
+	exportHandler      *handlers.ExportHandler
+	seriesHandler      *handlers.EventSeriesHandler
+	calendarHandler    *handlers.CalendarHandler
+	webhookHandler     *handlers.WebhookHandler
+	liveHandler        *handlers.LiveHandler
+	roleHandler        *handlers.RoleHandler
+	auditHandler       *handlers.AuditHandler
+	jwksHandler        *handlers.JWKSHandler
 }
 
 func NewRouter(
 	cfg *config.Config,
+	authz services.Authorizer,
+	tokens services.TokenRevocation,
+	keys *jwtkeys.KeySet,
 	authHandler *handlers.AuthHandler,
 	userHandler *handlers.UserHandler,
 	deptHandler *handlers.DepartmentHandler,
 	attendanceHandler *handlers.AttendanceHandler,
 	qrHandler *handlers.QRHandler,
 	eventHandler *handlers.EventHandler,
+	participantHandler *handlers.EventParticipantHandler,
+	leaveHandler *handlers.LeaveHandler,
+	correctionHandler *handlers.CorrectionHandler,
+	reportHandler *handlers.ReportHandler,
+	exportHandler *handlers.ExportHandler,
+	seriesHandler *handlers.EventSeriesHandler,
+	calendarHandler *handlers.CalendarHandler,
+	webhookHandler *handlers.WebhookHandler,
+	liveHandler *handlers.LiveHandler,
+	roleHandler *handlers.RoleHandler,
+	auditHandler *handlers.AuditHandler,
+	jwksHandler *handlers.JWKSHandler,
 ) *Router {
 	return &Router{
-		cfg:               cfg,
-		authHandler:       authHandler,
-		userHandler:       userHandler,
-		deptHandler:       deptHandler,
-		attendanceHandler: attendanceHandler,
-		qrHandler:         qrHandler,
-		eventHandler:      eventHandler,
+		cfg:                cfg,
+		authz:              authz,
+		tokens:             tokens,
+		keys:               keys,
+		authHandler:        authHandler,
+		userHandler:        userHandler,
+		deptHandler:        deptHandler,
+		attendanceHandler:  attendanceHandler,
+		qrHandler:          qrHandler,
+		eventHandler:       eventHandler,
+		participantHandler: participantHandler,
+		leaveHandler:       leaveHandler,
+		correctionHandler:  correctionHandler,
+		reportHandler:      reportHandler,
+		exportHandler:      exportHandler,
+		seriesHandler:      seriesHandler,
+		calendarHandler:    calendarHandler,
+		webhookHandler:     webhookHandler,
+		liveHandler:        liveHandler,
+		roleHandler:        roleHandler,
+		auditHandler:       auditHandler,
+		jwksHandler:        jwksHandler,
 	}
 }
 
+// can requires any of the given permissions
+func (r *Router) can(permissions ...models.Permission) gin.HandlerFunc {
+	return middleware.PermissionMiddleware(r.authz, permissions...)
+}
+
 func (r *Router) Setup(engine *gin.Engine) {
 	// Global Middleware
 	engine.Use(middleware.CORSMiddleware(r.cfg))
@@ -53,8 +105,12 @@ func (r *Router) Setup(engine *gin.Engine) {
 		})
 	})
 
-	// API v1 Group
+	// Public keys that verify our tokens
+	engine.GET("/.well-known/jwks.json", r.jwksHandler.GetJWKS)
+
+	// API v1 Group, rate limited per client IP
 	v1 := engine.Group("/api/v1")
+	v1.Use(middleware.RateLimitMiddleware(r.cfg))
 	{
 		// Auth Routes (Public)
 		auth := v1.Group("/auth")
@@ -63,24 +119,66 @@ func (r *Router) Setup(engine *gin.Engine) {
 			auth.POST("/login", r.authHandler.Login)
 			auth.POST("/refresh", r.authHandler.RefreshToken)
 			auth.POST("/logout", r.authHandler.Logout)
+			auth.POST("/forgot-password", r.authHandler.ForgotPassword)
+			auth.POST("/reset-password", r.authHandler.ResetPassword)
+
+			// Second login step, authenticated by the challenge token returned by /login
+			auth.POST("/mfa/verify", r.authHandler.VerifyMFA)
+			auth.POST("/mfa/setup", r.authHandler.BeginMFAEnrollment)
+			auth.POST("/mfa/activate", r.authHandler.CompleteMFAEnrollment)
+
+			// Single sign-on with the OpenID Connect identity provider
+			auth.GET("/oidc/authorize", r.authHandler.BeginOIDCLogin)
+			auth.POST("/oidc/callback", r.authHandler.CompleteOIDCLogin)
 		}
 
+		// Calendar Feed Routes (Public, authenticated by the per-user feed token because
+		// calendar clients cannot send the Authorization header)
+		calendarFeed := v1.Group("/calendar")
+		{
+			calendarFeed.GET("/feed.ics", r.calendarHandler.GetFeed)
+			calendarFeed.GET("/events/:id", r.calendarHandler.GetEvent)
+		}
+
+		// Live Event Stream (QR managers). Browsers' EventSource cannot send headers, so the
+		// access token may also be passed as the access_token query parameter.
+		v1.GET("/events/:id/live",
+			middleware.QueryTokenMiddleware(),
+			middleware.AuthMiddleware(r.keys, r.tokens),
+			r.can(models.PermQRManage),
+			r.liveHandler.Stream,
+		)
+
 		// Protected Routes
 		protected := v1.Group("/")
-		protected.Use(middleware.AuthMiddleware(r.cfg))
+		protected.Use(middleware.AuthMiddleware(r.keys, r.tokens))
 		{
 			// User Routes
 			users := protected.Group("/users")
 			{
 				users.GET("/me", r.userHandler.GetMe)
+				users.GET("/me/permissions", r.roleHandler.GetMine)
 				users.PUT("/me/password", r.userHandler.ChangePassword)
+				users.GET("/me/mfa", r.authHandler.GetMFAStatus)
+				users.POST("/me/mfa/setup", r.authHandler.SetupMFA)
+				users.POST("/me/mfa/activate", r.authHandler.ActivateMFA)
+				users.POST("/me/mfa/recovery-codes", r.authHandler.RegenerateRecoveryCodes)
+				users.DELETE("/me/mfa", r.authHandler.DisableMFA)
+				users.GET("/me/sessions", r.authHandler.GetMySessions)
+				users.DELETE("/me/sessions/:id", r.authHandler.RevokeMySession)
 
-				// Admin only - using middleware directly instead of sub-group
-				users.POST("", middleware.RoleMiddleware(string(models.RoleAdmin)), r.userHandler.Create)
-				users.GET("", middleware.RoleMiddleware(string(models.RoleAdmin)), r.userHandler.GetAll)
-				users.GET("/:id", middleware.RoleMiddleware(string(models.RoleAdmin)), r.userHandler.GetByID)
-				users.PUT("/:id", middleware.RoleMiddleware(string(models.RoleAdmin)), r.userHandler.Update)
-				users.DELETE("/:id", middleware.RoleMiddleware(string(models.RoleAdmin)), r.userHandler.Delete)
+				// Account management - using middleware directly instead of sub-group
+				users.POST("", r.can(models.PermUsersManage), r.userHandler.Create)
+				users.POST("/import", r.can(models.PermUsersManage), r.userHandler.Import)
+				users.GET("", r.can(models.PermUsersRead), r.userHandler.GetAll)
+				users.GET("/:id", r.can(models.PermUsersRead), r.userHandler.GetByID)
+				users.PUT("/:id", r.can(models.PermUsersManage), r.userHandler.Update)
+				users.DELETE("/:id", r.can(models.PermUsersManage), r.userHandler.Delete)
+				users.POST("/:id/unlock", r.can(models.PermUsersManage), r.userHandler.Unlock)
+				users.DELETE("/:id/mfa", r.can(models.PermUsersManage), r.authHandler.ResetMFA)
+				users.GET("/:id/sessions", r.can(models.PermUsersRead), r.authHandler.GetUserSessions)
+				users.DELETE("/:id/sessions", r.can(models.PermUsersManage), r.authHandler.RevokeUserSessions)
+				users.GET("/:id/attendance/export", r.can(models.PermAttendanceExport), r.exportHandler.ExportUser)
 			}
 
 			// Department Routes
@@ -89,10 +187,10 @@ func (r *Router) Setup(engine *gin.Engine) {
 				departments.GET("", r.deptHandler.GetAll)
 				departments.GET("/:id", r.deptHandler.GetByID)
 
-				// Admin only
-				departments.POST("", middleware.RoleMiddleware(string(models.RoleAdmin)), r.deptHandler.Create)
-				departments.PUT("/:id", middleware.RoleMiddleware(string(models.RoleAdmin)), r.deptHandler.Update)
-				departments.DELETE("/:id", middleware.RoleMiddleware(string(models.RoleAdmin)), r.deptHandler.Delete)
+				// Department management
+				departments.POST("", r.can(models.PermDepartmentsManage), r.deptHandler.Create)
+				departments.PUT("/:id", r.can(models.PermDepartmentsManage), r.deptHandler.Update)
+				departments.DELETE("/:id", r.can(models.PermDepartmentsManage), r.deptHandler.Delete)
 			}
 
 			// Event Routes
@@ -101,21 +199,86 @@ func (r *Router) Setup(engine *gin.Engine) {
 				events.GET("", r.eventHandler.GetAll)
 				events.GET("/:id", r.eventHandler.GetByID)
 
-				// Admin only
-				events.POST("", middleware.RoleMiddleware(string(models.RoleAdmin)), r.eventHandler.Create)
-				events.PUT("/:id", middleware.RoleMiddleware(string(models.RoleAdmin)), r.eventHandler.Update)
-				events.DELETE("/:id", middleware.RoleMiddleware(string(models.RoleAdmin)), r.eventHandler.Delete)
+				// Event management
+				events.POST("", r.can(models.PermEventsWrite), r.eventHandler.Create)
+				events.PUT("/:id", r.can(models.PermEventsWrite), r.eventHandler.Update)
+				events.DELETE("/:id", r.can(models.PermEventsWrite), r.eventHandler.Delete)
+
+				// Event Attendance
+				events.GET("/:id/attendance", r.can(models.PermAttendanceRead), r.eventHandler.GetAttendance)
+				events.GET("/:id/attendance/export", r.can(models.PermAttendanceExport), r.exportHandler.ExportEvent)
+				events.POST("/:id/attendance/manual", r.can(models.PermAttendanceManual), r.eventHandler.MarkManualAttendance)
+				events.POST("/:id/attendance/checkout/manual", r.can(models.PermAttendanceManual), r.eventHandler.MarkManualCheckOut)
+
+				// Event Participants (roster)
+				events.GET("/:id/participants", r.can(models.PermEventsWrite, models.PermAttendanceRead), r.participantHandler.GetAll)
+				events.GET("/:id/participants/users", r.can(models.PermEventsWrite, models.PermAttendanceRead), r.participantHandler.GetExpectedUsers)
+				events.POST("/:id/participants", r.can(models.PermEventsWrite), r.participantHandler.Add)
+				events.DELETE("/:id/participants/:participantId", r.can(models.PermEventsWrite), r.participantHandler.Remove)
+			}
+
+			// Calendar Feed Token Routes
+			calendar := protected.Group("/calendar")
+			{
+				calendar.GET("/token", r.calendarHandler.GetToken)
+				calendar.POST("/token", r.calendarHandler.RotateToken)
+				calendar.DELETE("/token", r.calendarHandler.RevokeToken)
+			}
+
+			// Recurring Event Routes
+			series := protected.Group("/event-series")
+			series.Use(r.can(models.PermEventsWrite))
+			{
+				series.GET("", r.seriesHandler.GetAll)
+				series.POST("", r.seriesHandler.Create)
+				series.GET("/:id", r.seriesHandler.GetByID)
+				series.PUT("/:id", r.seriesHandler.Update)
+				series.DELETE("/:id", r.seriesHandler.Delete)
+				series.GET("/:id/occurrences", r.seriesHandler.GetOccurrences)
+				series.POST("/:id/exceptions", r.seriesHandler.AddException)
+			}
+
+			// Role Routes (users:manage may list roles to assign them)
+			roles := protected.Group("/roles")
+			{
+				roles.GET("", r.can(models.PermRolesManage, models.PermUsersManage), r.roleHandler.GetAll)
+				roles.GET("/permissions", r.can(models.PermRolesManage, models.PermUsersManage), r.roleHandler.GetCatalog)
+				roles.GET("/:id", r.can(models.PermRolesManage, models.PermUsersManage), r.roleHandler.GetByID)
+				roles.POST("", r.can(models.PermRolesManage), r.roleHandler.Create)
+				roles.PUT("/:id", r.can(models.PermRolesManage), r.roleHandler.Update)
+				roles.DELETE("/:id", r.can(models.PermRolesManage), r.roleHandler.Delete)
+			}
 
-				// Admin Event Attendance
-				events.GET("/:id/attendance", middleware.RoleMiddleware(string(models.RoleAdmin)), r.eventHandler.GetAttendance)
-				events.POST("/:id/attendance/manual", middleware.RoleMiddleware(string(models.RoleAdmin)), r.eventHandler.MarkManualAttendance)
+			// Audit Log Routes (read-only; entries are written by the services)
+			audit := protected.Group("/audit-logs")
+			audit.Use(r.can(models.PermAuditRead))
+			{
+				audit.GET("", r.auditHandler.GetAll)
+				audit.GET("/:id", r.auditHandler.GetByID)
 			}
 
-			// QR Routes (Admin only)
+			// Webhook Routes
+			webhooks := protected.Group("/webhooks")
+			webhooks.Use(r.can(models.PermWebhooksManage))
+			{
+				webhooks.GET("", r.webhookHandler.GetAll)
+				webhooks.POST("", r.webhookHandler.Create)
+				webhooks.GET("/:id", r.webhookHandler.GetByID)
+				webhooks.PUT("/:id", r.webhookHandler.Update)
+				webhooks.DELETE("/:id", r.webhookHandler.Delete)
+				webhooks.GET("/:id/deliveries", r.webhookHandler.GetDeliveries)
+				webhooks.GET("/:id/deliveries/:deliveryId", r.webhookHandler.GetDelivery)
+				webhooks.POST("/:id/deliveries/:deliveryId/retry", r.webhookHandler.RetryDelivery)
+			}
+
+			// QR Routes
 			qr := protected.Group("/qr")
-			qr.Use(middleware.RoleMiddleware(string(models.RoleAdmin)))
+			qr.Use(r.can(models.PermQRManage))
 			{
 				qr.GET("/active", r.qrHandler.GetActive)
+				qr.GET("/active.png", r.qrHandler.GetActivePNG)
+				qr.GET("/active.svg", r.qrHandler.GetActiveSVG)
+				qr.GET("/poster.pdf", r.qrHandler.GetPoster)
 				qr.POST("/generate", r.qrHandler.Generate)
 				qr.POST("/deactivate", r.qrHandler.Deactivate)
 			}
@@ -124,9 +287,48 @@ func (r *Router) Setup(engine *gin.Engine) {
 			attendance := protected.Group("/attendance")
 			{
 				attendance.POST("/mark", r.attendanceHandler.MarkAttendance)
+				attendance.POST("/checkout", r.attendanceHandler.CheckOut)
 				attendance.GET("/today", r.attendanceHandler.GetToday)
 				attendance.GET("/history", r.attendanceHandler.GetMyHistory)
 				attendance.GET("/range", r.attendanceHandler.GetByDateRange)
+				attendance.GET("/export", r.can(models.PermAttendanceExport), r.exportHandler.ExportRange)
+				attendance.POST("/:id/corrections", r.correctionHandler.Create)
+				attendance.GET("/:id/revisions", r.correctionHandler.GetHistory)
+			}
+
+			// Attendance Correction Routes
+			corrections := protected.Group("/attendance-corrections")
+			{
+				corrections.GET("/me", r.correctionHandler.GetMine)
+				corrections.POST("/:id/cancel", r.correctionHandler.Cancel)
+
+				// Reviewers of their own departments or of all of them
+				reviewers := r.can(models.PermCorrectionsReview, models.PermCorrectionsReviewAll)
+				corrections.GET("/pending", reviewers, r.correctionHandler.GetPending)
+				corrections.POST("/:id/approve", reviewers, r.correctionHandler.Approve)
+				corrections.POST("/:id/reject", reviewers, r.correctionHandler.Reject)
+			}
+
+			// Report Routes (reports:read covers the departments the user manages, reports:read_all every one)
+			reports := protected.Group("/reports")
+			reports.Use(r.can(models.PermReportsRead, models.PermReportsReadAll))
+			{
+				reports.GET("/departments", r.reportHandler.GetDepartments)
+				reports.GET("/departments/:id", r.reportHandler.GetDepartment)
+			}
+
+			// Leave Request Routes
+			leave := protected.Group("/leave-requests")
+			{
+				leave.POST("", r.leaveHandler.Create)
+				leave.GET("/me", r.leaveHandler.GetMine)
+				leave.POST("/:id/cancel", r.leaveHandler.Cancel)
+
+				// Reviewers of their own departments or of all of them
+				reviewers := r.can(models.PermLeaveReview, models.PermLeaveReviewAll)
+				leave.GET("/pending", reviewers, r.leaveHandler.GetPending)
+				leave.POST("/:id/approve", reviewers, r.leaveHandler.Approve)
+				leave.POST("/:id/reject", reviewers, r.leaveHandler.Reject)
 			}
 		}
 	}
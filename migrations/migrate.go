@@ -62,6 +62,23 @@ func runMigrations(db *gorm.DB) {
 	// (User depende de Department, Department depende de User)
 	log.Println("Step 1: Creating tables without foreign keys...")
 
+	// Los pasos previos solo se ejecutan la primera vez, antes de que existan el índice
+	// único de asistencias y las columnas closed_at de eventos y auto_recorded de asistencias
+	dedupeAttendances := db.Migrator().HasTable(&models.Attendance{}) &&
+		!db.Migrator().HasIndex(&models.Attendance{}, "idx_attendance_event_user")
+	backfillClosedEvents := db.Migrator().HasTable(&models.Event{}) &&
+		!db.Migrator().HasColumn(&models.Event{}, "ClosedAt")
+	backfillAutoRecorded := db.Migrator().HasTable(&models.Attendance{}) &&
+		!db.Migrator().HasColumn(&models.Attendance{}, "AutoRecorded")
+
+	// Paso 0: Eliminar asistencias duplicadas por evento y usuario para poder crear el índice único
+	if dedupeAttendances {
+		log.Println("Step 0: Removing duplicate attendances...")
+		if err := db.Exec(dedupeAttendancesSQL).Error; err != nil {
+			log.Fatalf("Failed to run migrations (step 0): %v", err)
+		}
+	}
+
 	// Guardar configuración original
 	originalConfig := db.Config.DisableForeignKeyConstraintWhenMigrating
 
@@ -72,9 +89,24 @@ func runMigrations(db *gorm.DB) {
 		&models.User{},
 		&models.Department{},
 		&models.Event{},
+		&models.EventSeries{},
 		&models.Attendance{},
 		&models.RefreshToken{},
+		&models.CalendarToken{},
 		&models.QRCode{},
+		&models.EventParticipant{},
+		&models.LeaveRequest{},
+		&models.AttendanceCorrection{},
+		&models.AttendanceRevision{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.WebhookDeliveryAttempt{},
+		&models.RoleDefinition{},
+		&models.AuditLog{},
+		&models.PasswordResetToken{},
+		&models.MFARecoveryCode{},
+		&models.RevokedSession{},
+		&models.OIDCAuthRequest{},
 	); err != nil {
 		log.Fatalf("Failed to run migrations (step 1): %v", err)
 	}
@@ -88,20 +120,112 @@ func runMigrations(db *gorm.DB) {
 		&models.User{},
 		&models.Department{},
 		&models.Event{},
+		&models.EventSeries{},
 		&models.Attendance{},
 		&models.RefreshToken{},
+		&models.CalendarToken{},
 		&models.QRCode{},
+		&models.EventParticipant{},
+		&models.LeaveRequest{},
+		&models.AttendanceCorrection{},
+		&models.AttendanceRevision{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.WebhookDeliveryAttempt{},
+		&models.RoleDefinition{},
+		&models.AuditLog{},
+		&models.PasswordResetToken{},
+		&models.MFARecoveryCode{},
+		&models.RevokedSession{},
+		&models.OIDCAuthRequest{},
 	); err != nil {
 		log.Fatalf("Failed to run migrations (step 2): %v", err)
 	}
 
+	// Paso 3: Proteger el registro de auditoría contra modificaciones
+	log.Println("Step 3: Making the audit log append-only...")
+	if err := db.Exec(auditLogAppendOnlySQL).Error; err != nil {
+		log.Fatalf("Failed to run migrations (step 3): %v", err)
+	}
+
+	// Paso 4: Cada refresh token anterior a las sesiones forma una sesión propia
+	log.Println("Step 4: Assigning sessions to existing refresh tokens...")
+	if err := db.Exec(`UPDATE refresh_tokens
+		SET family_id = 'legacy-' || id, session_started_at = created_at
+		WHERE family_id IS NULL OR family_id = ''`).Error; err != nil {
+		log.Fatalf("Failed to run migrations (step 4): %v", err)
+	}
+
+	// Paso 5: Los eventos terminados antes de existir el cierre automático se dan por cerrados,
+	// para que el job de ausencias no registre ausencias históricas
+	if backfillClosedEvents {
+		log.Println("Step 5: Closing events that ended before absence tracking...")
+		if err := db.Exec(`UPDATE events SET closed_at = NOW()
+			WHERE closed_at IS NULL
+			AND (is_active = false OR (end_time > '0001-01-01' AND end_time <= NOW()))`).Error; err != nil {
+			log.Fatalf("Failed to run migrations (step 5): %v", err)
+		}
+	}
+
+	// Paso 6: Marcar las ausencias que ya había creado el job al cerrar eventos, las únicas que
+	// un permiso aprobado puede convertir en on_leave
+	if backfillAutoRecorded {
+		log.Println("Step 6: Flagging absences recorded by the absence job...")
+		if err := db.Exec(`UPDATE attendances SET auto_recorded = true
+			WHERE (status = 'absent' AND notes = 'No check-in recorded')
+			OR (status = 'on_leave' AND notes LIKE 'On approved % leave (request #%)')`).Error; err != nil {
+			log.Fatalf("Failed to run migrations (step 6): %v", err)
+		}
+	}
+
 	log.Println("Migrations completed successfully")
 
 	// Seed initial data if needed
 	seedData(db)
 }
 
+// auditLogAppendOnlySQL instala un trigger que rechaza UPDATE y DELETE sobre audit_logs,
+// de modo que ni la aplicación ni un error de código puedan reescribir el historial
+const auditLogAppendOnlySQL = `
+CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs;
+CREATE TRIGGER audit_logs_append_only
+	BEFORE UPDATE OR DELETE ON audit_logs
+	FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only();
+`
+
+// dedupeAttendancesSQL conserva una asistencia por evento y usuario: la no eliminada con el
+// check-in más antiguo
+const dedupeAttendancesSQL = `
+DELETE FROM attendances a
+USING (
+	SELECT id, ROW_NUMBER() OVER (
+		PARTITION BY event_id, user_id
+		ORDER BY deleted_at IS NOT NULL, check_in, id
+	) AS rn
+	FROM attendances
+) d
+WHERE a.id = d.id AND d.rn > 1
+`
+
 func seedData(db *gorm.DB) {
+	// Los roles del sistema se crean en cada ejecución si faltan; los existentes no se
+	// tocan para conservar los permisos editados
+	for _, role := range models.SystemRoles() {
+		role := role
+		result := db.Where(models.RoleDefinition{Name: role.Name}).FirstOrCreate(&role)
+		if result.Error != nil {
+			log.Printf("Failed to seed role %s: %v", role.Name, result.Error)
+		} else if result.RowsAffected > 0 {
+			log.Printf("Role %s created", role.Name)
+		}
+	}
+
 	// Verificar si existe el admin
 	var count int64
 	db.Model(&models.User{}).Count(&count)
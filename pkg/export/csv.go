@@ -0,0 +1,42 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// csvFlushEvery indica cada cuántas filas se vuelca el buffer al escritor
+const csvFlushEvery = 500
+
+type csvWriter struct {
+	w    *csv.Writer
+	rows int
+}
+
+// NewCSVWriter crea un RowWriter que escribe CSV
+func NewCSVWriter(w io.Writer) RowWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) WriteRow(cells []string) error {
+	sanitized := make([]string, len(cells))
+	for i, cell := range cells {
+		sanitized[i] = sanitizeCell(cell)
+	}
+
+	if err := c.w.Write(sanitized); err != nil {
+		return err
+	}
+
+	c.rows++
+	if c.rows%csvFlushEvery == 0 {
+		c.w.Flush()
+		return c.w.Error()
+	}
+	return nil
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,25 @@
+package export
+
+import (
+	"strconv"
+	"strings"
+)
+
+// RowWriter escribe filas de celdas de texto en un formato tabular
+type RowWriter interface {
+	WriteRow(cells []string) error
+	// Close vuelca lo pendiente y cierra el formato; no cierra el io.Writer subyacente
+	Close() error
+}
+
+// sanitizeCell neutraliza fórmulas para que una hoja de cálculo no las ejecute al
+// abrir un CSV (inyección CSV). Los números, incluso negativos, se dejan intactos.
+func sanitizeCell(cell string) string {
+	if cell == "" || !strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return cell
+	}
+	if _, err := strconv.ParseFloat(cell, 64); err == nil {
+		return cell
+	}
+	return "'" + cell
+}
@@ -0,0 +1,89 @@
+package geo
+
+import "math"
+
+// earthRadiusMeters es el radio medio de la Tierra usado en los cálculos de distancia
+const earthRadiusMeters = 6371000.0
+
+// Point es una coordenada geográfica en grados decimales
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// Valid indica si la coordenada está dentro de los rangos de latitud y longitud
+func (p Point) Valid() bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180
+}
+
+// Distance retorna la distancia en metros entre dos puntos (fórmula de haversine)
+func Distance(a, b Point) float64 {
+	lat1 := toRadians(a.Lat)
+	lat2 := toRadians(b.Lat)
+	dLat := lat2 - lat1
+	dLng := toRadians(b.Lng - a.Lng)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// PolygonContains indica si p está dentro del polígono (ray casting)
+func PolygonContains(polygon []Point, p Point) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) &&
+			p.Lng < (b.Lng-a.Lng)*(p.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// DistanceToPolygon retorna 0 si p está dentro del polígono, o la distancia en metros
+// al borde más cercano. Usa una proyección equirectangular local, suficientemente
+// precisa para geocercas de unos pocos kilómetros.
+func DistanceToPolygon(polygon []Point, p Point) float64 {
+	if len(polygon) == 0 {
+		return math.Inf(1)
+	}
+	if PolygonContains(polygon, p) {
+		return 0
+	}
+
+	cosLat := math.Cos(toRadians(p.Lat))
+	project := func(q Point) (float64, float64) {
+		x := toRadians(q.Lng-p.Lng) * cosLat * earthRadiusMeters
+		y := toRadians(q.Lat-p.Lat) * earthRadiusMeters
+		return x, y
+	}
+
+	min := math.Inf(1)
+	for i := range polygon {
+		ax, ay := project(polygon[i])
+		bx, by := project(polygon[(i+1)%len(polygon)])
+		if d := distanceToSegment(ax, ay, bx, by); d < min {
+			min = d
+		}
+	}
+	return min
+}
+
+// distanceToSegment retorna la distancia del origen al segmento AB en el plano
+func distanceToSegment(ax, ay, bx, by float64) float64 {
+	dx, dy := bx-ax, by-ay
+	lengthSq := dx*dx + dy*dy
+
+	t := 0.0
+	if lengthSq > 0 {
+		t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/lengthSq))
+	}
+
+	return math.Hypot(ax+t*dx, ay+t*dy)
+}
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
@@ -0,0 +1,173 @@
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ContentType es el tipo MIME de los archivos iCalendar
+const ContentType = "text/calendar; charset=utf-8"
+
+const (
+	prodID        = "-//Attendance Backend//Calendar 1.0//EN"
+	utcLayout     = "20060102T150405Z"
+	localLayout   = "20060102T150405"
+	maxLineOctets = 75
+)
+
+// Calendar es un VCALENDAR (RFC 5545) con sus eventos
+type Calendar struct {
+	Name   string        // X-WR-CALNAME, nombre mostrado por los clientes
+	TTL    time.Duration // intervalo de actualización sugerido a los clientes suscritos
+	Events []Event
+}
+
+// Event es un VEVENT. Con TimeZone las fechas se escriben en hora local con TZID
+// (necesario para que una RRULE respete los cambios de horario); sin él, en UTC.
+type Event struct {
+	UID          string
+	Summary      string
+	Description  string
+	Start        time.Time
+	End          time.Time // opcional
+	TimeZone     string    // zona IANA, opcional
+	RRule        string    // sin el prefijo "RRULE:"
+	ExDates      []time.Time
+	RecurrenceID *time.Time // inicio original de la ocurrencia que este evento reemplaza
+	Modified     time.Time  // DTSTAMP y LAST-MODIFIED
+}
+
+// Write escribe el calendario con finales de línea CRLF y líneas plegadas a 75 octetos
+func (c *Calendar) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	lw := &lineWriter{w: bw}
+
+	lw.line("BEGIN:VCALENDAR")
+	lw.line("VERSION:2.0")
+	lw.line("PRODID:" + prodID)
+	lw.line("CALSCALE:GREGORIAN")
+	lw.line("METHOD:PUBLISH")
+	if c.Name != "" {
+		lw.line("X-WR-CALNAME:" + escapeText(c.Name))
+	}
+	if c.TTL > 0 {
+		ttl := formatDuration(c.TTL)
+		lw.line("REFRESH-INTERVAL;VALUE=DURATION:" + ttl)
+		lw.line("X-PUBLISHED-TTL:" + ttl)
+	}
+	for i := range c.Events {
+		c.Events[i].write(lw)
+	}
+	lw.line("END:VCALENDAR")
+
+	if lw.err != nil {
+		return lw.err
+	}
+	return bw.Flush()
+}
+
+func (e *Event) write(lw *lineWriter) {
+	modified := e.Modified
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+
+	lw.line("BEGIN:VEVENT")
+	lw.line("UID:" + escapeText(e.UID))
+	lw.line("DTSTAMP:" + modified.UTC().Format(utcLayout))
+	lw.line("LAST-MODIFIED:" + modified.UTC().Format(utcLayout))
+	if e.RecurrenceID != nil {
+		lw.line(e.dateTime("RECURRENCE-ID", *e.RecurrenceID))
+	}
+	lw.line(e.dateTime("DTSTART", e.Start))
+	if !e.End.IsZero() {
+		lw.line(e.dateTime("DTEND", e.End))
+	}
+	if e.RRule != "" {
+		lw.line("RRULE:" + e.RRule)
+	}
+	for _, exDate := range e.ExDates {
+		lw.line(e.dateTime("EXDATE", exDate))
+	}
+	lw.line("SUMMARY:" + escapeText(e.Summary))
+	if e.Description != "" {
+		lw.line("DESCRIPTION:" + escapeText(e.Description))
+	}
+	lw.line("END:VEVENT")
+}
+
+// dateTime formatea una propiedad DATE-TIME en UTC o en la zona del evento
+func (e *Event) dateTime(name string, t time.Time) string {
+	if e.TimeZone == "" || e.TimeZone == "UTC" {
+		return name + ":" + t.UTC().Format(utcLayout)
+	}
+	if loc, err := time.LoadLocation(e.TimeZone); err == nil {
+		return name + ";TZID=" + e.TimeZone + ":" + t.In(loc).Format(localLayout)
+	}
+	return name + ":" + t.UTC().Format(utcLayout)
+}
+
+// escapeText escapa un valor TEXT según RFC 5545 §3.3.11
+func escapeText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\n", `\n`,
+		"\r", `\n`,
+	)
+	return replacer.Replace(s)
+}
+
+// formatDuration formatea una duración como DURATION de RFC 5545 (p. ej. PT1H30M)
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	var b strings.Builder
+	b.WriteString("PT")
+	if h := int(d / time.Hour); h > 0 {
+		b.WriteString(strconv.Itoa(h) + "H")
+	}
+	if m := int(d % time.Hour / time.Minute); m > 0 {
+		b.WriteString(strconv.Itoa(m) + "M")
+	}
+	if s := int(d % time.Minute / time.Second); s > 0 || b.Len() == 2 {
+		b.WriteString(strconv.Itoa(s) + "S")
+	}
+	return b.String()
+}
+
+// lineWriter escribe líneas de contenido plegándolas sin partir caracteres UTF-8
+type lineWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (lw *lineWriter) line(s string) {
+	if lw.err != nil {
+		return
+	}
+
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		lw.write(s[:cut] + "\r\n ")
+		s = s[cut:]
+		// Las líneas de continuación empiezan con un espacio que cuenta en el límite
+		limit = maxLineOctets - 1
+	}
+	lw.write(s + "\r\n")
+}
+
+func (lw *lineWriter) write(s string) {
+	if lw.err == nil {
+		_, lw.err = lw.w.WriteString(s)
+	}
+}
@@ -0,0 +1,87 @@
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Algoritmos de firma admitidos
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// rsaKeyBits es el tamaño de las claves RSA generadas
+const rsaKeyBits = 2048
+
+// Key es una clave de firma identificada por su kid. Las claves retiradas no tienen parte
+// privada: solo verifican los tokens que firmaron mientras caducan.
+type Key struct {
+	ID        string
+	Algorithm string
+	Private   crypto.Signer // nil en claves retiradas
+	Public    crypto.PublicKey
+}
+
+// Generate crea una clave nueva para el algoritmo indicado
+func Generate(algorithm string) (*Key, error) {
+	var private crypto.Signer
+	switch algorithm {
+	case AlgRS256:
+		key, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate RSA key: %w", err)
+		}
+		private = key
+	case AlgEdDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate Ed25519 key: %w", err)
+		}
+		private = key
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q (use %s or %s)", algorithm, AlgRS256, AlgEdDSA)
+	}
+
+	id, err := newKeyID()
+	if err != nil {
+		return nil, err
+	}
+	return &Key{ID: id, Algorithm: algorithm, Private: private, Public: private.Public()}, nil
+}
+
+// newKeyID genera un kid con la fecha de creación, para que las claves se ordenen por
+// antigüedad, y un sufijo aleatorio
+func newKeyID() (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("failed to generate key id: %w", err)
+	}
+	return time.Now().UTC().Format("20060102T150405") + "-" + hex.EncodeToString(suffix), nil
+}
+
+// algorithmOf deduce el algoritmo de firma del tipo de clave pública
+func algorithmOf(public crypto.PublicKey) (string, error) {
+	switch public.(type) {
+	case *rsa.PublicKey:
+		return AlgRS256, nil
+	case ed25519.PublicKey:
+		return AlgEdDSA, nil
+	default:
+		return "", fmt.Errorf("unsupported key type %T", public)
+	}
+}
+
+func (k *Key) method() jwt.SigningMethod {
+	if k.Algorithm == AlgEdDSA {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
@@ -0,0 +1,100 @@
+// Package jwtkeys gestiona las claves con las que se firman y verifican los JWT: una clave
+// activa que firma y otras que solo verifican, para rotarlas sin invalidar los tokens
+// emitidos, publicadas como JWKS para que otros servicios verifiquen sin compartir secretos.
+package jwtkeys
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// KeySet firma con la clave activa y verifica con cualquier clave del conjunto según el
+// kid del token. Los tokens sin kid se verifican con el secreto HS256, si se configuró,
+// para aceptar los emitidos antes de pasar a claves asimétricas.
+type KeySet struct {
+	keys   map[string]*Key
+	order  []*Key
+	active *Key
+	secret []byte
+}
+
+// Load carga las claves de dir. Sin dir, el conjunto firma y verifica con HS256 y secret.
+func Load(dir, secret string) (*KeySet, error) {
+	if dir == "" {
+		if secret == "" {
+			return nil, errors.New("a signing secret or a key directory is required")
+		}
+		return &KeySet{secret: []byte(secret)}, nil
+	}
+
+	keys, activeID, err := LoadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	return New(keys, activeID, secret)
+}
+
+// New crea un conjunto con las claves indicadas; activeID debe ser una de ellas con parte
+// privada. secret puede estar vacío.
+func New(keys []*Key, activeID, secret string) (*KeySet, error) {
+	set := &KeySet{keys: make(map[string]*Key, len(keys)), order: keys}
+	if secret != "" {
+		set.secret = []byte(secret)
+	}
+	for _, key := range keys {
+		set.keys[key.ID] = key
+	}
+
+	set.active = set.keys[activeID]
+	if set.active == nil {
+		return nil, errors.New("no active signing key")
+	}
+	if set.active.Private == nil {
+		return nil, fmt.Errorf("active key %s is retired and cannot sign", activeID)
+	}
+	return set, nil
+}
+
+// Sign firma los claims con la clave activa, indicando su kid en la cabecera
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if s.active == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+	}
+
+	token := jwt.NewWithClaims(s.active.method(), claims)
+	token.Header["kid"] = s.active.ID
+	return token.SignedString(s.active.Private)
+}
+
+// Keyfunc elige la clave que verifica un token, para jwt.Parse. Rechaza los tokens cuyo
+// algoritmo no es el de la clave, de modo que una clave pública nunca se use como secreto
+// HMAC.
+func (s *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || s.secret == nil {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return s.secret, nil
+	}
+
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.Public, nil
+}
+
+// JWKS devuelve las claves públicas del conjunto, de la más antigua a la más reciente
+func (s *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(s.order))}
+	for _, key := range s.order {
+		set.Keys = append(set.Keys, key.JWK())
+	}
+	return set
+}
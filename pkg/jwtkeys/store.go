@@ -0,0 +1,188 @@
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Un directorio de claves contiene un fichero <kid>.pem por clave, con la clave privada
+// (PKCS#8, o PKCS#1 para RSA) o, en las claves retiradas, solo la pública, y un fichero
+// active con el kid de la clave que firma.
+const (
+	keyExtension = ".pem"
+	activeFile   = "active"
+)
+
+// LoadDir lee las claves del directorio, ordenadas de la más antigua a la más reciente, y
+// el kid de la clave activa ("" si no hay ninguna)
+func LoadDir(dir string) ([]*Key, string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read key directory: %w", err)
+	}
+
+	var keys []*Key
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, keyExtension) {
+			continue
+		}
+		key, err := readKey(filepath.Join(dir, name))
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", name, err)
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+
+	active, err := os.ReadFile(filepath.Join(dir, activeFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, "", fmt.Errorf("failed to read active key: %w", err)
+	}
+	return keys, strings.TrimSpace(string(active)), nil
+}
+
+func readKey(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	key := &Key{ID: strings.TrimSuffix(filepath.Base(path), keyExtension)}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported key type %T", parsed)
+		}
+		key.Private, key.Public = signer, signer.Public()
+	case "RSA PRIVATE KEY":
+		parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.Private, key.Public = parsed, parsed.Public()
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.Public = parsed
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+
+	if key.Algorithm, err = algorithmOf(key.Public); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Save escribe la clave en el directorio, creándolo si no existe. Las claves privadas solo
+// son legibles por su propietario.
+func Save(dir string, key *Key) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create key directory: %w", err)
+	}
+
+	var block *pem.Block
+	if key.Private != nil {
+		der, err := x509.MarshalPKCS8PrivateKey(key.Private)
+		if err != nil {
+			return err
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	} else {
+		der, err := x509.MarshalPKIXPublicKey(key.Public)
+		if err != nil {
+			return err
+		}
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	}
+
+	return writeFile(filepath.Join(dir, key.ID+keyExtension), pem.EncodeToMemory(block))
+}
+
+// SetActive hace que la clave firme los tokens nuevos. Debe tener parte privada.
+func SetActive(dir, id string) error {
+	key, err := find(dir, id)
+	if err != nil {
+		return err
+	}
+	if key.Private == nil {
+		return fmt.Errorf("key %s is retired and cannot sign", id)
+	}
+	return writeFile(filepath.Join(dir, activeFile), []byte(id+"\n"))
+}
+
+// Retire borra la parte privada de una clave que ya no firma; sigue verificando los
+// tokens emitidos con ella hasta que se elimine
+func Retire(dir, id string) error {
+	key, err := findInactive(dir, id)
+	if err != nil {
+		return err
+	}
+	key.Private = nil
+	return Save(dir, key)
+}
+
+// Remove elimina una clave que ya no firma. Los tokens firmados con ella dejan de ser
+// válidos.
+func Remove(dir, id string) error {
+	if _, err := findInactive(dir, id); err != nil {
+		return err
+	}
+	return os.Remove(filepath.Join(dir, id+keyExtension))
+}
+
+func find(dir, id string) (*Key, error) {
+	keys, _, err := LoadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if key.ID == id {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("key %s not found", id)
+}
+
+func findInactive(dir, id string) (*Key, error) {
+	key, err := find(dir, id)
+	if err != nil {
+		return nil, err
+	}
+	_, active, err := LoadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	if active == id {
+		return nil, fmt.Errorf("key %s is the active key; activate another one first", id)
+	}
+	return key, nil
+}
+
+// writeFile escribe de forma atómica, para que el servidor nunca lea un fichero a medias
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,73 @@
+package mail
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/juank/attendance-backend/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// FileSender guarda cada correo como un archivo .eml en un directorio en lugar de
+// enviarlo. Pensado para desarrollo y pruebas locales.
+type FileSender struct {
+	dir  string
+	from string
+}
+
+// NewFileSender crea un Sender que escribe en dir, creándolo si no existe
+func NewFileSender(dir, from string) (*FileSender, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+	return &FileSender{dir: dir, from: from}, nil
+}
+
+func (s *FileSender) Send(msg Message) error {
+	now := time.Now()
+	body, err := render(s.from, msg, now)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000"), safeName(msg.To))
+	path := filepath.Join(s.dir, name)
+	if err := os.WriteFile(path, body, 0o600); err != nil {
+		return fmt.Errorf("failed to write mail: %w", err)
+	}
+	logger.Info("Mail written to file", zap.String("to", msg.To), zap.String("path", path))
+	return nil
+}
+
+// safeName deja en una dirección solo caracteres válidos en un nombre de archivo
+func safeName(address string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_', r == '@':
+			return r
+		}
+		return '_'
+	}, address)
+}
+
+// LogSender escribe los correos en el log en lugar de enviarlos. Los enlaces que
+// contienen (por ejemplo, los de restablecimiento de contraseña) quedan en el log, así que
+// no debe usarse en producción.
+type LogSender struct{}
+
+// NewLogSender crea un Sender que solo registra los correos
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+func (s *LogSender) Send(msg Message) error {
+	logger.Info("Mail not sent (log driver)",
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.String("body", msg.Body),
+	)
+	return nil
+}
@@ -0,0 +1,52 @@
+package mail
+
+import (
+	"fmt"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"time"
+)
+
+// SMTPConfig son los datos de conexión con el servidor de correo
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string // sin usuario no se autentica
+	Password string
+	From     string // dirección del remitente; admite "Nombre <dirección>"
+}
+
+// SMTPSender envía correos a través de un servidor SMTP. Usa STARTTLS si el servidor lo
+// ofrece, y la autenticación PLAIN de net/smtp solo se permite sobre TLS o con localhost.
+type SMTPSender struct {
+	cfg SMTPConfig
+}
+
+// NewSMTPSender crea un Sender que usa el servidor indicado
+func NewSMTPSender(cfg SMTPConfig) *SMTPSender {
+	return &SMTPSender{cfg: cfg}
+}
+
+func (s *SMTPSender) Send(msg Message) error {
+	body, err := render(s.cfg.From, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	from, err := netmail.ParseAddress(s.cfg.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+	}
+
+	addr := net.JoinHostPort(s.cfg.Host, s.cfg.Port)
+	if err := smtp.SendMail(addr, auth, from.Address, []string{msg.To}, body); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	return nil
+}
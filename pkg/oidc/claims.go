@@ -0,0 +1,66 @@
+package oidc
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Claims son los datos del usuario que el proveedor incluye en el ID token
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	GivenName     string
+	FamilyName    string
+	Groups        []string
+}
+
+// parseClaims extrae los claims de usuario; groupsClaim es el nombre del claim con los
+// grupos, que algunos proveedores llaman de otra forma (roles, cognito:groups...)
+func parseClaims(raw map[string]interface{}, groupsClaim string) *Claims {
+	claims := &Claims{
+		Subject:       stringClaim(raw["sub"]),
+		Email:         strings.TrimSpace(stringClaim(raw["email"])),
+		EmailVerified: boolClaim(raw["email_verified"]),
+		Name:          stringClaim(raw["name"]),
+		GivenName:     stringClaim(raw["given_name"]),
+		FamilyName:    stringClaim(raw["family_name"]),
+	}
+
+	switch groups := raw[groupsClaim].(type) {
+	case []interface{}:
+		for _, group := range groups {
+			if name := stringClaim(group); name != "" {
+				claims.Groups = append(claims.Groups, name)
+			}
+		}
+	case string:
+		// Un único grupo, o una lista separada por espacios o comas
+		claims.Groups = strings.FieldsFunc(groups, func(r rune) bool { return r == ' ' || r == ',' })
+	}
+	return claims
+}
+
+func stringClaim(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	default:
+		return ""
+	}
+}
+
+// boolClaim acepta también "true", que envían algunos proveedores
+func boolClaim(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(v, "true")
+	default:
+		return false
+	}
+}
@@ -0,0 +1,329 @@
+// Package oidc implementa el inicio de sesión con OpenID Connect (flujo authorization code
+// con PKCE) contra un proveedor de identidad: descubrimiento, intercambio del código y
+// verificación del ID token con las claves publicadas por el proveedor.
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/juank/attendance-backend/pkg/jwtkeys"
+)
+
+const (
+	// leeway tolera relojes desfasados entre el proveedor y este servidor
+	leeway = time.Minute
+	// jwksRefreshInterval limita cuántas veces se vuelven a pedir las claves del proveedor
+	// al encontrar un kid desconocido
+	jwksRefreshInterval = time.Minute
+	// maxResponseSize acota las respuestas que se leen del proveedor
+	maxResponseSize = 1 << 20
+)
+
+// signingMethods son los algoritmos de ID token admitidos; nunca HMAC ni none
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// Config identifica al proveedor y a esta aplicación como cliente suyo
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string // vacío para clientes públicos, que se autentican solo con PKCE
+	RedirectURL  string
+	Scopes       []string // por defecto openid, email y profile
+	GroupsClaim  string   // por defecto groups
+}
+
+// metadata es la parte del documento de descubrimiento que se usa
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider es un proveedor de identidad. Descubre su configuración en el primer uso, de
+// modo que el servidor arranca aunque el proveedor no esté disponible.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu     sync.Mutex
+	meta   *metadata
+	keys   jwtkeys.JWKS
+	keysAt time.Time
+}
+
+// NewProvider crea el proveedor descrito por cfg
+func NewProvider(cfg Config) *Provider {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+	cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+	return &Provider{cfg: cfg, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// AuthCodeURL devuelve la URL del proveedor a la que se envía al usuario para iniciar
+// sesión. state y nonce deben ser aleatorios y de un solo uso; verifier es el
+// code_verifier de PKCE, que se presenta luego en Exchange.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {strings.Join(p.cfg.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {CodeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return meta.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Login intercambia el código de autorización y devuelve los claims del ID token, una vez
+// verificados su firma, emisor, audiencia, caducidad y nonce
+func (p *Provider) Login(ctx context.Context, code, verifier, nonce string) (*Claims, error) {
+	idToken, err := p.exchange(ctx, code, verifier)
+	if err != nil {
+		return nil, err
+	}
+	return p.verify(ctx, idToken, nonce)
+}
+
+func (p *Provider) exchange(ctx context.Context, code, verifier string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {verifier},
+		"client_id":     {p.cfg.ClientID},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		// client_secret_basic codifica las credenciales como formulario (RFC 6749 §2.3.1)
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := p.do(req, &body)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %w", err)
+	}
+	if body.Error != "" {
+		return "", fmt.Errorf("token request rejected: %s %s", body.Error, body.ErrorDescription)
+	}
+	if status != http.StatusOK || body.IDToken == "" {
+		return "", fmt.Errorf("token request failed with status %d", status)
+	}
+	return body.IDToken, nil
+}
+
+func (p *Provider) verify(ctx context.Context, idToken, nonce string) (*Claims, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := jwt.NewParser(jwt.WithValidMethods(signingMethods), jwt.WithoutClaimsValidation())
+	raw := jwt.MapClaims{}
+	if _, err := parser.ParseWithClaims(idToken, raw, func(token *jwt.Token) (interface{}, error) {
+		return p.verificationKey(ctx, token)
+	}); err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+
+	now := time.Now()
+	switch {
+	case !raw.VerifyIssuer(meta.Issuer, true):
+		return nil, errors.New("invalid ID token: unexpected issuer")
+	case !raw.VerifyAudience(p.cfg.ClientID, true):
+		return nil, errors.New("invalid ID token: unexpected audience")
+	case !raw.VerifyExpiresAt(now.Add(-leeway).Unix(), true):
+		return nil, errors.New("invalid ID token: expired")
+	case !raw.VerifyIssuedAt(now.Add(leeway).Unix(), false):
+		return nil, errors.New("invalid ID token: issued in the future")
+	case stringClaim(raw["nonce"]) != nonce:
+		return nil, errors.New("invalid ID token: nonce mismatch")
+	}
+	// Con varias audiencias, el token debe haberse emitido para este cliente
+	if azp := stringClaim(raw["azp"]); azp != "" && azp != p.cfg.ClientID {
+		return nil, errors.New("invalid ID token: unexpected authorized party")
+	}
+
+	claims := parseClaims(raw, p.cfg.GroupsClaim)
+	if claims.Subject == "" {
+		return nil, errors.New("invalid ID token: missing subject")
+	}
+	return claims, nil
+}
+
+// verificationKey busca la clave del token en el JWKS del proveedor, volviéndolo a pedir
+// si el kid es desconocido (el proveedor pudo rotar sus claves)
+func (p *Provider) verificationKey(ctx context.Context, token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	p.mu.Lock()
+	jwk := p.lookup(kid)
+	stale := time.Since(p.keysAt) >= jwksRefreshInterval
+	p.mu.Unlock()
+
+	if jwk == nil && stale {
+		if err := p.refreshKeys(ctx); err != nil {
+			return nil, err
+		}
+		p.mu.Lock()
+		jwk = p.lookup(kid)
+		p.mu.Unlock()
+	}
+	if jwk == nil {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	key, err := jwk.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	// El algoritmo del token debe corresponder al tipo de clave
+	ok := false
+	switch key.(type) {
+	case *rsa.PublicKey:
+		_, isRSA := token.Method.(*jwt.SigningMethodRSA)
+		_, isPSS := token.Method.(*jwt.SigningMethodRSAPSS)
+		ok = isRSA || isPSS
+	case *ecdsa.PublicKey:
+		_, ok = token.Method.(*jwt.SigningMethodECDSA)
+	case ed25519.PublicKey:
+		_, ok = token.Method.(*jwt.SigningMethodEd25519)
+	}
+	if !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key, nil
+}
+
+// lookup busca la clave por kid; sin kid, sirve la única clave del proveedor. Requiere p.mu.
+func (p *Provider) lookup(kid string) *jwtkeys.JWK {
+	if kid == "" {
+		if len(p.keys.Keys) == 1 {
+			return &p.keys.Keys[0]
+		}
+		return nil
+	}
+	return p.keys.Key(kid)
+}
+
+func (p *Provider) refreshKeys(ctx context.Context) error {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, meta.JWKSURI, nil)
+	if err != nil {
+		return err
+	}
+	var keys jwtkeys.JWKS
+	status, err := p.do(req, &keys)
+	if err != nil {
+		return fmt.Errorf("failed to fetch provider keys: %w", err)
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("failed to fetch provider keys: status %d", status)
+	}
+
+	p.mu.Lock()
+	p.keys, p.keysAt = keys, time.Now()
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	meta := p.meta
+	p.mu.Unlock()
+	if meta != nil {
+		return meta, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	meta = &metadata{}
+	status, err := p.do(req, meta)
+	if err != nil {
+		return nil, fmt.Errorf("provider discovery failed: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("provider discovery failed: status %d", status)
+	}
+	// El documento debe ser del emisor configurado (OpenID Connect Discovery §4.3)
+	if strings.TrimSuffix(meta.Issuer, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("provider discovery failed: issuer %q does not match %q", meta.Issuer, p.cfg.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("provider discovery failed: incomplete metadata")
+	}
+
+	p.mu.Lock()
+	p.meta = meta
+	p.mu.Unlock()
+	return meta, nil
+}
+
+// do envía la petición y decodifica la respuesta JSON en out, sea cual sea el estado, ya
+// que los errores de OAuth también vienen en JSON
+func (p *Provider) do(req *http.Request, out interface{}) (int, error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return 0, err
+	}
+	if err := json.Unmarshal(body, out); err != nil && resp.StatusCode == http.StatusOK {
+		return 0, fmt.Errorf("invalid response: %w", err)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,89 @@
+package pubsub
+
+import "sync"
+
+// DefaultBuffer es la capacidad del canal de cada suscriptor si no se indica otra
+const DefaultBuffer = 32
+
+// Hub reparte mensajes en memoria entre los suscriptores de un tema, identificado por un
+// ID (por ejemplo, el de un evento). Solo llega a los clientes conectados a esta
+// instancia; no persiste nada.
+type Hub struct {
+	mu     sync.RWMutex
+	topics map[uint]map[chan interface{}]struct{}
+	buffer int
+}
+
+// NewHub crea un hub cuyos suscriptores pueden acumular hasta buffer mensajes sin leer
+func NewHub(buffer int) *Hub {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	return &Hub{
+		topics: make(map[uint]map[chan interface{}]struct{}),
+		buffer: buffer,
+	}
+}
+
+// Subscribe registra un suscriptor del tema. La función devuelta lo da de baja y debe
+// llamarse siempre; es seguro llamarla más de una vez. El canal se cierra si el
+// suscriptor se queda atrás, y en ese caso debe volver a suscribirse.
+func (h *Hub) Subscribe(topic uint) (<-chan interface{}, func()) {
+	ch := make(chan interface{}, h.buffer)
+
+	h.mu.Lock()
+	subscribers, ok := h.topics[topic]
+	if !ok {
+		subscribers = make(map[chan interface{}]struct{})
+		h.topics[topic] = subscribers
+	}
+	subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			h.remove(topic, ch)
+		})
+	}
+}
+
+// Publish entrega el mensaje a los suscriptores del tema sin bloquear. Los que tienen el
+// buffer lleno se dan de baja y su canal se cierra.
+func (h *Hub) Publish(topic uint, msg interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.topics[topic] {
+		select {
+		case ch <- msg:
+		default:
+			h.remove(topic, ch)
+		}
+	}
+}
+
+// Subscribers devuelve cuántos suscriptores tiene el tema
+func (h *Hub) Subscribers(topic uint) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.topics[topic])
+}
+
+// remove da de baja un suscriptor y cierra su canal; requiere tener el lock
+func (h *Hub) remove(topic uint, ch chan interface{}) {
+	subscribers, ok := h.topics[topic]
+	if !ok {
+		return
+	}
+	if _, ok := subscribers[ch]; !ok {
+		return
+	}
+	delete(subscribers, ch)
+	close(ch)
+	if len(subscribers) == 0 {
+		delete(h.topics, topic)
+	}
+}
@@ -0,0 +1,75 @@
+package qrimage
+
+import (
+	"io"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Poster describe un cartel imprimible en A4 con el código en el centro
+type Poster struct {
+	Title   string   // en grande, sobre el código
+	Details []string // líneas bajo el título, p. ej. fecha y lugar
+	Content string   // contenido codificado
+	Footer  string   // instrucciones bajo el código
+	Level   Level
+}
+
+// WritePDF escribe el cartel en PDF. El código se dibuja como vectores, así que se
+// imprime nítido a cualquier tamaño.
+func (p *Poster) WritePDF(w io.Writer) error {
+	level, err := ParseLevel(string(p.Level))
+	if err != nil {
+		return err
+	}
+	q, err := encode(p.Content, level)
+	if err != nil {
+		return err
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(p.Title, true)
+	pdf.SetCreator("attendance-backend", false)
+	pdf.SetMargins(20, 25, 20)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddPage()
+
+	// Las fuentes estándar de PDF usan cp1252, suficiente para textos en español
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pageWidth, _ := pdf.GetPageSize()
+	contentWidth := pageWidth - 40
+
+	pdf.SetFont("Helvetica", "B", 30)
+	pdf.MultiCell(contentWidth, 13, tr(p.Title), "", "C", false)
+	pdf.Ln(3)
+
+	pdf.SetFont("Helvetica", "", 15)
+	pdf.SetTextColor(80, 80, 80)
+	for _, line := range p.Details {
+		pdf.MultiCell(contentWidth, 8, tr(line), "", "C", false)
+	}
+
+	const codeSize = 140.0
+	bitmap := q.Bitmap()
+	module := codeSize / float64(len(bitmap))
+	left := (pageWidth - codeSize) / 2
+	top := pdf.GetY() + 12
+
+	pdf.SetFillColor(0, 0, 0)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				pdf.Rect(left+float64(x)*module, top+float64(y)*module, module, module, "F")
+			}
+		}
+	}
+
+	pdf.SetY(top + codeSize + 10)
+	pdf.SetFont("Helvetica", "B", 17)
+	pdf.SetTextColor(0, 0, 0)
+	if p.Footer != "" {
+		pdf.MultiCell(contentWidth, 9, tr(p.Footer), "", "C", false)
+	}
+
+	return pdf.Output(w)
+}
@@ -0,0 +1,236 @@
+package qrimage
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+	"sync"
+
+	"github.com/skip2/go-qrcode"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Level es el nivel de corrección de errores del código
+type Level string
+
+const (
+	LevelLow      Level = "L" // ~7% recuperable
+	LevelMedium   Level = "M" // ~15% recuperable
+	LevelQuartile Level = "Q" // ~25% recuperable
+	LevelHigh     Level = "H" // ~30% recuperable
+)
+
+// Límites y valores por defecto del tamaño, en píxeles
+const (
+	MinSize     = 64
+	MaxSize     = 2048
+	DefaultSize = 256
+)
+
+// Content types de cada formato
+const (
+	PNGContentType = "image/png"
+	SVGContentType = "image/svg+xml"
+	PDFContentType = "application/pdf"
+)
+
+// Options configura el renderizado. Size es el ancho del código, márgenes incluidos; el
+// pie, si lo hay, se añade debajo.
+type Options struct {
+	Size    int
+	Level   Level
+	Caption string
+}
+
+// ParseLevel interpreta un nivel de corrección; vacío equivale a M
+func ParseLevel(value string) (Level, error) {
+	switch Level(strings.ToUpper(value)) {
+	case "":
+		return LevelMedium, nil
+	case LevelLow, LevelMedium, LevelQuartile, LevelHigh:
+		return Level(strings.ToUpper(value)), nil
+	}
+	return "", errors.New("level must be one of L, M, Q or H")
+}
+
+// Normalize aplica los valores por defecto y valida las opciones
+func (o *Options) Normalize() error {
+	if o.Size == 0 {
+		o.Size = DefaultSize
+	}
+	if o.Size < MinSize || o.Size > MaxSize {
+		return fmt.Errorf("size must be between %d and %d", MinSize, MaxSize)
+	}
+	level, err := ParseLevel(string(o.Level))
+	if err != nil {
+		return err
+	}
+	o.Level = level
+	o.Caption = strings.TrimSpace(o.Caption)
+	return nil
+}
+
+// PNG renderiza el contenido como imagen PNG
+func PNG(content string, opts Options) ([]byte, error) {
+	if err := opts.Normalize(); err != nil {
+		return nil, err
+	}
+	q, err := encode(content, opts.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	code := q.Image(opts.Size)
+	bounds := code.Bounds()
+	captionHeight := 0
+	if opts.Caption != "" {
+		captionHeight = bounds.Dx() / 6
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()+captionHeight))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(img, bounds, code, bounds.Min, draw.Src)
+	if captionHeight > 0 {
+		if err := drawCaption(img, opts.Caption, bounds.Dy(), captionHeight); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG renderiza el contenido como SVG. Cada módulo mide una unidad del viewBox, de modo
+// que la imagen escala sin perder nitidez.
+func SVG(content string, opts Options) ([]byte, error) {
+	if err := opts.Normalize(); err != nil {
+		return nil, err
+	}
+	q, err := encode(content, opts.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	bitmap := q.Bitmap()
+	modules := len(bitmap)
+	viewHeight := float64(modules)
+	if opts.Caption != "" {
+		viewHeight += float64(modules) / 6
+	}
+	height := int(float64(opts.Size) * viewHeight / float64(modules))
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %g" shape-rendering="crispEdges">`+"\n",
+		opts.Size, height, modules, viewHeight)
+	fmt.Fprintf(&buf, `<rect width="100%%" height="100%%" fill="#fff"/>`+"\n")
+
+	buf.WriteString(`<path fill="#000" d="`)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&buf, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	buf.WriteString(`"/>` + "\n")
+
+	if opts.Caption != "" {
+		fontSize := float64(modules) / 12
+		fmt.Fprintf(&buf, `<text x="%g" y="%g" font-family="sans-serif" font-size="%g" text-anchor="middle" dominant-baseline="middle" fill="#000">`,
+			float64(modules)/2, float64(modules)+(viewHeight-float64(modules))/2-fontSize/4, fontSize)
+		if err := xml.EscapeText(&buf, []byte(opts.Caption)); err != nil {
+			return nil, err
+		}
+		buf.WriteString("</text>\n")
+	}
+	buf.WriteString("</svg>\n")
+
+	return buf.Bytes(), nil
+}
+
+func encode(content string, level Level) (*qrcode.QRCode, error) {
+	if content == "" {
+		return nil, errors.New("empty QR content")
+	}
+	return qrcode.New(content, recoveryLevel(level))
+}
+
+func recoveryLevel(level Level) qrcode.RecoveryLevel {
+	switch level {
+	case LevelLow:
+		return qrcode.Low
+	case LevelQuartile:
+		return qrcode.High
+	case LevelHigh:
+		return qrcode.Highest
+	default:
+		return qrcode.Medium
+	}
+}
+
+var (
+	captionFont     *opentype.Font
+	captionFontErr  error
+	captionFontOnce sync.Once
+)
+
+// drawCaption escribe el texto centrado en la franja inferior, recortándolo con puntos
+// suspensivos si no cabe
+func drawCaption(img *image.RGBA, caption string, top, height int) error {
+	captionFontOnce.Do(func() {
+		captionFont, captionFontErr = opentype.Parse(goregular.TTF)
+	})
+	if captionFontErr != nil {
+		return captionFontErr
+	}
+
+	face, err := opentype.NewFace(captionFont, &opentype.FaceOptions{
+		Size:    float64(height) / 2,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return err
+	}
+	defer face.Close()
+
+	width := img.Bounds().Dx()
+	margin := width / 16
+	text := fitText(face, caption, fixed.I(width-2*margin))
+
+	drawer := &font.Drawer{Dst: img, Src: image.NewUniform(color.Black), Face: face}
+	textWidth := drawer.MeasureString(text)
+	metrics := face.Metrics()
+	baseline := top + (height+(metrics.Ascent-metrics.Descent).Ceil())/2
+	drawer.Dot = fixed.Point26_6{X: (fixed.I(width) - textWidth) / 2, Y: fixed.I(baseline)}
+	drawer.DrawString(text)
+	return nil
+}
+
+func fitText(face font.Face, text string, maxWidth fixed.Int26_6) string {
+	if font.MeasureString(face, text) <= maxWidth {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		candidate := strings.TrimSpace(string(runes)) + "…"
+		if font.MeasureString(face, candidate) <= maxWidth {
+			return candidate
+		}
+	}
+	return ""
+}
@@ -0,0 +1,127 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Policy define cómo se penalizan los fallos consecutivos (por ejemplo, de inicio de
+// sesión): tras cada fallo hay que esperar Delay, que se duplica con cada fallo hasta
+// MaxDelay, y al llegar a MaxFailures la clave queda bloqueada durante Lockout. Pasado
+// Lockout sin nuevos fallos, el contador vuelve a cero.
+type Policy struct {
+	Delay       time.Duration
+	MaxDelay    time.Duration
+	MaxFailures int
+	Lockout     time.Duration
+}
+
+// Expired indica si los fallos registrados, el último en last, ya no cuentan
+func (p Policy) Expired(last, now time.Time) bool {
+	return now.Sub(last) >= p.Lockout
+}
+
+// Locked indica si failures fallos bloquean la clave
+func (p Policy) Locked(failures int) bool {
+	return p.MaxFailures > 0 && failures >= p.MaxFailures
+}
+
+// RetryAt devuelve el momento a partir del cual se admite un nuevo intento tras failures
+// fallos, el último en last
+func (p Policy) RetryAt(failures int, last time.Time) time.Time {
+	if failures <= 0 {
+		return time.Time{}
+	}
+	if p.Locked(failures) {
+		return last.Add(p.Lockout)
+	}
+	delay := p.Delay
+	for i := 1; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return last.Add(delay)
+}
+
+// FailureTracker cuenta en memoria los fallos consecutivos por clave según una Policy
+type FailureTracker struct {
+	mu        sync.Mutex
+	policy    Policy
+	failures  map[string]*failureState
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+type failureState struct {
+	count int
+	last  time.Time
+}
+
+// NewFailureTracker crea un contador de fallos con la política indicada
+func NewFailureTracker(policy Policy) *FailureTracker {
+	return &FailureTracker{
+		policy:   policy,
+		failures: make(map[string]*failureState),
+		now:      time.Now,
+	}
+}
+
+// Wait devuelve cuánto debe esperar la clave antes de un nuevo intento; 0 si puede ya
+func (t *FailureTracker) Wait(key string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	state, ok := t.failures[key]
+	if !ok {
+		return 0
+	}
+	if t.policy.Expired(state.last, now) {
+		delete(t.failures, key)
+		return 0
+	}
+	if wait := t.policy.RetryAt(state.count, state.last).Sub(now); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// Fail registra un fallo de la clave y devuelve el total de fallos consecutivos
+func (t *FailureTracker) Fail(key string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.prune(now)
+
+	state, ok := t.failures[key]
+	if !ok || t.policy.Expired(state.last, now) {
+		state = &failureState{}
+		t.failures[key] = state
+	}
+	state.count++
+	state.last = now
+	return state.count
+}
+
+// Reset olvida los fallos de la clave
+func (t *FailureTracker) Reset(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.failures, key)
+}
+
+// prune descarta, como mucho una vez por Lockout, las claves cuyos fallos ya han caducado
+func (t *FailureTracker) prune(now time.Time) {
+	if now.Sub(t.lastPrune) < t.policy.Lockout {
+		return
+	}
+	t.lastPrune = now
+	for key, state := range t.failures {
+		if t.policy.Expired(state.last, now) {
+			delete(t.failures, key)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter aplica un token bucket por clave (por ejemplo, la IP del cliente). Cada bucket
+// admite ráfagas de hasta capacity peticiones y se rellena a razón de capacity por period.
+// El estado vive en memoria y es propio de cada instancia.
+type Limiter struct {
+	mu        sync.Mutex
+	capacity  float64
+	rate      float64 // tokens por segundo
+	period    time.Duration
+	buckets   map[string]*bucket
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter crea un limitador de capacity peticiones por period. Devuelve nil si alguno
+// de los dos no es positivo; un Limiter nil lo permite todo.
+func NewLimiter(capacity int, period time.Duration) *Limiter {
+	if capacity <= 0 || period <= 0 {
+		return nil
+	}
+	return &Limiter{
+		capacity: float64(capacity),
+		rate:     float64(capacity) / period.Seconds(),
+		period:   period,
+		buckets:  make(map[string]*bucket),
+		now:      time.Now,
+	}
+}
+
+// Capacity devuelve el número máximo de peticiones seguidas que admite una clave
+func (l *Limiter) Capacity() int {
+	if l == nil {
+		return 0
+	}
+	return int(l.capacity)
+}
+
+// Allow consume un token de la clave. Devuelve si la petición se admite, los tokens que
+// quedan y, si se rechaza, cuánto falta para el siguiente token.
+func (l *Limiter) Allow(key string) (bool, int, time.Duration) {
+	if l == nil {
+		return true, 0, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.capacity, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.capacity, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		return false, 0, wait
+	}
+	b.tokens--
+	return true, int(b.tokens), 0
+}
+
+// prune descarta, como mucho una vez por periodo, los buckets que ya se han rellenado del
+// todo, que son indistinguibles de uno nuevo
+func (l *Limiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < l.period {
+		return
+	}
+	l.lastPrune = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= l.period {
+			delete(l.buckets, key)
+		}
+	}
+}
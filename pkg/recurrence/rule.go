@@ -0,0 +1,245 @@
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+// maxPeriods acota la búsqueda de ocurrencias (más de 270 años con FREQ=DAILY)
+const maxPeriods = 100000
+
+const untilLayout = "20060102"
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Rule es el subconjunto de RRULE (RFC 5545) soportado:
+// FREQ=DAILY|WEEKLY|MONTHLY, INTERVAL, BYDAY (sólo semanal), UNTIL y COUNT
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []time.Weekday
+	Until    *time.Time // fecha local inclusive, a medianoche UTC
+	Count    int
+}
+
+// Parse interpreta una regla como "FREQ=WEEKLY;BYDAY=MO,WE;UNTIL=20261231".
+// Acepta el prefijo opcional "RRULE:".
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, errors.New("empty rule")
+	}
+
+	rule := &Rule{Interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid rule part %q", part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = Frequency(strings.ToUpper(value))
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 365 {
+				return nil, errors.New("INTERVAL must be between 1 and 365")
+			}
+			rule.Interval = n
+		case "BYDAY":
+			for _, code := range strings.Split(strings.ToUpper(value), ",") {
+				day, ok := weekdayCodes[code]
+				if !ok {
+					return nil, fmt.Errorf("invalid BYDAY value %q", code)
+				}
+				rule.ByDay = append(rule.ByDay, day)
+			}
+		case "UNTIL":
+			// Sólo se usa la fecha: UNTIL=20261231 o UNTIL=20261231T235959Z
+			if len(value) < len(untilLayout) {
+				return nil, errors.New("UNTIL must be a date like 20261231")
+			}
+			until, err := time.Parse(untilLayout, value[:len(untilLayout)])
+			if err != nil {
+				return nil, errors.New("UNTIL must be a date like 20261231")
+			}
+			rule.Until = &until
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, errors.New("COUNT must be a positive number")
+			}
+			rule.Count = n
+		default:
+			return nil, fmt.Errorf("unsupported rule part %q", key)
+		}
+	}
+
+	switch rule.Freq {
+	case Daily, Monthly:
+		if len(rule.ByDay) > 0 {
+			return nil, errors.New("BYDAY is only supported with FREQ=WEEKLY")
+		}
+	case Weekly:
+	case "":
+		return nil, errors.New("FREQ is required")
+	default:
+		return nil, errors.New("FREQ must be DAILY, WEEKLY or MONTHLY")
+	}
+
+	if rule.Until != nil && rule.Count > 0 {
+		return nil, errors.New("UNTIL and COUNT cannot be combined")
+	}
+
+	rule.ByDay = normalizeWeekdays(rule.ByDay)
+	return rule, nil
+}
+
+// String retorna la regla en forma canónica
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			codes = append(codes, weekdayCode(day))
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.Format(untilLayout))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	return strings.Join(parts, ";")
+}
+
+// ICal retorna la regla como RRULE de RFC 5545 para un DTSTART con TZID en loc.
+// UNTIL se expresa como el último instante del día en UTC, como exige la RFC.
+func (r *Rule) ICal(loc *time.Location) string {
+	if r.Until == nil {
+		return r.String()
+	}
+	rule := *r
+	rule.Until = nil
+	until := time.Date(r.Until.Year(), r.Until.Month(), r.Until.Day(), 23, 59, 59, 0, loc).UTC()
+	return rule.String() + ";UNTIL=" + until.Format("20060102T150405Z")
+}
+
+// Between retorna los inicios de las ocurrencias de una serie que empieza en start
+// y que caen en [from, to]. Las ocurrencias conservan la hora local de start en su
+// zona horaria, también al cruzar cambios de horario. COUNT se cuenta desde start.
+func (r *Rule) Between(start, from, to time.Time) []time.Time {
+	var occurrences []time.Time
+	loc := start.Location()
+	emitted := 0
+
+	for period := 0; period < maxPeriods; period++ {
+		for _, day := range r.periodDays(start, period) {
+			if day.Before(dateOf(start)) {
+				continue
+			}
+			if r.Until != nil && day.After(*r.Until) {
+				return occurrences
+			}
+			if r.Count > 0 && emitted >= r.Count {
+				return occurrences
+			}
+
+			t := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), start.Second(), 0, loc)
+			if t.After(to) {
+				return occurrences
+			}
+			emitted++
+			if !t.Before(from) {
+				occurrences = append(occurrences, t)
+			}
+		}
+	}
+
+	return occurrences
+}
+
+// periodDays retorna las fechas candidatas (medianoche UTC) del período n de la regla
+func (r *Rule) periodDays(start time.Time, n int) []time.Time {
+	first := dateOf(start)
+
+	switch r.Freq {
+	case Daily:
+		return []time.Time{first.AddDate(0, 0, n*r.Interval)}
+
+	case Weekly:
+		days := r.ByDay
+		if len(days) == 0 {
+			days = []time.Weekday{start.Weekday()}
+		}
+		monday := first.AddDate(0, 0, -((int(first.Weekday())+6)%7)+7*n*r.Interval)
+		dates := make([]time.Time, 0, len(days))
+		for _, day := range days {
+			dates = append(dates, monday.AddDate(0, 0, (int(day)+6)%7))
+		}
+		return dates
+
+	default:
+		year, month := first.Year(), first.Month()+time.Month(n*r.Interval)
+		candidate := time.Date(year, month, first.Day(), 0, 0, 0, 0, time.UTC)
+		// Los meses sin ese día (p. ej. 31) se omiten, como en RFC 5545
+		if candidate.Day() != first.Day() {
+			return nil
+		}
+		return []time.Time{candidate}
+	}
+}
+
+// dateOf retorna la fecha local de t a medianoche UTC
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// normalizeWeekdays ordena los días de lunes a domingo y quita duplicados
+func normalizeWeekdays(days []time.Weekday) []time.Weekday {
+	seen := make(map[time.Weekday]bool, len(days))
+	unique := make([]time.Weekday, 0, len(days))
+	for _, day := range days {
+		if !seen[day] {
+			seen[day] = true
+			unique = append(unique, day)
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool {
+		return (int(unique[i])+6)%7 < (int(unique[j])+6)%7
+	})
+	return unique
+}
+
+func weekdayCode(day time.Weekday) string {
+	for code, d := range weekdayCodes {
+		if d == day {
+			return code
+		}
+	}
+	return ""
+}
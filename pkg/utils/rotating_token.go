@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rotatingTokenPrefix identifica los tokens QR dinámicos frente a los UUID estáticos
+const rotatingTokenPrefix = "dq1"
+
+// GenerateSecret genera un secreto aleatorio de n bytes codificado en hex
+func GenerateSecret(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// RotationCounter retorna la ventana de tiempo a la que pertenece t
+func RotationCounter(t time.Time, period time.Duration) int64 {
+	return t.Unix() / int64(period/time.Second)
+}
+
+// GenerateRotatingToken genera el token de una ventana: dq1.<eventID>.<counter>.<hmac>
+func GenerateRotatingToken(secret string, eventID uint, counter int64) string {
+	return fmt.Sprintf("%s.%d.%d.%s", rotatingTokenPrefix, eventID, counter, rotatingSignature(secret, eventID, counter))
+}
+
+// IsRotatingToken indica si el token tiene el formato de un QR dinámico
+func IsRotatingToken(token string) bool {
+	return strings.HasPrefix(token, rotatingTokenPrefix+".")
+}
+
+// ParseRotatingToken extrae el evento y la ventana de un token dinámico sin verificar la firma
+func ParseRotatingToken(token string) (uint, int64, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 || parts[0] != rotatingTokenPrefix {
+		return 0, 0, errors.New("malformed rotating token")
+	}
+
+	eventID, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return 0, 0, errors.New("malformed rotating token")
+	}
+
+	counter, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("malformed rotating token")
+	}
+
+	return uint(eventID), counter, nil
+}
+
+// VerifyRotatingToken verifica la firma del token y que su ventana esté dentro de la
+// tolerancia (skew ventanas antes o después de la actual)
+func VerifyRotatingToken(token, secret string, now time.Time, period time.Duration, skew int64) bool {
+	eventID, counter, err := ParseRotatingToken(token)
+	if err != nil {
+		return false
+	}
+
+	current := RotationCounter(now, period)
+	if counter < current-skew || counter > current+skew {
+		return false
+	}
+
+	expected := GenerateRotatingToken(secret, eventID, counter)
+	return hmac.Equal([]byte(token), []byte(expected))
+}
+
+func rotatingSignature(secret string, eventID uint, counter int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d:%d", eventID, counter)
+	// 16 bytes son suficientes para una ventana de pocos segundos y mantienen el QR pequeño
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
@@ -3,50 +3,88 @@ package utils
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 	"github.com/juank/attendance-backend/config"
+	"github.com/juank/attendance-backend/pkg/jwtkeys"
+)
+
+// AccessAudience es el aud de los access tokens. Los servicios que verifican tokens con
+// el JWKS deben exigirlo: los refresh tokens y los de desafío MFA se firman con las mismas
+// claves pero llevan otro aud y no dan acceso a la API.
+const AccessAudience = "attendance-api"
+
+// refreshAudience es el aud de los refresh tokens, que solo sirven para /auth/refresh
+const refreshAudience = "attendance-refresh"
+
+// Valores de token_use, que repite el tipo de token para los verificadores que no miran aud
+const (
+	tokenUseAccess  = "access"
+	tokenUseRefresh = "refresh"
 )
 
 type TokenClaims struct {
 	UserID uint   `json:"user_id"`
 	Email  string `json:"email"`
 	Role   string `json:"role"`
+	// SessionID identifica la familia de refresh tokens con la que se emitió el token
+	SessionID string `json:"sid,omitempty"`
+	// TokenVersion es la versión de tokens del usuario al emitirlo; al incrementarla se
+	// revocan sus access tokens
+	TokenVersion int    `json:"ver,omitempty"`
+	TokenUse     string `json:"token_use,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateTokenPair genera un access token y un refresh token
-func GenerateTokenPair(userID uint, email, role string, cfg *config.Config) (string, string, error) {
+// refreshTokenClaims son los claims de un refresh token
+type refreshTokenClaims struct {
+	TokenUse string `json:"token_use"`
+	jwt.RegisteredClaims
+}
+
+// GenerateTokenPair genera un access token y un refresh token para la sesión indicada,
+// firmados con la clave activa de keys
+func GenerateTokenPair(userID uint, email, role, sessionID string, tokenVersion int, keys *jwtkeys.KeySet, cfg *config.Config) (string, string, error) {
 	// Access Token
 	accessTokenClaims := TokenClaims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
+		UserID:       userID,
+		Email:        email,
+		Role:         role,
+		SessionID:    sessionID,
+		TokenVersion: tokenVersion,
+		TokenUse:     tokenUseAccess,
 		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{AccessAudience},
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(cfg.JWT.Expiration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "attendance-backend",
 		},
 	}
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessTokenClaims)
-	accessTokenString, err := accessToken.SignedString([]byte(cfg.JWT.Secret))
+	accessTokenString, err := keys.Sign(accessTokenClaims)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to sign access token: %w", err)
 	}
 
 	// Refresh Token (usamos un string aleatorio o un JWT con mayor duración)
 	// Aquí usaremos un JWT simple para el refresh token también, pero podría ser un UUID
-	refreshTokenClaims := jwt.RegisteredClaims{
-		Subject:   fmt.Sprintf("%d", userID),
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(cfg.JWT.RefreshExpiration)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
-		Issuer:    "attendance-backend",
+	// El ID aleatorio evita que dos tokens emitidos en el mismo segundo sean idénticos
+	refreshClaims := refreshTokenClaims{
+		TokenUse: tokenUseRefresh,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   fmt.Sprintf("%d", userID),
+			Audience:  jwt.ClaimStrings{refreshAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(cfg.JWT.RefreshExpiration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    "attendance-backend",
+		},
 	}
 
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshTokenClaims)
-	refreshTokenString, err := refreshToken.SignedString([]byte(cfg.JWT.Secret))
+	refreshTokenString, err := keys.Sign(refreshClaims)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to sign refresh token: %w", err)
 	}
@@ -54,22 +92,96 @@ func GenerateTokenPair(userID uint, email, role string, cfg *config.Config) (str
 	return accessTokenString, refreshTokenString, nil
 }
 
-// ValidateToken valida el token y retorna los claims
-func ValidateToken(tokenString string, secret string) (*TokenClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(secret), nil
-	})
+// ValidateToken valida un access token con la clave de keys indicada en su kid y retorna
+// los claims. Rechaza los refresh tokens y los tokens de desafío MFA.
+func ValidateToken(tokenString string, keys *jwtkeys.KeySet) (*TokenClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, keys.Keyfunc)
 
 	if err != nil {
 		return nil, err
 	}
 
 	if claims, ok := token.Claims.(*TokenClaims); ok && token.Valid {
-		return claims, nil
+		if claims.VerifyAudience(AccessAudience, true) && claims.TokenUse == tokenUseAccess {
+			return claims, nil
+		}
+		// Los access tokens emitidos antes de existir aud no lo llevan; se distinguen de los
+		// refresh tokens de entonces porque solo ellos tienen user_id
+		if len(claims.Audience) == 0 && claims.TokenUse == "" && claims.UserID != 0 {
+			return claims, nil
+		}
 	}
 
 	return nil, errors.New("invalid token")
 }
+
+// ValidateRefreshToken valida un refresh token de GenerateTokenPair
+func ValidateRefreshToken(tokenString string, keys *jwtkeys.KeySet) error {
+	token, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, keys.Keyfunc)
+	if err != nil {
+		return err
+	}
+
+	claims, ok := token.Claims.(*TokenClaims)
+	if !ok || !token.Valid {
+		return errors.New("invalid refresh token")
+	}
+	if claims.VerifyAudience(refreshAudience, true) && claims.TokenUse == tokenUseRefresh {
+		return nil
+	}
+	// Refresh tokens emitidos antes de existir aud: sin aud, sin user_id y con sub
+	if len(claims.Audience) == 0 && claims.TokenUse == "" && claims.UserID == 0 && claims.Subject != "" {
+		return nil
+	}
+	return errors.New("invalid refresh token")
+}
+
+// challengeAudience distingue los tokens de desafío MFA de los de acceso y refresco
+const challengeAudience = "mfa_challenge"
+
+// ChallengeClaims identifican a un usuario que ha superado la contraseña pero aún debe
+// completar el segundo factor
+type ChallengeClaims struct {
+	Purpose string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+// GenerateChallengeToken genera un token de desafío MFA para el usuario, válido durante ttl
+func GenerateChallengeToken(userID uint, purpose string, keys *jwtkeys.KeySet, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := ChallengeClaims{
+		Purpose: purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(uint64(userID), 10),
+			Audience:  jwt.ClaimStrings{challengeAudience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    "attendance-backend",
+		},
+	}
+
+	token, err := keys.Sign(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign challenge token: %w", err)
+	}
+	return token, nil
+}
+
+// ValidateChallengeToken valida un token de GenerateChallengeToken y retorna el usuario y
+// el propósito
+func ValidateChallengeToken(tokenString string, keys *jwtkeys.KeySet) (uint, string, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ChallengeClaims{}, keys.Keyfunc)
+	if err != nil {
+		return 0, "", err
+	}
+
+	claims, ok := token.Claims.(*ChallengeClaims)
+	if !ok || !token.Valid || !claims.VerifyAudience(challengeAudience, true) {
+		return 0, "", errors.New("invalid challenge token")
+	}
+	userID, err := strconv.ParseUint(claims.Subject, 10, 32)
+	if err != nil {
+		return 0, "", errors.New("invalid challenge token")
+	}
+	return uint(userID), claims.Purpose, nil
+}